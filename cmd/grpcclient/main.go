@@ -0,0 +1,63 @@
+// Command grpcclient is an example client for the CardValidator gRPC service
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/jamesmeyerr/credit-card-validator/internal/api/validatorpb"
+)
+
+func main() {
+	// Get server address from environment or use default. The server only listens for gRPC
+	// when started with GRPC_PORT set, e.g. GRPC_PORT=9090.
+	addr := os.Getenv("GRPC_ADDR")
+	if addr == "" {
+		addr = "localhost:9090"
+	}
+
+	conn, err := grpc.Dial(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to connect to %s: %v\n", addr, err)
+		os.Exit(1)
+	}
+	defer conn.Close()
+
+	client := validatorpb.NewCardValidatorClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Validate a single card
+	resp, err := client.Validate(ctx, &validatorpb.ValidateRequest{
+		CardNumber: "4111 1111 1111 1111",
+		ExpiryDate: "12/30",
+		Cvv:        "123",
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Validate failed: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Validate: valid=%t network=%s message=%q\n", resp.Valid, resp.Network, resp.Message)
+
+	// Validate a batch of cards
+	batch, err := client.ValidateBatch(ctx, &validatorpb.ValidateBatchRequest{
+		Requests: []*validatorpb.ValidateRequest{
+			{CardNumber: "378282246310005"},
+			{CardNumber: "5555555555554444"},
+			{CardNumber: "1234567890123456"},
+		},
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ValidateBatch failed: %v\n", err)
+		os.Exit(1)
+	}
+	for i, result := range batch.Results {
+		fmt.Printf("Batch[%d]: valid=%t network=%s\n", i, result.Valid, result.Network)
+	}
+}
@@ -1,18 +1,29 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io/fs"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
-	"path/filepath"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
 	"github.com/jamesmeyerr/credit-card-validator/internal/api"
+	"github.com/jamesmeyerr/credit-card-validator/internal/events"
+	"github.com/jamesmeyerr/credit-card-validator/internal/luhn"
 	"github.com/jamesmeyerr/credit-card-validator/internal/middleware"
+	"github.com/jamesmeyerr/credit-card-validator/internal/recent"
+	"github.com/jamesmeyerr/credit-card-validator/internal/tracing"
+	"github.com/jamesmeyerr/credit-card-validator/web"
 )
 
 // Configuration constants
@@ -21,67 +32,365 @@ const (
 	RateLimit       = 10.0 / 60.0 // tokens per second
 	BucketSize      = 5           // maximum burst
 	CleanupInterval = 10 * time.Minute
+
+	// HTTP server defaults, overridable through the environment.
+	// ReadHeaderTimeout bounds how long a client may trickle headers (slowloris).
+	ReadHeaderTimeout = 2 * time.Second
+	IdleTimeout       = 15 * time.Second
+	MaxHeaderBytes    = 16 * 1024
 )
 
 func main() {
+	startTime := time.Now()
+
 	// Get port from environment or use default
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
 	}
 
+	// The gRPC listener is opt-in: it only starts when GRPC_PORT is set
+	grpcPort := os.Getenv("GRPC_PORT")
+	ports := map[string]string{"PORT": port}
+	if grpcPort != "" {
+		ports["GRPC_PORT"] = grpcPort
+	}
+
+	// Fail fast with a clear message rather than an opaque listen error
+	for name, value := range ports {
+		number, err := validatePort(value)
+		if err != nil {
+			log.Fatal().Err(err).Str("variable", name).Msg("Invalid port")
+		}
+		if number < 1024 {
+			log.Warn().Str("variable", name).Int("port", number).Msg("Binding a privileged port; this usually requires elevated permissions")
+		}
+	}
+
 	// Create middleware components
-	rateLimiter := middleware.NewRateLimiter(RateLimit, BucketSize, CleanupInterval)
+	rateLimiterConfig := middleware.RateLimiterConfig{
+		Rate:            RateLimit,
+		BucketSize:      BucketSize,
+		CleanupInterval: CleanupInterval,
+		IncludeHeaders:  os.Getenv("RATE_LIMIT_HEADERS") == "true",
+		MaxClients:      middleware.DefaultMaxClients,
+	}
+	// Summarize rejections per client, e.g. RATE_LIMIT_LOG_SUMMARY=1m, instead of logging each one
+	rateLimiterConfig.LogSummaryInterval = durationFromEnv("RATE_LIMIT_LOG_SUMMARY", 0)
+	if maxClients, err := strconv.Atoi(os.Getenv("RATE_LIMIT_MAX_CLIENTS")); err == nil && maxClients >= 0 {
+		rateLimiterConfig.MaxClients = maxClients
+	}
+	if exempt := os.Getenv("RATE_LIMIT_EXEMPT_CIDRS"); exempt != "" {
+		rateLimiterConfig.ExemptCIDRs = strings.Split(exempt, ",")
+	}
+	algorithm, err := middleware.ParseRateLimitAlgorithm(os.Getenv("RATE_LIMIT_ALGORITHM"))
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid rate limiter configuration")
+	}
+	rateLimiterConfig.Algorithm = algorithm
+
+	// Client IP resolution behind proxies
+	strategy, err := middleware.ParseHopStrategy(os.Getenv("CLIENT_IP_STRATEGY"))
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid client IP configuration")
+	}
+	clientIPConfig := middleware.DefaultClientIPConfig()
+	clientIPConfig.Strategy = strategy
+	if trusted := os.Getenv("TRUSTED_PROXY_CIDRS"); trusted != "" {
+		clientIPConfig.TrustedProxies = strings.Split(trusted, ",")
+	}
+	if err := middleware.ConfigureClientIP(clientIPConfig); err != nil {
+		log.Fatal().Err(err).Msg("Invalid client IP configuration")
+	}
+
+	rateLimiter, err := middleware.NewRateLimiter(rateLimiterConfig)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid rate limiter configuration")
+	}
 	defer rateLimiter.Shutdown()
 	
-	sanitizer := middleware.NewInputSanitizer(middleware.DefaultSanitizationConfig())
+	// 422 instead of 400 for well-formed requests that fail validation, from the sanitizer and handlers alike
+	statusConfig := middleware.DefaultStatusConfig()
+	statusConfig.UnprocessableEntity = os.Getenv("UNPROCESSABLE_ENTITY") == "true"
+
+	sanitizerConfig := middleware.DefaultSanitizationConfig()
+	sanitizerConfig.Status = statusConfig
+	sanitizerConfig.PreserveOriginalFormat = os.Getenv("PRESERVE_CARD_FORMAT") == "true"
+	sanitizerConfig.LenientExpiry = os.Getenv("LENIENT_EXPIRY") == "true"
+	sanitizerConfig.Report = os.Getenv("SANITIZE_REPORT") == "true"
+	sanitizer := middleware.NewInputSanitizer(sanitizerConfig)
+
+	// Per-stage timing in request logs, for performance debugging
+	middleware.TimingBreakdown = os.Getenv("TIMING_BREAKDOWN") == "true"
+	if mask := os.Getenv("LOG_CVV_MASK"); mask != "" {
+		middleware.CVVLogMask = mask
+	}
+
+	// Settings shared by every validation endpoint, over HTTP and gRPC
+	apiConfig := api.DefaultConfig()
+	apiConfig.Status = statusConfig
+
+	// Debug responses must be explicitly enabled and are never meant for production
+	apiConfig.Debug = os.Getenv("DEBUG_RESPONSES") == "true"
+
+	// Optional expiry grace period for processors that accept recently expired cards
+	if graceDays, err := strconv.Atoi(os.Getenv("EXPIRY_GRACE_DAYS")); err == nil && graceDays > 0 {
+		apiConfig.Validation.GracePeriodDays = graceDays
+	}
+
+	// Optional required request fields beyond the card number, from a list or a JSON file
+	if fields := os.Getenv("REQUIRED_FIELDS"); fields != "" {
+		required, err := api.ParseRequiredFields(strings.Split(fields, ","))
+		if err != nil {
+			log.Fatal().Err(err).Msg("Invalid required fields configuration")
+		}
+		apiConfig.RequiredFields = required
+	}
+	if path := os.Getenv("REQUIRED_FIELDS_FILE"); path != "" {
+		required, err := api.LoadRequiredFieldsFile(path)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Invalid required fields configuration")
+		}
+		apiConfig.RequiredFields = required
+	}
+
+	// Optional per-network CVV requirement, e.g. "American Express"
+	if required := os.Getenv("CVV_REQUIRED_NETWORKS"); required != "" {
+		apiConfig.Validation.CVVRequiredNetworks = strings.Split(required, ",")
+	}
+
+	// Optional network rules file, reloaded on SIGHUP
+	rulesFile := os.Getenv("NETWORK_RULES")
+	if rulesFile != "" {
+		loadNetworkRules(rulesFile, true)
+		reload := make(chan os.Signal, 1)
+		signal.Notify(reload, syscall.SIGHUP)
+		go func() {
+			for range reload {
+				loadNetworkRules(rulesFile, false)
+			}
+		}()
+	}
+
+	// Optional BIN routing table
+	if routingFile := os.Getenv("ROUTING_TABLE"); routingFile != "" {
+		table, err := luhn.LoadRoutingTableFile(routingFile)
+		if err != nil {
+			log.Fatal().Err(err).Str("file", routingFile).Msg("Failed to load routing table")
+		}
+		luhn.SetRoutingTable(table)
+	}
+
+	// Optional OpenTelemetry tracing, configured through the standard OTEL_* variables
+	shutdownTracing, err := tracing.Setup(context.Background())
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to configure tracing")
+	}
+
+	// Optional repeat-validation tracking for fraud review
+	var recentTracker *recent.Tracker
+	if window := durationFromEnv("RECENT_VALIDATIONS_WINDOW", 0); window > 0 {
+		recentConfig := recent.DefaultConfig()
+		recentConfig.Window = window
+		recentConfig.Salt = []byte(os.Getenv("RECENT_VALIDATIONS_SALT"))
+		recentTracker, err = recent.NewTracker(recentConfig)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to create repeat-validation tracker")
+		}
+		defer recentTracker.Shutdown()
+		api.RecentTracker = recentTracker
+	}
+
+	// Optional keyed card hash for client-side deduplication
+	if secret := os.Getenv("CARD_HASH_SECRET"); secret != "" {
+		apiConfig.CardHashKey = []byte(secret)
+	}
+
+	// Optional webhook for validation audit events
+	var dispatcher *events.Dispatcher
+	if webhookURL := os.Getenv("WEBHOOK_URL"); webhookURL != "" {
+		dispatcher = events.NewDispatcher(events.DefaultDispatcherConfig(webhookURL))
+		api.EventDispatcher = dispatcher
+	}
+
+	// Optional append-only audit trail of validation decisions, separate from the request log
+	var auditLog *events.AuditLogger
+	if auditPath := os.Getenv("AUDIT_LOG_PATH"); auditPath != "" {
+		auditConfig := events.DefaultAuditConfig(auditPath)
+		if maxSize, err := strconv.ParseInt(os.Getenv("AUDIT_LOG_MAX_BYTES"), 10, 64); err == nil && maxSize >= 0 {
+			auditConfig.MaxSizeBytes = maxSize
+		}
+		if backups, err := strconv.Atoi(os.Getenv("AUDIT_LOG_MAX_BACKUPS")); err == nil && backups >= 0 {
+			auditConfig.MaxBackups = backups
+		}
+		auditLog, err = events.NewAuditLogger(auditConfig)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to open audit log")
+		}
+		defer auditLog.Close()
+		api.AuditLog = auditLog
+	}
 
 	// Create router
 	mux := http.NewServeMux()
 	
 	// API endpoint
-	mux.HandleFunc("/validate", api.ValidationHandler)
+	validationHandler := api.NewValidationHandler(apiConfig)
+	mux.Handle("/validate", validationHandler)
 
-	// Static file server for web frontend
-	fs := http.FileServer(http.Dir("web/static"))
-	mux.Handle("/static/", http.StripPrefix("/static/", fs))
+	// Batch API endpoint
+	batchConfig := api.DefaultBatchConfig()
+	batchConfig.Config = apiConfig
+	if concurrency, err := strconv.Atoi(os.Getenv("BATCH_CONCURRENCY")); err == nil && concurrency > 0 {
+		batchConfig.Concurrency = concurrency
+	}
+	mux.Handle("/validate/batch", api.NewBatchHandler(batchConfig))
 
-	// Serve the main HTML page
-	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		if r.URL.Path != "/" {
-			http.NotFound(w, r)
-			return
-		}
-		http.ServeFile(w, r, filepath.Join("web", "templates", "index.html"))
-	})
+	// CSV file upload for ops users; registered outside the mux because it is not JSON
+	uploadConfig := api.DefaultUploadConfig()
+	uploadConfig.Config = apiConfig
+	uploadHandler := api.NewUploadHandler(uploadConfig)
 
-	// Build the middleware chain - order matters:
-	// 1. Logging (outermost) - captures all requests
-	// 2. Rate limiting - prevents abuse
-	// 3. Request sanitization - cleans inputs before processing
-	
-	// Apply middleware chain - logging applies to everything
-	handler := middleware.LoggingMiddleware(mux)
+	// Live validation results for the web UI over Server-Sent Events
+	sseHandler := api.NewSSEHandler(apiConfig)
+	mux.Handle("/validate/sse", sseHandler)
+
+	// Check digit resolution for OCR pipelines
+	mux.Handle("/check-digit", api.NewCheckDigitHandler(apiConfig))
+
+	// Masking without validation for internal tools
+	mux.Handle("/mask", api.NewMaskHandler(apiConfig))
+
+	// Card number extraction for pasted text
+	mux.HandleFunc("/extract", api.ExtractHandler)
+
+	// Luhn weight table for auditors (only served when debug responses are enabled)
+	mux.Handle("/luhn/breakdown", api.NewBreakdownHandler(apiConfig))
+
+	// In-process latency percentiles for environments without a metrics backend
+	latencyStats := middleware.NewLatencyStats(middleware.DefaultReservoirSize)
+	mux.HandleFunc("/stats", latencyStats.StatsHandler)
+
+	// OpenAPI specification
+	mux.HandleFunc("/openapi.json", api.OpenAPIHandler)
+
+	// Liveness probe, reachable without the gateway header
+	mux.HandleFunc("/healthz", api.HealthHandler)
+
+	// Web frontend, embedded in the binary. WEB_DIR serves it from disk instead during
+	// development; if that directory is missing only the API is served.
+	webEnabled := registerWebRoutes(mux, os.Getenv("WEB_DIR"))
+
+	// Build the middleware chain - order matters, outermost first:
+	// 1. Request counting - lets shutdown report every request
+	// 2. Latency stats - measures everything the service does with the request
+	// 3. Logging - captures all requests
+	// 4. Tracing - starts a span continuing the caller's trace
+	// 5. Required header (optional) - rejects requests that bypassed the gateway
+	// 6. URI length limit - rejects over-length query strings
+	// 7. Duplicate request window (optional) - replays double-clicked submits
+	// 8. Rate limiting - prevents abuse
+	// 9. Routing - /validate/upload goes straight to its handler; /validate converts
+	//    form-encoded bodies to JSON, then continues like every other route
+	// 10. Content-Type enforcement - rejects non-JSON bodies
+	// 11. Request sanitization (/validate only) - cleans inputs before processing
 	
 	// For the validate endpoint, add sanitization
 	apiHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path == "/validate" {
-			sanitizer.SanitizeMiddleware(http.HandlerFunc(api.ValidationHandler)).ServeHTTP(w, r)
+			middleware.Timed("sanitizer", sanitizer.SanitizeMiddleware(
+				middleware.Timed("handler", validationHandler))).ServeHTTP(w, r)
 		} else {
-			mux.ServeHTTP(w, r)
+			middleware.Timed("handler", mux).ServeHTTP(w, r)
 		}
 	})
 	
-	// Rate limiting is the final layer
-	handler = rateLimiter.RateLimitMiddleware(apiHandler)
+	// Reject non-JSON bodies on mutating requests for every route except the multipart upload
+	jsonHandler := middleware.Timed("content_type", middleware.RequireJSONMiddleware(apiHandler))
+	// The HTML form may also post /validate form-encoded; it is converted to JSON first
+	formHandler := middleware.FormToJSONMiddleware(sanitizerConfig.MaxRequestSize)(jsonHandler)
+	routedHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/validate/upload":
+			middleware.Timed("handler", uploadHandler).ServeHTTP(w, r)
+		case "/validate":
+			formHandler.ServeHTTP(w, r)
+		default:
+			jsonHandler.ServeHTTP(w, r)
+		}
+	})
+
+	// Rate limiting
+	var handler http.Handler = middleware.Timed("rate_limiter", rateLimiter.RateLimitMiddleware(routedHandler))
+
+	// Optional duplicate request window, e.g. DEDUPE_WINDOW=2s, replays the response to a
+	// double-clicked submit ahead of the rate limiter so the duplicate costs no tokens
+	if window := os.Getenv("DEDUPE_WINDOW"); window != "" {
+		dedupeConfig := middleware.DefaultDedupeConfig()
+		if window != "true" {
+			duration, err := time.ParseDuration(window)
+			if err != nil || duration <= 0 {
+				log.Fatal().Str("value", window).Msg("Invalid DEDUPE_WINDOW")
+			}
+			dedupeConfig.Window = duration
+		}
+		handler = middleware.NewDeduplicator(dedupeConfig).DedupeMiddleware(handler)
+	}
+
+	// Over-length URIs are rejected before they consume rate limit tokens
+	maxURLLength := middleware.DefaultMaxURLLength
+	if limit, err := strconv.Atoi(os.Getenv("MAX_URL_LENGTH")); err == nil && limit > 0 {
+		maxURLLength = limit
+	}
+	handler = middleware.MaxURLLengthMiddleware(maxURLLength)(handler)
+
+	// Requests that bypassed the gateway are rejected before doing any other work
+	requiredHeaderName, requiredHeaderValue := os.Getenv("REQUIRED_HEADER_NAME"), os.Getenv("REQUIRED_HEADER_VALUE")
+	if requiredHeaderName != "" {
+		if requiredHeaderValue == "" {
+			log.Fatal().Msg("REQUIRED_HEADER_VALUE must be set when REQUIRED_HEADER_NAME is set")
+		}
+		handler = middleware.RequireHeaderMiddleware(requiredHeaderName, requiredHeaderValue)(handler)
+	}
+
+	// Tracing wraps everything below logging so rejected requests still produce spans
+	handler = middleware.TracingMiddleware(handler)
+
+	// Logging applies to everything, including rate-limited requests.
+	// Each layer that reads the body restores it for the next one.
+	handler = middleware.LoggingMiddleware(handler)
+
+	// Latency is measured around everything the service does with the request
+	handler = latencyStats.StatsMiddleware(handler)
+
+	// Request counting is the final layer so shutdown can report every request
+	requestCounter := middleware.NewRequestCounter()
+	handler = requestCounter.CountMiddleware(handler)
 
 	// Create server with all middleware applied
-	server := &http.Server{
-		Addr:         ":" + port,
-		Handler:      handler,
-		ReadTimeout:  5 * time.Second,
-		WriteTimeout: 10 * time.Second,
-		IdleTimeout:  15 * time.Second,
+	server := newHTTPServer(port, handler)
+
+	// TLS is enabled when both files are set; HTTP/2 is then negotiated automatically via ALPN
+	tlsCertFile, tlsKeyFile := os.Getenv("TLS_CERT_FILE"), os.Getenv("TLS_KEY_FILE")
+	scheme := "http"
+	if tlsCertFile != "" && tlsKeyFile != "" {
+		scheme = "https"
+	}
+
+	// Open event streams would otherwise hold up graceful shutdown until the deadline
+	server.RegisterOnShutdown(sseHandler.Shutdown)
+
+	// Create the gRPC server with the same validation logic and protections as HTTP: the
+	// required header as metadata, the shared rate limiter, and the sanitizer's field checks
+	var grpcServer *grpc.Server
+	if grpcPort != "" {
+		grpcConfig := api.DefaultGRPCConfig()
+		grpcConfig.Config = apiConfig
+		grpcConfig.RequiredHeaderName = requiredHeaderName
+		grpcConfig.RequiredHeaderValue = requiredHeaderValue
+		grpcConfig.RateLimiter = rateLimiter
+		grpcConfig.Sanitization = sanitizerConfig
+		grpcServer = api.NewGRPCServer(grpcConfig)
 	}
 
 	// Channel for graceful shutdown signals
@@ -99,31 +408,199 @@ func main() {
 
 		fmt.Printf("Credit Card Validation Service\n")
 		fmt.Printf("==============================\n")
-		fmt.Printf("Server running on http://localhost%s\n", server.Addr)
-		fmt.Printf("Web interface: http://localhost%s\n", server.Addr)
-		fmt.Printf("API endpoint: http://localhost%s/validate\n", server.Addr)
+		fmt.Printf("Server running on %s://localhost%s\n", scheme, server.Addr)
+		if webEnabled {
+			fmt.Printf("Web interface: %s://localhost%s\n", scheme, server.Addr)
+		} else {
+			fmt.Printf("Web interface: Disabled\n")
+		}
+		fmt.Printf("API endpoint: %s://localhost%s/validate\n", scheme, server.Addr)
+		fmt.Printf("Batch endpoint: %s://localhost%s/validate/batch\n", scheme, server.Addr)
+		fmt.Printf("CSV upload: %s://localhost%s/validate/upload\n", scheme, server.Addr)
+		fmt.Printf("OpenAPI spec: %s://localhost%s/openapi.json\n", scheme, server.Addr)
+		if grpcServer != nil {
+			fmt.Printf("gRPC endpoint: localhost:%s\n", grpcPort)
+		} else {
+			fmt.Printf("gRPC endpoint: Disabled\n")
+		}
 		fmt.Printf("Rate limit: %.1f requests per minute per IP (max burst: %d)\n", RateLimit*60, BucketSize)
 		fmt.Printf("Input sanitization: Enabled\n")
 		fmt.Printf("Structured logging: Enabled\n")
 		fmt.Printf("==============================\n")
 		
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		var err error
+		if scheme == "https" {
+			err = server.ListenAndServeTLS(tlsCertFile, tlsKeyFile)
+		} else {
+			err = server.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			log.Fatal().Err(err).Msg("Server failed to start")
 		}
 	}()
 
+	// Start gRPC server in a separate goroutine
+	if grpcServer != nil {
+		go func() {
+			listener, err := net.Listen("tcp", ":"+grpcPort)
+			if err != nil {
+				log.Fatal().Err(err).Str("grpc_port", grpcPort).Msg("gRPC server failed to listen")
+			}
+
+			log.Info().Str("grpc_port", grpcPort).Msg("Starting gRPC Card Validator service")
+
+			if err := grpcServer.Serve(listener); err != nil {
+				log.Fatal().Err(err).Msg("gRPC server failed to start")
+			}
+		}()
+	}
+
 	// Wait for interruption signal
 	<-done
-	log.Info().Msg("Shutting down server...")
+	shutdownStart := time.Now()
+	servedBeforeShutdown := requestCounter.Served()
+	log.Info().
+		Int64("in_flight", requestCounter.InFlight()).
+		Msg("Shutting down server...")
 
 	// Create a timeout context for shutdown
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
+	// Gracefully shutdown the gRPC server, forcing it closed if the deadline passes
+	grpcStopped := make(chan struct{})
+	go func() {
+		if grpcServer != nil {
+			grpcServer.GracefulStop()
+		}
+		close(grpcStopped)
+	}()
+
 	// Gracefully shutdown the server
 	if err := server.Shutdown(ctx); err != nil {
 		log.Fatal().Err(err).Msg("Server shutdown failed")
 	}
+
+	select {
+	case <-grpcStopped:
+	case <-ctx.Done():
+		if grpcServer != nil {
+			grpcServer.Stop()
+		}
+	}
+
+	// Flush buffered spans
+	if err := shutdownTracing(ctx); err != nil {
+		log.Warn().Err(err).Msg("Trace spans not fully exported before shutdown")
+	}
+
+	// Flush pending webhook events
+	if err := dispatcher.Shutdown(ctx); err != nil {
+		log.Warn().Err(err).Msg("Webhook events not fully delivered before shutdown")
+	}
 	
-	log.Info().Msg("Server gracefully stopped")
-}
\ No newline at end of file
+	log.Info().
+		Uint64("requests_served", requestCounter.Served()).
+		Uint64("requests_drained", requestCounter.Served()-servedBeforeShutdown).
+		Dur("drain_duration", time.Since(shutdownStart)).
+		Dur("uptime", time.Since(startTime)).
+		Msg("Server gracefully stopped")
+}
+
+// loadNetworkRules installs network rules from a JSON file. A missing file restores the
+// built-in rules. An invalid file also does at startup, but on reload the current rules
+// are kept so a bad edit cannot silently discard a working set.
+func loadNetworkRules(path string, startup bool) {
+	rules, err := luhn.LoadNetworkRulesFile(path)
+	switch {
+	case err == nil:
+		luhn.SetNetworkRules(rules)
+		log.Info().Str("file", path).Int("rules", len(rules)).Msg("Loaded network rules")
+	case errors.Is(err, fs.ErrNotExist):
+		luhn.SetNetworkRules(nil)
+		log.Warn().Str("file", path).Msg("Network rules file not found; using built-in rules")
+	case startup:
+		luhn.SetNetworkRules(nil)
+		log.Error().Err(err).Str("file", path).Msg("Invalid network rules file; using built-in rules")
+	default:
+		log.Error().Err(err).Str("file", path).Msg("Invalid network rules file; keeping current rules")
+	}
+}
+
+// registerWebRoutes serves the web frontend at / and /static/ from the embedded assets, or from
+// webDir when it is set. If webDir is not a directory the frontend is disabled with a warning
+// and / answers with the JSON not-found error, so the API keeps working. It reports whether the
+// frontend is served.
+func registerWebRoutes(mux *http.ServeMux, webDir string) bool {
+	var webFS fs.FS = web.Assets
+	webEnabled := true
+	if webDir != "" {
+		if info, err := os.Stat(webDir); err != nil || !info.IsDir() {
+			log.Warn().Str("web_dir", webDir).Msg("Web directory not found; web frontend disabled")
+			webEnabled = false
+		}
+		webFS = os.DirFS(webDir)
+	}
+
+	// Static file server for web frontend
+	if webEnabled {
+		staticFS, err := fs.Sub(webFS, "static")
+		if err != nil {
+			log.Fatal().Err(err).Msg("Invalid web assets")
+		}
+		mux.Handle("/static/", http.StripPrefix("/static/", http.FileServer(http.FS(staticFS))))
+	}
+
+	// Serve the main HTML page
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" || !webEnabled {
+			middleware.NotFoundHandler(w, r)
+			return
+		}
+		page, err := fs.ReadFile(webFS, "templates/index.html")
+		if err != nil {
+			middleware.NotFoundHandler(w, r)
+			return
+		}
+		http.ServeContent(w, r, "index.html", time.Time{}, bytes.NewReader(page))
+	})
+	return webEnabled
+}
+
+// newHTTPServer creates the HTTP server with its timeouts and header limit, which can be
+// overridden with READ_HEADER_TIMEOUT, IDLE_TIMEOUT, and MAX_HEADER_BYTES
+func newHTTPServer(port string, handler http.Handler) *http.Server {
+	server := &http.Server{
+		Addr:              ":" + port,
+		Handler:           handler,
+		ReadHeaderTimeout: durationFromEnv("READ_HEADER_TIMEOUT", ReadHeaderTimeout),
+		ReadTimeout:       5 * time.Second,
+		WriteTimeout:      10 * time.Second,
+		IdleTimeout:       durationFromEnv("IDLE_TIMEOUT", IdleTimeout),
+		MaxHeaderBytes:    MaxHeaderBytes,
+	}
+	if maxHeaderBytes, err := strconv.Atoi(os.Getenv("MAX_HEADER_BYTES")); err == nil && maxHeaderBytes > 0 {
+		server.MaxHeaderBytes = maxHeaderBytes
+	}
+	return server
+}
+
+// validatePort checks that a port is a number from 1 to 65535
+func validatePort(port string) (int, error) {
+	number, err := strconv.Atoi(port)
+	if err != nil {
+		return 0, fmt.Errorf("port %q is not a number", port)
+	}
+	if number < 1 || number > 65535 {
+		return 0, fmt.Errorf("port %d is out of range 1-65535", number)
+	}
+	return number, nil
+}
+
+// durationFromEnv parses a duration such as "30s" from the environment, falling back to def
+func durationFromEnv(name string, def time.Duration) time.Duration {
+	if d, err := time.ParseDuration(os.Getenv(name)); err == nil && d > 0 {
+		return d
+	}
+	return def
+}
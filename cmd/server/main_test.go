@@ -0,0 +1,240 @@
+package main
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jamesmeyerr/credit-card-validator/internal/luhn"
+	"github.com/jamesmeyerr/credit-card-validator/web"
+)
+
+// startServer serves handler on a loopback port with newHTTPServer's settings
+func startServer(t *testing.T, handler http.Handler) (*http.Server, string) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := newHTTPServer("0", handler)
+	go server.Serve(listener)
+	t.Cleanup(func() { server.Close() })
+	return server, listener.Addr().String()
+}
+
+func TestServerRejectsSlowHeaders(t *testing.T) {
+	t.Setenv("READ_HEADER_TIMEOUT", "200ms")
+	_, addr := startServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// Start a request but never finish its headers
+	if _, err := io.WriteString(conn, "GET / HTTP/1.1\r\nHost: localhost\r\nX-Slow: "); err != nil {
+		t.Fatal(err)
+	}
+
+	// The server closes the connection once the header timeout passes
+	start := time.Now()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := io.ReadAll(conn); err != nil {
+		t.Fatalf("connection was not closed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("connection stayed open for %v with incomplete headers", elapsed)
+	}
+}
+
+func TestServerConfigFromEnvironment(t *testing.T) {
+	server := newHTTPServer("8080", nil)
+	if server.Addr != ":8080" || server.ReadHeaderTimeout != ReadHeaderTimeout || server.IdleTimeout != IdleTimeout || server.MaxHeaderBytes != MaxHeaderBytes {
+		t.Errorf("defaults = addr %q, read header %v, idle %v, max header %d",
+			server.Addr, server.ReadHeaderTimeout, server.IdleTimeout, server.MaxHeaderBytes)
+	}
+
+	t.Setenv("READ_HEADER_TIMEOUT", "500ms")
+	t.Setenv("IDLE_TIMEOUT", "1m")
+	t.Setenv("MAX_HEADER_BYTES", "4096")
+	server = newHTTPServer("8080", nil)
+	if server.ReadHeaderTimeout != 500*time.Millisecond || server.IdleTimeout != time.Minute || server.MaxHeaderBytes != 4096 {
+		t.Errorf("overrides = read header %v, idle %v, max header %d", server.ReadHeaderTimeout, server.IdleTimeout, server.MaxHeaderBytes)
+	}
+
+	// Malformed values keep the defaults
+	t.Setenv("READ_HEADER_TIMEOUT", "soon")
+	t.Setenv("MAX_HEADER_BYTES", "-1")
+	server = newHTTPServer("8080", nil)
+	if server.ReadHeaderTimeout != ReadHeaderTimeout || server.MaxHeaderBytes != MaxHeaderBytes {
+		t.Errorf("malformed overrides = read header %v, max header %d", server.ReadHeaderTimeout, server.MaxHeaderBytes)
+	}
+}
+
+func TestServerRejectsOversizedHeaders(t *testing.T) {
+	t.Setenv("MAX_HEADER_BYTES", "1024")
+	_, addr := startServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	req, _ := http.NewRequest(http.MethodGet, "http://"+addr+"/", nil)
+	req.Header.Set("X-Large", strings.Repeat("a", 8192))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusRequestHeaderFieldsTooLarge {
+		t.Errorf("status = %d, want 431", resp.StatusCode)
+	}
+}
+
+func TestLoadNetworkRulesReload(t *testing.T) {
+	t.Cleanup(func() { luhn.SetNetworkRules(nil) })
+	path := filepath.Join(t.TempDir(), "rules.json")
+	writeRules := func(network string) {
+		t.Helper()
+		rules := `[{"network":"` + network + `","prefix_low":"6039","prefix_high":"6039","lengths":[16]}]`
+		if err := os.WriteFile(path, []byte(rules), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	network := func() string {
+		return luhn.ValidateCard(luhn.CardValidationRequest{CardNumber: "6039000000000002"}).Network
+	}
+
+	writeRules("Gift Card")
+	loadNetworkRules(path, true)
+	if got := network(); got != "Gift Card" {
+		t.Fatalf("after startup load: network = %q, want Gift Card", got)
+	}
+
+	// A modified file takes effect on reload
+	writeRules("Store Card")
+	loadNetworkRules(path, false)
+	if got := network(); got != "Store Card" {
+		t.Errorf("after reload: network = %q, want Store Card", got)
+	}
+
+	// An invalid edit keeps the working rules on reload, but not at startup
+	if err := os.WriteFile(path, []byte(`[{"network":"Broken"`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	loadNetworkRules(path, false)
+	if got := network(); got != "Store Card" {
+		t.Errorf("after invalid reload: network = %q, want Store Card kept", got)
+	}
+	loadNetworkRules(path, true)
+	if len(luhn.Rules()) != len(luhn.NetworkRules) {
+		t.Errorf("invalid file at startup: %d rules, want the built-in rules", len(luhn.Rules()))
+	}
+
+	// A missing file restores the built-in rules
+	writeRules("Gift Card")
+	loadNetworkRules(path, false)
+	os.Remove(path)
+	loadNetworkRules(path, false)
+	if len(luhn.Rules()) != len(luhn.NetworkRules) {
+		t.Errorf("missing file: %d rules, want the built-in rules", len(luhn.Rules()))
+	}
+}
+
+func TestValidatePort(t *testing.T) {
+	valid := map[string]int{"1": 1, "80": 80, "1023": 1023, "8080": 8080, "65535": 65535}
+	for port, want := range valid {
+		if got, err := validatePort(port); err != nil || got != want {
+			t.Errorf("validatePort(%q) = %d, %v; want %d", port, got, err, want)
+		}
+	}
+
+	for _, port := range []string{"", "http", "80a", " 8080", "8080.0", "0", "-1", "65536", "99999999999999999999"} {
+		if got, err := validatePort(port); err == nil {
+			t.Errorf("validatePort(%q) = %d, want an error", port, got)
+		}
+	}
+}
+
+// serveWeb sends a GET through a mux with only the web routes registered
+func serveWeb(mux *http.ServeMux, target string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+	return w
+}
+
+func TestWebRoutesDisabledWhenWebDirMissing(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/validate", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+	if registerWebRoutes(mux, filepath.Join(t.TempDir(), "missing")) {
+		t.Fatal("web frontend enabled for a missing directory")
+	}
+
+	// The page and assets answer with the JSON not-found error rather than a broken page
+	for _, target := range []string{"/", "/static/js/app.js"} {
+		w := serveWeb(mux, target)
+		if w.Code != http.StatusNotFound || w.Header().Get("Content-Type") != "application/json" {
+			t.Errorf("GET %s: status %d, Content-Type %q; want a JSON 404", target, w.Code, w.Header().Get("Content-Type"))
+		}
+	}
+
+	// The API is unaffected
+	if w := serveWeb(mux, "/validate"); w.Code != http.StatusOK {
+		t.Errorf("GET /validate: status = %d, want 200", w.Code)
+	}
+}
+
+func TestWebRoutesServeEmbeddedAssets(t *testing.T) {
+	mux := http.NewServeMux()
+	if !registerWebRoutes(mux, "") {
+		t.Fatal("embedded web frontend disabled")
+	}
+
+	w := serveWeb(mux, "/")
+	page, _ := web.Assets.ReadFile("templates/index.html")
+	if w.Code != http.StatusOK || w.Body.String() != string(page) {
+		t.Errorf("GET /: status %d, want 200 with the embedded index page", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+		t.Errorf("GET /: Content-Type = %q, want text/html", ct)
+	}
+
+	script, _ := web.Assets.ReadFile("static/js/app.js")
+	if w := serveWeb(mux, "/static/js/app.js"); w.Code != http.StatusOK || w.Body.String() != string(script) {
+		t.Errorf("GET /static/js/app.js: status %d, want 200 with the embedded script", w.Code)
+	}
+
+	// Other paths are the JSON not-found error, not the index page
+	if w := serveWeb(mux, "/missing"); w.Code != http.StatusNotFound {
+		t.Errorf("GET /missing: status = %d, want 404", w.Code)
+	}
+}
+
+func TestWebRoutesServeWebDir(t *testing.T) {
+	dir := t.TempDir()
+	for path, contents := range map[string]string{
+		"templates/index.html": "<html>development page</html>",
+		"static/js/app.js":     "// development script",
+	} {
+		full := filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(contents), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// On-disk files override the embedded ones during development
+	mux := http.NewServeMux()
+	registerWebRoutes(mux, dir)
+	if w := serveWeb(mux, "/"); w.Body.String() != "<html>development page</html>" {
+		t.Errorf("GET / = %q, want the page from WEB_DIR", w.Body.String())
+	}
+	if w := serveWeb(mux, "/static/js/app.js"); w.Body.String() != "// development script" {
+		t.Errorf("GET /static/js/app.js = %q, want the script from WEB_DIR", w.Body.String())
+	}
+}
@@ -0,0 +1,169 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/jamesmeyerr/credit-card-validator/internal/luhn"
+	"github.com/jamesmeyerr/credit-card-validator/internal/middleware"
+)
+
+// BatchRequest represents the JSON request structure for batch validation
+type BatchRequest struct {
+	Cards []Request `json:"cards"`
+}
+
+// BatchResponse represents the JSON response structure for batch validation
+type BatchResponse struct {
+	Results []Response `json:"results"`
+
+	// Summary aggregates the results when requested with ?summary=true
+	Summary *luhn.Summary `json:"summary,omitempty"`
+}
+
+// BatchConfig defines batch validation limits
+type BatchConfig struct {
+	Config // settings shared with the other validation endpoints
+
+	MaxItems       int   // maximum cards per batch
+	Concurrency    int   // maximum cards validated in parallel
+	MaxRequestSize int64 // in bytes
+}
+
+// DefaultBatchConfig returns a default configuration
+func DefaultBatchConfig() BatchConfig {
+	return BatchConfig{
+		Config:         DefaultConfig(),
+		MaxItems:       MaxBatchSize,
+		Concurrency:    luhn.DefaultBatchConcurrency,
+		MaxRequestSize: 64 * 1024, // 64KB covers a full batch of formatted cards
+	}
+}
+
+// BatchHandler handles batch credit card validation requests
+type BatchHandler struct {
+	config BatchConfig
+}
+
+// NewBatchHandler creates a new batch validation handler
+func NewBatchHandler(config BatchConfig) *BatchHandler {
+	return &BatchHandler{
+		config: config,
+	}
+}
+
+// ServeHTTP validates every card in the batch, preserving input order
+func (h *BatchHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Get logger with request context
+	logger := middleware.ApplicationLogger(r.Context())
+
+	if r.Method != http.MethodPost {
+		logger.Warn().Str("method", r.Method).Msg("Invalid HTTP method")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Set content type
+	w.Header().Set("Content-Type", "application/json")
+
+	// Parse the request
+	var req BatchRequest
+	r.Body = http.MaxBytesReader(w, r.Body, h.config.MaxRequestSize)
+	if err := decodeJSON(r.Body, &req); err != nil {
+		logger.Warn().Err(err).Msg("Failed to parse batch JSON request")
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			middleware.WriteError(w, r, http.StatusRequestEntityTooLarge, middleware.ErrCodeRequestTooLarge,
+				fmt.Sprintf("request body exceeds max size %d bytes", h.config.MaxRequestSize))
+			return
+		}
+		middleware.WriteError(w, r, http.StatusBadRequest, middleware.ErrCodeInvalidJSON, invalidJSONMessage(err))
+		return
+	}
+
+	if len(req.Cards) == 0 {
+		logger.Warn().Msg("Empty batch request")
+		middleware.WriteError(w, r, http.StatusBadRequest, middleware.ErrCodeEmptyBatch, "At least one card is required")
+		return
+	}
+	if len(req.Cards) > h.config.MaxItems {
+		logger.Warn().Int("count", len(req.Cards)).Msg("Batch exceeds maximum size")
+		middleware.WriteError(w, r, http.StatusBadRequest, middleware.ErrCodeBatchTooLarge,
+			fmt.Sprintf("batch exceeds max size %d, received %d", h.config.MaxItems, len(req.Cards)))
+		return
+	}
+
+	// Optionally return only the valid or invalid results, each with its index in the request
+	only := r.URL.Query().Get("only")
+	if only != "" && only != "valid" && only != "invalid" {
+		logger.Warn().Str("only", only).Msg("Unknown batch filter")
+		middleware.WriteError(w, r, http.StatusBadRequest, middleware.ErrCodeInvalidQuery,
+			`only must be "valid" or "invalid"`)
+		return
+	}
+
+	// Required fields are enforced per card, with every missing field in the batch reported at once
+	var missing []string
+	for i, card := range req.Cards {
+		for _, field := range h.config.missingFields(card) {
+			missing = append(missing, fmt.Sprintf("cards[%d].%s", i, field))
+		}
+	}
+	if len(missing) > 0 {
+		logger.Warn().Strs("fields", missing).Msg("Missing required fields in batch request")
+		middleware.WriteFieldsError(w, r, http.StatusUnprocessableEntity, middleware.ErrCodeMissingField,
+			missingFieldsMessage(missing), missing)
+		return
+	}
+
+	validationReqs := make([]luhn.CardValidationRequest, len(req.Cards))
+	for i, card := range req.Cards {
+		validationReq, err := h.config.validationRequest(card)
+		if err != nil {
+			logger.Warn().Int("index", i).Str("check_algorithm", card.CheckAlgorithm).Msg("Unknown check algorithm")
+			middleware.WriteError(w, r, h.config.Status.ValidationStatus(), middleware.ErrCodeInvalidCheckAlgorithm,
+				fmt.Sprintf(`cards[%d].check_algorithm must be "luhn" or "none"`, i))
+			return
+		}
+		validationReqs[i] = validationReq
+	}
+
+	// Validate in parallel, stopping early if the client goes away
+	cardInfos, err := luhn.ValidateBatch(r.Context(), validationReqs, h.config.Concurrency)
+	if err != nil {
+		logger.Warn().Err(err).Msg("Batch validation cancelled")
+		return
+	}
+
+	lang := negotiateLanguage(r)
+	resp := BatchResponse{Results: make([]Response, 0, len(cardInfos))}
+	for i, cardInfo := range cardInfos {
+		result := buildResponse(cardInfo, lang)
+		result.RecentValidations = recordRecent(req.Cards[i].CardNumber)
+		result.CardHash = h.config.cardHash(req.Cards[i].CardNumber)
+		emitValidationEvent(r.Context(), req.Cards[i].CardNumber, cardInfo)
+
+		if only != "" {
+			// Filter on the outcome, so an expired card that passes Luhn is not "valid"
+			if (cardInfo.Outcome == luhn.OutcomeValid) != (only == "valid") {
+				continue
+			}
+			index := i
+			result.Index = &index
+		}
+		resp.Results = append(resp.Results, result)
+	}
+
+	// The summary always covers the whole batch, even when the results are filtered
+	if r.URL.Query().Get("summary") == "true" {
+		summary := luhn.Summarize(cardInfos)
+		resp.Summary = &summary
+	}
+
+	logger.Info().Int("count", len(cardInfos)).Msg("Batch validation completed")
+
+	// Return response
+	w.WriteHeader(http.StatusOK)
+	encodeBatchResponse(w, r, resp)
+}
@@ -0,0 +1,242 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jamesmeyerr/credit-card-validator/internal/luhn"
+	"github.com/jamesmeyerr/credit-card-validator/internal/luhn/luhntest"
+	"github.com/jamesmeyerr/credit-card-validator/internal/middleware"
+)
+
+// postBatch posts a JSON body to a batch handler with the default configuration
+func postBatch(t *testing.T, target, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	return postBatchWith(t, DefaultConfig(), target, body)
+}
+
+// postBatchWith posts a JSON body to a BatchHandler with the given shared configuration
+func postBatchWith(t *testing.T, config Config, target, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	batchConfig := DefaultBatchConfig()
+	batchConfig.Config = config
+	r := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	NewBatchHandler(batchConfig).ServeHTTP(w, r)
+	return w
+}
+
+// batchBody encodes card numbers as a batch request
+func batchBody(t *testing.T, numbers []string) string {
+	t.Helper()
+	req := BatchRequest{}
+	for _, number := range numbers {
+		req.Cards = append(req.Cards, Request{CardNumber: number})
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
+
+// decodeErrorDetail decodes an error envelope, failing the test on error
+func decodeErrorDetail(t *testing.T, w *httptest.ResponseRecorder) middleware.ErrorDetail {
+	t.Helper()
+	var resp middleware.ErrorResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode error envelope: %v", err)
+	}
+	return resp.Error
+}
+
+func TestBatchPreservesOrder(t *testing.T) {
+	networks := []string{"Visa", "Mastercard", "American Express", "Discover", "JCB"}
+	numbers := make([]string, MaxBatchSize)
+	wantNetwork := make([]string, MaxBatchSize)
+	for i := range numbers {
+		wantNetwork[i] = networks[i%len(networks)]
+		numbers[i] = luhntest.RandomValidCard(wantNetwork[i])
+		if i%3 == 0 {
+			// Break the check digit of every third card
+			last := numbers[i][len(numbers[i])-1]
+			numbers[i] = numbers[i][:len(numbers[i])-1] + string('0'+(last-'0'+1)%10)
+		}
+	}
+
+	w := postBatch(t, "/validate/batch", batchBody(t, numbers))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+	var resp BatchResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Results) != len(numbers) {
+		t.Fatalf("%d results, want %d", len(resp.Results), len(numbers))
+	}
+
+	for i, result := range resp.Results {
+		want := luhn.ValidateCard(luhn.CardValidationRequest{CardNumber: numbers[i]})
+		if result.Valid != want.Valid || result.Network != want.Network || result.CardLength != len(numbers[i]) {
+			t.Errorf("result %d = valid %v, network %q; want valid %v, network %q",
+				i, result.Valid, result.Network, want.Valid, want.Network)
+		}
+		if result.Valid != (i%3 != 0) {
+			t.Errorf("result %d: valid %v", i, result.Valid)
+		}
+	}
+}
+
+func TestBatchErrors(t *testing.T) {
+	tooMany := make([]string, MaxBatchSize+1)
+	for i := range tooMany {
+		tooMany[i] = "4111111111111111"
+	}
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		code   string
+	}{
+		{"malformed JSON", `{"cards":[`, http.StatusBadRequest, middleware.ErrCodeInvalidJSON},
+		{"empty batch", `{"cards":[]}`, http.StatusBadRequest, middleware.ErrCodeEmptyBatch},
+		{"too many cards", batchBody(t, tooMany), http.StatusBadRequest, middleware.ErrCodeBatchTooLarge},
+		{"too large", `{"cards":[{"card_number":"` + strings.Repeat("4", 70*1024) + `"}]}`,
+			http.StatusRequestEntityTooLarge, middleware.ErrCodeRequestTooLarge},
+		{"missing card number", `{"cards":[{"card_number":"4111111111111111"},{"cvv":"123"}]}`,
+			http.StatusUnprocessableEntity, middleware.ErrCodeMissingField},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := postBatch(t, "/validate/batch", tt.body)
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d", w.Code, tt.status)
+			}
+			if detail := decodeErrorDetail(t, w); detail.Code != tt.code {
+				t.Errorf("code = %q, want %q", detail.Code, tt.code)
+			}
+		})
+	}
+}
+
+func TestBatchMissingFieldsNamed(t *testing.T) {
+	w := postBatch(t, "/validate/batch", `{"cards":[{"card_number":"4111111111111111"},{"cvv":"123"},{}]}`)
+	detail := decodeErrorDetail(t, w)
+	want := []string{"cards[1].card_number", "cards[2].card_number"}
+	if fmt.Sprint(detail.Fields) != fmt.Sprint(want) {
+		t.Errorf("fields = %v, want %v", detail.Fields, want)
+	}
+}
+
+func TestBatchCancelledRequestReturnsEarly(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	r := httptest.NewRequest(http.MethodPost, "/validate/batch", strings.NewReader(batchBody(t, []string{"4111111111111111", "5555555555554444"}))).WithContext(ctx)
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	NewBatchHandler(DefaultBatchConfig()).ServeHTTP(w, r)
+
+	if w.Body.Len() != 0 {
+		t.Errorf("cancelled batch got a response: %s", w.Body)
+	}
+}
+
+func TestBatchSummary(t *testing.T) {
+	body := batchBody(t, []string{"4111111111111111", "4111111111111112", "5500000000000004"})
+
+	var resp BatchResponse
+	if err := json.NewDecoder(postBatch(t, "/validate/batch?summary=true", body).Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Summary == nil {
+		t.Fatal("no summary with ?summary=true")
+	}
+	if s := resp.Summary; s.Total != 3 || s.Valid != 2 || s.Invalid != 1 || s.ByNetwork["Visa"] != 2 || s.ByNetwork["Mastercard"] != 1 {
+		t.Errorf("summary = %+v", *s)
+	}
+
+	resp = BatchResponse{}
+	if err := json.NewDecoder(postBatch(t, "/validate/batch", body).Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Summary != nil {
+		t.Errorf("summary without ?summary=true: %+v", *resp.Summary)
+	}
+}
+
+func TestBatchOnlyFilter(t *testing.T) {
+	body := batchBody(t, []string{"4111111111111111", "4111111111111112", "5500000000000004", "1234"})
+	tests := []struct {
+		only        string
+		wantIndexes []int
+	}{
+		{"valid", []int{0, 2}},
+		{"invalid", []int{1, 3}},
+	}
+
+	for _, tt := range tests {
+		var resp BatchResponse
+		if err := json.NewDecoder(postBatch(t, "/validate/batch?summary=true&only="+tt.only, body).Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		if len(resp.Results) != len(tt.wantIndexes) {
+			t.Fatalf("only=%s: %d results, want %d", tt.only, len(resp.Results), len(tt.wantIndexes))
+		}
+		for i, result := range resp.Results {
+			if result.Index == nil || *result.Index != tt.wantIndexes[i] {
+				t.Errorf("only=%s: result %d index = %v, want %d", tt.only, i, result.Index, tt.wantIndexes[i])
+			}
+			if (result.Outcome == luhn.OutcomeValid) != (tt.only == "valid") {
+				t.Errorf("only=%s: result %d outcome = %q", tt.only, i, result.Outcome)
+			}
+		}
+		// The summary still covers every card
+		if resp.Summary == nil || resp.Summary.Total != 4 {
+			t.Errorf("only=%s: summary = %+v, want 4 cards", tt.only, resp.Summary)
+		}
+	}
+
+	// An expired card passes Luhn but its outcome is expired, so it is not valid
+	expired := `{"cards":[{"card_number":"4111111111111111","expiry_date":"01/20"},{"card_number":"5500000000000004"}]}`
+	for only, want := range map[string]int{"valid": 1, "invalid": 0} {
+		var resp BatchResponse
+		if err := json.NewDecoder(postBatch(t, "/validate/batch?only="+only, expired).Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		if len(resp.Results) != 1 || *resp.Results[0].Index != want {
+			t.Fatalf("only=%s with an expired card: %+v, want only index %d", only, resp.Results, want)
+		}
+	}
+
+	// Unfiltered results have no index
+	w := postBatch(t, "/validate/batch", body)
+	if strings.Contains(w.Body.String(), `"index"`) {
+		t.Errorf("unfiltered response includes index: %s", w.Body.String())
+	}
+
+	// Nothing matching is an empty list, not null
+	w = postBatch(t, "/validate/batch?only=invalid", batchBody(t, []string{"4111111111111111"}))
+	if !strings.Contains(w.Body.String(), `"results":[]`) {
+		t.Errorf("no matches: body = %s, want an empty results list", w.Body.String())
+	}
+}
+
+func TestBatchOnlyFilterUnknown(t *testing.T) {
+	w := postBatch(t, "/validate/batch?only=expired", batchBody(t, []string{"4111111111111111"}))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", w.Code)
+	}
+	if detail := decodeErrorDetail(t, w); detail.Code != middleware.ErrCodeInvalidQuery {
+		t.Errorf("code = %q, want %q", detail.Code, middleware.ErrCodeInvalidQuery)
+	}
+}
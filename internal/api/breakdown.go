@@ -0,0 +1,69 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/jamesmeyerr/credit-card-validator/internal/luhn"
+	"github.com/jamesmeyerr/credit-card-validator/internal/middleware"
+)
+
+// BreakdownResponse represents the JSON response with the Luhn per-digit breakdown
+type BreakdownResponse struct {
+	Digits []luhn.DigitStep `json:"digits"`
+	Sum    int              `json:"sum"`
+	Valid  bool             `json:"valid"`
+}
+
+// BreakdownHandler returns the Luhn weight table for a number so auditors can verify
+// the implementation. It is only available when Config.Debug is set.
+type BreakdownHandler struct {
+	config Config
+}
+
+// NewBreakdownHandler creates a new breakdown handler
+func NewBreakdownHandler(config Config) *BreakdownHandler {
+	return &BreakdownHandler{
+		config: config,
+	}
+}
+
+// ServeHTTP returns the breakdown for a single card number
+func (h *BreakdownHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Get logger with request context
+	logger := middleware.ApplicationLogger(r.Context())
+
+	// Hide the endpoint entirely unless debugging is enabled server-side
+	if !h.config.Debug {
+		middleware.NotFoundHandler(w, r)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		logger.Warn().Str("method", r.Method).Msg("Invalid HTTP method")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Parse the request
+	var req Request
+	r.Body = http.MaxBytesReader(w, r.Body, maxSingleCardRequestSize)
+	if err := decodeJSON(r.Body, &req); err != nil {
+		logger.Warn().Err(err).Msg("Failed to parse breakdown request")
+		middleware.WriteError(w, r, http.StatusBadRequest, middleware.ErrCodeInvalidJSON, invalidJSONMessage(err))
+		return
+	}
+
+	digits, sum := luhn.LuhnBreakdown(req.CardNumber)
+
+	logger.Debug().Int("digits", len(digits)).Msg("Luhn breakdown generated")
+
+	// Return response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(BreakdownResponse{
+		Digits: digits,
+		Sum:    sum,
+		Valid:  len(digits) >= 2 && sum%10 == 0,
+	})
+}
@@ -0,0 +1,44 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// breakdown posts a body to a BreakdownHandler with debugging enabled or not
+func breakdown(t *testing.T, debug bool, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	config := DefaultConfig()
+	config.Debug = debug
+	r := httptest.NewRequest(http.MethodPost, "/luhn/breakdown", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	NewBreakdownHandler(config).ServeHTTP(w, r)
+	return w
+}
+
+func TestBreakdownHandler(t *testing.T) {
+	w := breakdown(t, true, `{"card_number":"79927398713"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+	var resp BreakdownResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Digits) != 11 || resp.Sum != 70 || !resp.Valid {
+		t.Errorf("breakdown = %d digits, sum %d, valid %v; want 11, 70, true", len(resp.Digits), resp.Sum, resp.Valid)
+	}
+	if step := resp.Digits[7]; step.Digit != 8 || !step.Doubled || step.Value != 7 {
+		t.Errorf("position 8 = %+v, want 8 doubled to 7", step)
+	}
+}
+
+func TestBreakdownHandlerRequiresDebug(t *testing.T) {
+	if w := breakdown(t, false, `{"card_number":"79927398713"}`); w.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", w.Code)
+	}
+}
@@ -0,0 +1,144 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/jamesmeyerr/credit-card-validator/internal/luhn"
+)
+
+// camelResponse mirrors Response with camelCase JSON keys.
+// Its fields must match Response exactly so the two convert directly.
+type camelResponse struct {
+	Valid          bool   `json:"valid"`
+	Network        string `json:"network,omitempty"`
+	NetworkStatus  string `json:"networkStatus"`
+	BrandSlug      string `json:"brandSlug,omitempty"`
+	CardLength     int    `json:"cardLength,omitempty"`
+	ExpiryValid    bool   `json:"expiryValid,omitempty"`
+	ExpiryFormatOK bool   `json:"expiryFormatOk,omitempty"`
+	ExpiryISO      string `json:"expiryIso,omitempty"`
+
+	NewExpiryValid    bool `json:"newExpiryValid,omitempty"`
+	NewExpiryFormatOK bool `json:"newExpiryFormatOk,omitempty"`
+	RenewalValid      bool `json:"renewalValid,omitempty"`
+
+	CVVValid       bool   `json:"cvvValid,omitempty"`
+	CVVMissing     bool   `json:"cvvMissing,omitempty"`
+	SecurityCodeLocation string `json:"securityCodeLocation,omitempty"`
+	Message        string `json:"message,omitempty"`
+	Code           string `json:"code"`
+	Outcome        string `json:"outcome"`
+
+	Score int `json:"score"`
+
+	RecentValidations int `json:"recentValidations,omitempty"`
+
+	CardHash string `json:"cardHash,omitempty"`
+
+	CardNumberOriginal string `json:"cardNumberOriginal,omitempty"`
+
+	BIN       string `json:"bin,omitempty"`
+	BINLength int    `json:"binLength,omitempty"`
+
+	Processor string `json:"processor,omitempty"`
+
+	IssuerCountryAlpha2  string `json:"issuerCountryAlpha2,omitempty"`
+	IssuerCountryAlpha3  string `json:"issuerCountryAlpha3,omitempty"`
+	IssuerCountryNumeric string `json:"issuerCountryNumeric,omitempty"`
+
+	Index *int `json:"index,omitempty"`
+
+	Candidates []luhn.NetworkCandidate `json:"candidates,omitempty"`
+
+	Debug *DebugInfo `json:"debug,omitempty"`
+}
+
+// camelBatchResponse mirrors BatchResponse with camelCase JSON keys
+type camelBatchResponse struct {
+	Results []camelResponse `json:"results"`
+	Summary *camelSummary   `json:"summary,omitempty"`
+}
+
+// camelSummary mirrors luhn.Summary with camelCase JSON keys
+type camelSummary struct {
+	Total     int            `json:"total"`
+	Valid     int            `json:"valid"`
+	Invalid   int            `json:"invalid"`
+	Expired   int            `json:"expired"`
+	ByNetwork map[string]int `json:"byNetwork"`
+}
+
+// wantsCamelCase checks if the client asked for camelCase keys via ?case=camel or X-Case-Style
+func wantsCamelCase(r *http.Request) bool {
+	style := r.URL.Query().Get("case")
+	if style == "" {
+		style = r.Header.Get("X-Case-Style")
+	}
+	return strings.EqualFold(style, "camel")
+}
+
+// wantsLowerNetwork checks if the client asked for lowercase network names via ?network_case=lower
+func wantsLowerNetwork(r *http.Request) bool {
+	return strings.EqualFold(r.URL.Query().Get("network_case"), "lower")
+}
+
+// lowerNetwork lowercases a network name and joins words with hyphens, e.g. "american-express"
+func lowerNetwork(network string) string {
+	return strings.Join(strings.Fields(strings.ToLower(network)), "-")
+}
+
+// wantsMessage checks if the client kept the human-readable message; ?verbose=false omits it
+func wantsMessage(r *http.Request) bool {
+	return !strings.EqualFold(r.URL.Query().Get("verbose"), "false")
+}
+
+// presentResponse applies the client's display options to a single result
+func presentResponse(r *http.Request, resp Response) Response {
+	if wantsLowerNetwork(r) {
+		resp.Network = lowerNetwork(resp.Network)
+	}
+	if !wantsMessage(r) {
+		resp.Message = ""
+	}
+	return resp
+}
+
+// presentedResponse applies the client's display options and key style to a single result
+func presentedResponse(r *http.Request, resp Response) interface{} {
+	resp = presentResponse(r, resp)
+	if wantsCamelCase(r) {
+		return camelResponse(resp)
+	}
+	return resp
+}
+
+// encodeResponse writes a validation response in the client's preferred key style
+func encodeResponse(w http.ResponseWriter, r *http.Request, resp Response) error {
+	return json.NewEncoder(w).Encode(presentedResponse(r, resp))
+}
+
+// encodeBatchResponse writes a batch response in the client's preferred key style
+func encodeBatchResponse(w http.ResponseWriter, r *http.Request, resp BatchResponse) error {
+	if wantsLowerNetwork(r) || !wantsMessage(r) {
+		// Copy before rewriting so the caller's results are left untouched
+		results := make([]Response, len(resp.Results))
+		for i, result := range resp.Results {
+			results[i] = presentResponse(r, result)
+		}
+		resp.Results = results
+	}
+	if wantsCamelCase(r) {
+		camel := camelBatchResponse{Results: make([]camelResponse, len(resp.Results))}
+		for i, result := range resp.Results {
+			camel.Results[i] = camelResponse(result)
+		}
+		if resp.Summary != nil {
+			summary := camelSummary(*resp.Summary)
+			camel.Summary = &summary
+		}
+		return json.NewEncoder(w).Encode(camel)
+	}
+	return json.NewEncoder(w).Encode(resp)
+}
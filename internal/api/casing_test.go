@@ -0,0 +1,197 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// responseKeys decodes a JSON object and returns its keys
+func responseKeys(t *testing.T, body []byte) map[string]json.RawMessage {
+	t.Helper()
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(body, &keys); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	return keys
+}
+
+func TestResponseKeyStyles(t *testing.T) {
+	tests := []struct {
+		name    string
+		target  string
+		header  string
+		present []string
+		absent  []string
+	}{
+		{"default", "/validate", "", []string{"card_length", "network_status"}, []string{"cardLength", "networkStatus"}},
+		{"snake query", "/validate?case=snake", "", []string{"card_length", "network_status"}, []string{"cardLength"}},
+		{"camel query", "/validate?case=camel", "", []string{"cardLength", "networkStatus"}, []string{"card_length", "network_status"}},
+		{"camel header", "/validate", "camel", []string{"cardLength", "networkStatus"}, []string{"card_length"}},
+		{"query wins over header", "/validate?case=snake", "camel", []string{"card_length"}, []string{"cardLength"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, tt.target, strings.NewReader(`{"card_number":"4111111111111111"}`))
+			r.Header.Set("Content-Type", "application/json")
+			if tt.header != "" {
+				r.Header.Set("X-Case-Style", tt.header)
+			}
+			w := httptest.NewRecorder()
+			NewValidationHandler(DefaultConfig()).ServeHTTP(w, r)
+
+			keys := responseKeys(t, w.Body.Bytes())
+			for _, key := range tt.present {
+				if _, ok := keys[key]; !ok {
+					t.Errorf("key %q missing", key)
+				}
+			}
+			for _, key := range tt.absent {
+				if _, ok := keys[key]; ok {
+					t.Errorf("key %q present", key)
+				}
+			}
+		})
+	}
+}
+
+func TestBatchResponseCamelCase(t *testing.T) {
+	w := postBatch(t, "/validate/batch?case=camel&summary=true", `{"cards":[{"card_number":"4111111111111111"}]}`)
+
+	var resp struct {
+		Results []map[string]json.RawMessage `json:"results"`
+		Summary map[string]json.RawMessage   `json:"summary"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Results) != 1 {
+		t.Fatalf("%d results, want 1", len(resp.Results))
+	}
+	if _, ok := resp.Results[0]["cardLength"]; !ok {
+		t.Error("result is missing cardLength")
+	}
+	if _, ok := resp.Summary["byNetwork"]; !ok {
+		t.Error("summary is missing byNetwork")
+	}
+}
+
+func TestCamelResponseMatchesResponse(t *testing.T) {
+	// camelResponse must list the same fields as Response, in the same order, for the conversion
+	snake := jsonFields(Response{})
+	camel := jsonFields(camelResponse{})
+	if len(snake) != len(camel) {
+		t.Fatalf("Response has %d JSON fields, camelResponse has %d", len(snake), len(camel))
+	}
+	for i := range snake {
+		want := snakeToCamel(snake[i])
+		if !strings.EqualFold(camel[i], want) {
+			t.Errorf("field %d: Response %q, camelResponse %q", i, snake[i], camel[i])
+		}
+	}
+}
+
+// snakeToCamel converts a snake_case key to camelCase
+func snakeToCamel(key string) string {
+	parts := strings.Split(key, "_")
+	for i := 1; i < len(parts); i++ {
+		parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+	}
+	return strings.Join(parts, "")
+}
+
+func TestLowerNetwork(t *testing.T) {
+	tests := map[string]string{
+		"American Express": "american-express",
+		"Diners Club":      "diners-club",
+		"Visa":             "visa",
+		"JCB":              "jcb",
+		"Unknown":          "unknown",
+		"":                 "",
+	}
+	for network, want := range tests {
+		if got := lowerNetwork(network); got != want {
+			t.Errorf("lowerNetwork(%q) = %q, want %q", network, got, want)
+		}
+	}
+}
+
+func TestNetworkCaseLower(t *testing.T) {
+	tests := []struct {
+		number  string
+		network string
+		slug    string
+	}{
+		{"378282246310005", "American Express", "amex"},
+		{"4111111111111111", "Visa", "visa"},
+	}
+
+	for _, tt := range tests {
+		body := `{"card_number":"` + tt.number + `"}`
+
+		// The slug is always present; the network name is only rewritten on request
+		resp := decodeResponse(t, validate(t, "/validate", body))
+		if resp.Network != tt.network || resp.BrandSlug != tt.slug {
+			t.Errorf("%s: network %q, brand slug %q; want %q, %q", tt.number, resp.Network, resp.BrandSlug, tt.network, tt.slug)
+		}
+
+		for _, value := range []string{"lower", "LOWER"} {
+			resp = decodeResponse(t, validate(t, "/validate?network_case="+value, body))
+			if want := lowerNetwork(tt.network); resp.Network != want || resp.BrandSlug != tt.slug {
+				t.Errorf("%s with network_case=%s: network %q, brand slug %q; want %q, %q",
+					tt.number, value, resp.Network, resp.BrandSlug, want, tt.slug)
+			}
+		}
+	}
+}
+
+func TestBatchNetworkCaseLower(t *testing.T) {
+	var resp BatchResponse
+	body := batchBody(t, []string{"378282246310005", "4111111111111111"})
+	if err := json.NewDecoder(postBatch(t, "/validate/batch?network_case=lower", body).Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Results) != 2 || resp.Results[0].Network != "american-express" || resp.Results[1].Network != "visa" {
+		t.Errorf("results = %+v", resp.Results)
+	}
+}
+
+func TestVerboseFalseOmitsMessage(t *testing.T) {
+	body := `{"card_number":"4111111111111111"}`
+
+	// The message is kept by default and for any value other than false
+	for _, target := range []string{"/validate", "/validate?verbose=true", "/validate?verbose=1"} {
+		if keys := responseKeys(t, validate(t, target, body).Body.Bytes()); keys["message"] == nil {
+			t.Errorf("%s: message missing", target)
+		}
+	}
+
+	for _, target := range []string{"/validate?verbose=false", "/validate?verbose=FALSE", "/validate?verbose=false&case=camel"} {
+		keys := responseKeys(t, validate(t, target, body).Body.Bytes())
+		if _, ok := keys["message"]; ok {
+			t.Errorf("%s: message present", target)
+		}
+		// Everything a client needs without the English text is still there
+		if keys["outcome"] == nil || keys["valid"] == nil {
+			t.Errorf("%s: keys = %v, want outcome and valid", target, keys)
+		}
+	}
+}
+
+func TestBatchVerboseFalseOmitsMessage(t *testing.T) {
+	body := batchBody(t, []string{"4111111111111111", "4111111111111112"})
+	var resp struct {
+		Results []map[string]json.RawMessage `json:"results"`
+	}
+	if err := json.NewDecoder(postBatch(t, "/validate/batch?verbose=false", body).Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	for i, result := range resp.Results {
+		if _, ok := result["message"]; ok {
+			t.Errorf("result %d: message present", i)
+		}
+	}
+}
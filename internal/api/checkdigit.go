@@ -0,0 +1,70 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/jamesmeyerr/credit-card-validator/internal/luhn"
+	"github.com/jamesmeyerr/credit-card-validator/internal/middleware"
+)
+
+// CheckDigitRequest represents the JSON request for resolving an unreadable check digit
+type CheckDigitRequest struct {
+	CardNumber string `json:"card_number"` // Final digit replaced with X or ?
+}
+
+// CheckDigitResponse represents the JSON response with the resolved check digit
+type CheckDigitResponse struct {
+	CheckDigit int `json:"check_digit"`
+}
+
+// maxSingleCardRequestSize limits request bodies carrying a single card number
+const maxSingleCardRequestSize = 1024
+
+// CheckDigitHandler resolves the check digit for OCR'd numbers with an unreadable final digit
+type CheckDigitHandler struct {
+	config Config
+}
+
+// NewCheckDigitHandler creates a new check digit handler
+func NewCheckDigitHandler(config Config) *CheckDigitHandler {
+	return &CheckDigitHandler{
+		config: config,
+	}
+}
+
+// ServeHTTP resolves the check digit of a single card number
+func (h *CheckDigitHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Get logger with request context
+	logger := middleware.ApplicationLogger(r.Context())
+
+	if r.Method != http.MethodPost {
+		logger.Warn().Str("method", r.Method).Msg("Invalid HTTP method")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Parse the request
+	var req CheckDigitRequest
+	r.Body = http.MaxBytesReader(w, r.Body, maxSingleCardRequestSize)
+	if err := decodeJSON(r.Body, &req); err != nil {
+		logger.Warn().Err(err).Msg("Failed to parse check digit request")
+		middleware.WriteError(w, r, http.StatusBadRequest, middleware.ErrCodeInvalidJSON, invalidJSONMessage(err))
+		return
+	}
+
+	checkDigit, ok := luhn.ResolveCheckDigit(req.CardNumber)
+	if !ok {
+		logger.Warn().Msg("Check digit request without a single trailing placeholder")
+		middleware.WriteError(w, r, h.config.Status.ValidationStatus(), middleware.ErrCodeInvalidPlaceholder,
+			"card_number must end with exactly one unknown digit marked X or ?")
+		return
+	}
+
+	logger.Info().Msg("Check digit resolved")
+
+	// Return response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(CheckDigitResponse{CheckDigit: checkDigit})
+}
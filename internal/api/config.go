@@ -0,0 +1,38 @@
+package api
+
+import (
+	"github.com/jamesmeyerr/credit-card-validator/internal/luhn"
+	"github.com/jamesmeyerr/credit-card-validator/internal/middleware"
+)
+
+// Config holds the deployment settings the validation endpoints share. main builds one and
+// passes it to every handler, so HTTP and gRPC apply the same policy.
+type Config struct {
+	// Validation is the policy passed to luhn with every card
+	Validation luhn.ValidationConfig
+
+	// Status chooses between 400 and 422 for requests that fail validation; share it with the
+	// sanitizer so every field error uses the same status
+	Status middleware.StatusConfig
+
+	// Debug allows clients to request debug information with ?debug=true and enables
+	// /luhn/breakdown. It must stay disabled in production.
+	Debug bool
+
+	// CardHashKey is the server secret for the card_hash response field. The field is omitted
+	// unless a key is configured, since an unkeyed hash of a card number can be brute-forced.
+	CardHashKey []byte
+
+	// RequiredFields lists the fields every validation request must include, in reporting
+	// order. Build it with ParseRequiredFields or LoadRequiredFieldsFile.
+	RequiredFields []string
+}
+
+// DefaultConfig returns a default configuration
+func DefaultConfig() Config {
+	return Config{
+		Validation:     luhn.DefaultValidationConfig(),
+		Status:         middleware.DefaultStatusConfig(),
+		RequiredFields: []string{"card_number"},
+	}
+}
@@ -0,0 +1,34 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/jamesmeyerr/credit-card-validator/internal/luhn"
+)
+
+// DebugInfo contains diagnostic details about a validation. It never includes the card number.
+type DebugInfo struct {
+	Checksum int    `json:"checksum"` // Luhn sum modulo 10
+	Rule     string `json:"rule"`     // Network rule that matched, or "none"
+	Duration string `json:"duration"` // Time spent validating
+}
+
+// wantsDebug checks if debug output is both enabled server-side and requested by the client
+func (c Config) wantsDebug(r *http.Request) bool {
+	return c.Debug && r.URL.Query().Get("debug") == "true"
+}
+
+// buildDebugInfo collects debug details for a validated card number
+func buildDebugInfo(cardNumber string, elapsed time.Duration) *DebugInfo {
+	rule := "none"
+	if matched, ok := luhn.MatchRule(cardNumber); ok {
+		rule = matched.String()
+	}
+
+	return &DebugInfo{
+		Checksum: luhn.Checksum(cardNumber),
+		Rule:     rule,
+		Duration: elapsed.String(),
+	}
+}
@@ -0,0 +1,52 @@
+package api
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDebugInfo(t *testing.T) {
+	tests := []struct {
+		enabled bool
+		target  string
+		want    bool
+	}{
+		{false, "/validate", false},
+		{false, "/validate?debug=true", false},
+		{true, "/validate", false},
+		{true, "/validate?debug=false", false},
+		{true, "/validate?debug=true", true},
+	}
+
+	for _, tt := range tests {
+		config := DefaultConfig()
+		config.Debug = tt.enabled
+		w := validateWith(t, config, tt.target, `{"card_number":"4111111111111111"}`)
+		body := w.Body.String()
+		resp := decodeResponse(t, w)
+
+		if (resp.Debug != nil) != tt.want {
+			t.Errorf("Debug=%v %s: debug present %v, want %v", tt.enabled, tt.target, resp.Debug != nil, tt.want)
+			continue
+		}
+		if !tt.want {
+			continue
+		}
+		if resp.Debug.Checksum != 0 || resp.Debug.Rule != "Visa 4" || resp.Debug.Duration == "" {
+			t.Errorf("debug = %+v", resp.Debug)
+		}
+		if strings.Contains(body, "4111111111111111") {
+			t.Error("response contains the card number")
+		}
+	}
+}
+
+func TestDebugInfoUnmatched(t *testing.T) {
+	info := buildDebugInfo("9999999999999995", 0)
+	if info.Rule != "none" || info.Checksum != 0 {
+		t.Errorf("debug = %+v", info)
+	}
+	if info := buildDebugInfo("4111111111111112", 0); info.Checksum != 1 {
+		t.Errorf("checksum = %d, want 1", info.Checksum)
+	}
+}
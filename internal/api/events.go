@@ -0,0 +1,38 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/jamesmeyerr/credit-card-validator/internal/events"
+	"github.com/jamesmeyerr/credit-card-validator/internal/luhn"
+	"github.com/jamesmeyerr/credit-card-validator/internal/middleware"
+)
+
+// EventDispatcher receives an event for every validation when a webhook is configured
+var EventDispatcher *events.Dispatcher
+
+// AuditLog records every validation decision when an audit log is configured
+var AuditLog *events.AuditLogger
+
+// emitValidationEvent queues a masked validation event for the webhook and appends it to the
+// audit log, whichever are configured
+func emitValidationEvent(ctx context.Context, cardNumber string, cardInfo luhn.CardInfo) {
+	if EventDispatcher == nil && AuditLog == nil {
+		return
+	}
+
+	event := events.ValidationEvent{
+		MaskedNumber: luhn.Mask(luhn.Clean(cardNumber), 6, 4),
+		Network:      cardInfo.Network,
+		Outcome:      cardInfo.Outcome,
+		RequestID:    middleware.GetRequestID(ctx),
+		Timestamp:    time.Now().UTC(),
+	}
+
+	EventDispatcher.Dispatch(event)
+	if err := AuditLog.Log(event); err != nil {
+		logger := middleware.ApplicationLogger(ctx)
+		logger.Error().Err(err).Msg("Failed to write audit record")
+	}
+}
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jamesmeyerr/credit-card-validator/internal/events"
+)
+
+// setAuditLog records audit events in a buffer for one test
+func setAuditLog(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	audit, err := events.NewAuditLogger(events.AuditConfig{Writer: &buf})
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := AuditLog
+	AuditLog = audit
+	t.Cleanup(func() { AuditLog = previous })
+	return &buf
+}
+
+// postAudited sends a JSON POST to handler
+func postAudited(handler http.Handler, target, body string) {
+	r := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+}
+
+func TestValidationAuditRecordMasked(t *testing.T) {
+	buf := setAuditLog(t)
+	expiry := time.Now().AddDate(2, 0, 0).Format("01/06")
+	postAudited(NewValidationHandler(DefaultConfig()), "/validate", `{"card_number":"4111 1111 1111 1111","expiry_date":"`+expiry+`","cvv":"737"}`)
+	postAudited(NewBatchHandler(DefaultBatchConfig()), "/validate/batch", `{"cards":[{"card_number":"5500000000000004"},{"card_number":"4111111111111112"}]}`)
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("%d audit records, want 3: %s", len(lines), buf.String())
+	}
+	want := []events.ValidationEvent{
+		{MaskedNumber: "411111******1111", Network: "Visa", Outcome: "valid"},
+		{MaskedNumber: "550000******0004", Network: "Mastercard", Outcome: "valid"},
+		{MaskedNumber: "411111******1112", Network: "Visa", Outcome: "invalid_luhn"},
+	}
+	for i, line := range lines {
+		var event events.ValidationEvent
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			t.Fatal(err)
+		}
+		if event.MaskedNumber != want[i].MaskedNumber || event.Network != want[i].Network || event.Outcome != want[i].Outcome || event.Timestamp.IsZero() {
+			t.Errorf("record %d = %+v, want %+v", i, event, want[i])
+		}
+	}
+
+	// Timestamps are full of digits, so the CVV is checked by its field name
+	for _, secret := range []string{"4111111111111111", "4111 1111", "5500000000000004", "cvv"} {
+		if strings.Contains(buf.String(), secret) {
+			t.Errorf("audit log contains %q: %s", secret, buf.String())
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/jamesmeyerr/credit-card-validator/internal/luhn"
+	"github.com/jamesmeyerr/credit-card-validator/internal/middleware"
+)
+
+// ExtractRequest represents the JSON request for finding a card number in pasted text
+type ExtractRequest struct {
+	Text string `json:"text"`
+}
+
+// ExtractResponse represents the JSON response with the card number found, if any
+type ExtractResponse struct {
+	Found      bool   `json:"found"`
+	CardNumber string `json:"card_number,omitempty"` // Digits only
+}
+
+// maxExtractRequestSize limits pasted text; form contents are far smaller
+const maxExtractRequestSize = 4 * 1024
+
+// ExtractHandler finds the card number in free text so clients can handle pasted form contents
+func ExtractHandler(w http.ResponseWriter, r *http.Request) {
+	// Get logger with request context
+	logger := middleware.ApplicationLogger(r.Context())
+
+	if r.Method != http.MethodPost {
+		logger.Warn().Str("method", r.Method).Msg("Invalid HTTP method")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Parse the request
+	var req ExtractRequest
+	r.Body = http.MaxBytesReader(w, r.Body, maxExtractRequestSize)
+	if err := decodeJSON(r.Body, &req); err != nil {
+		logger.Warn().Err(err).Msg("Failed to parse extract request")
+		middleware.WriteError(w, r, http.StatusBadRequest, middleware.ErrCodeInvalidJSON, invalidJSONMessage(err))
+		return
+	}
+
+	// The text is never logged: it is likely to contain the full card number
+	cardNumber, found := luhn.ExtractCardNumber(req.Text)
+	logger.Info().Bool("found", found).Msg("Card number extraction completed")
+
+	// Return response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(ExtractResponse{Found: found, CardNumber: cardNumber})
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// extract posts a body to ExtractHandler
+func extract(t *testing.T, method, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	r := httptest.NewRequest(method, "/extract", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	ExtractHandler(w, r)
+	return w
+}
+
+func TestExtractHandler(t *testing.T) {
+	tests := []struct {
+		text  string
+		found bool
+		want  string
+	}{
+		{"My card is 4111 1111 1111 1111 exp 09/27", true, "4111111111111111"},
+		{"amex 3782-822463-10005 cvv 1234", true, "378282246310005"},
+		{"order 12345 shipped 09/27", false, ""},
+	}
+
+	for _, tt := range tests {
+		body, _ := json.Marshal(ExtractRequest{Text: tt.text})
+		w := extract(t, http.MethodPost, string(body))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%q: status = %d, want 200", tt.text, w.Code)
+		}
+		var resp ExtractResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.Found != tt.found || resp.CardNumber != tt.want {
+			t.Errorf("%q: response = %+v, want found %v, card number %q", tt.text, resp, tt.found, tt.want)
+		}
+	}
+}
+
+func TestExtractHandlerErrors(t *testing.T) {
+	if w := extract(t, http.MethodGet, ""); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET: status = %d, want 405", w.Code)
+	}
+	if w := extract(t, http.MethodPost, `{"text":`); w.Code != http.StatusBadRequest {
+		t.Errorf("malformed JSON: status = %d, want 400", w.Code)
+	}
+
+	body, _ := json.Marshal(ExtractRequest{Text: strings.Repeat("a", maxExtractRequestSize)})
+	if w := extract(t, http.MethodPost, string(body)); w.Code != http.StatusBadRequest {
+		t.Errorf("oversized text: status = %d, want 400", w.Code)
+	}
+}
@@ -0,0 +1,225 @@
+package api
+
+//go:generate protoc -I ../../proto --go_out=validatorpb --go_opt=paths=source_relative --go-grpc_out=validatorpb --go-grpc_opt=paths=source_relative validator/v1/validator.proto
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/jamesmeyerr/credit-card-validator/internal/api/validatorpb"
+	"github.com/jamesmeyerr/credit-card-validator/internal/luhn"
+	"github.com/jamesmeyerr/credit-card-validator/internal/middleware"
+	"github.com/jamesmeyerr/credit-card-validator/internal/tracing"
+	"github.com/rs/zerolog/log"
+)
+
+// MaxBatchSize limits the number of cards accepted in a single ValidateBatch call
+const MaxBatchSize = 100
+
+// GRPCServer implements the CardValidator gRPC service
+type GRPCServer struct {
+	validatorpb.UnimplementedCardValidatorServer
+	config Config
+}
+
+// GRPCConfig defines the protections applied to gRPC calls. They match the HTTP middleware
+// chain, so the gRPC listener is not a way around it.
+type GRPCConfig struct {
+	Config // settings shared with the HTTP validation endpoints
+
+	// RequiredHeaderName and RequiredHeaderValue require every call to carry this metadata,
+	// as REQUIRED_HEADER_NAME does for HTTP. An empty name disables the check.
+	RequiredHeaderName  string
+	RequiredHeaderValue string
+
+	// RateLimiter limits calls per client; share the HTTP limiter so both transports draw on
+	// one allowance. Nil disables rate limiting.
+	RateLimiter *middleware.RateLimiter
+
+	// Sanitization sets the field length and format rules, as for /validate
+	Sanitization middleware.SanitizationConfig
+}
+
+// DefaultGRPCConfig returns a default configuration
+func DefaultGRPCConfig() GRPCConfig {
+	return GRPCConfig{
+		Config:       DefaultConfig(),
+		Sanitization: middleware.DefaultSanitizationConfig(),
+	}
+}
+
+// NewGRPCServer creates a gRPC server with the CardValidator service registered. Calls pass
+// the required header check, the rate limiter, and the sanitizer's field checks, in the
+// same order as HTTP requests.
+func NewGRPCServer(config GRPCConfig) *grpc.Server {
+	var interceptors []grpc.UnaryServerInterceptor
+	if config.RequiredHeaderName != "" {
+		interceptors = append(interceptors, middleware.RequireMetadataInterceptor(config.RequiredHeaderName, config.RequiredHeaderValue))
+	}
+	if config.RateLimiter != nil {
+		interceptors = append(interceptors, config.RateLimiter.UnaryInterceptor())
+	}
+	interceptors = append(interceptors, sanitizeInterceptor(config.Sanitization))
+
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(interceptors...))
+	validatorpb.RegisterCardValidatorServer(server, &GRPCServer{config: config.Config})
+	return server
+}
+
+// sanitizeInterceptor applies the HTTP sanitizer's size, length, and format rules to each card
+// in a call, replacing its fields with their sanitized forms
+func sanitizeInterceptor(config middleware.SanitizationConfig) grpc.UnaryServerInterceptor {
+	sanitizer := middleware.NewInputSanitizer(config)
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		switch req := req.(type) {
+		case *validatorpb.ValidateRequest:
+			if err := sanitizeProtoRequest(sanitizer, config.MaxRequestSize, req, ""); err != nil {
+				return nil, err
+			}
+		case *validatorpb.ValidateBatchRequest:
+			for i, item := range req.GetRequests() {
+				if err := sanitizeProtoRequest(sanitizer, config.MaxRequestSize, item, fmt.Sprintf("requests[%d]: ", i)); err != nil {
+					return nil, err
+				}
+			}
+		}
+		return handler(ctx, req)
+	}
+}
+
+// sanitizeProtoRequest checks one card, prefixing any error message to locate it in a batch
+func sanitizeProtoRequest(sanitizer *middleware.InputSanitizer, maxSize int64, req *validatorpb.ValidateRequest, prefix string) error {
+	// Each card is held to the size of a /validate body
+	if size := proto.Size(req); int64(size) > maxSize {
+		return status.Errorf(codes.InvalidArgument, "%srequest exceeds max size %d bytes", prefix, maxSize)
+	}
+
+	fields, err := sanitizer.SanitizeFields(middleware.CardFields{
+		CardNumber:    req.GetCardNumber(),
+		ExpiryDate:    req.GetExpiryDate(),
+		NewExpiryDate: req.GetNewExpiryDate(),
+		CVV:           req.GetCvv(),
+	})
+	if err != nil {
+		return status.Error(codes.InvalidArgument, prefix+err.Error())
+	}
+	req.CardNumber = fields.CardNumber
+	req.ExpiryDate = fields.ExpiryDate
+	req.NewExpiryDate = fields.NewExpiryDate
+	return nil
+}
+
+// Validate checks a single card using the same logic as the HTTP handler
+func (s *GRPCServer) Validate(ctx context.Context, req *validatorpb.ValidateRequest) (*validatorpb.ValidateResponse, error) {
+	if missing := s.config.missingFields(fromProtoRequest(req)); len(missing) > 0 {
+		return nil, status.Error(codes.InvalidArgument, missingFieldsMessage(missing))
+	}
+
+	_, span := tracing.StartValidationSpan(ctx)
+	cardInfo, err := luhn.ValidateCardContext(ctx, s.config.toValidationRequest(req))
+	if err != nil {
+		span.End()
+		return nil, status.FromContextError(err).Err()
+	}
+	tracing.RecordValidation(span, cardInfo.Network, cardInfo.Outcome)
+	span.End()
+	emitValidationEvent(ctx, req.GetCardNumber(), cardInfo)
+	resp := toProtoResponse(cardInfo)
+
+	log.Info().
+		Bool("valid", resp.Valid).
+		Str("network", resp.Network).
+		Msg("gRPC card validation result")
+
+	return resp, nil
+}
+
+// ValidateBatch checks several cards, returning results in request order
+func (s *GRPCServer) ValidateBatch(ctx context.Context, req *validatorpb.ValidateBatchRequest) (*validatorpb.ValidateBatchResponse, error) {
+	if len(req.GetRequests()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "At least one card is required")
+	}
+	if len(req.GetRequests()) > MaxBatchSize {
+		return nil, status.Errorf(codes.InvalidArgument, "Batch exceeds maximum size of %d", MaxBatchSize)
+	}
+
+	// Required fields are enforced per card, as in the HTTP batch endpoint
+	var missing []string
+	for i, item := range req.GetRequests() {
+		for _, field := range s.config.missingFields(fromProtoRequest(item)) {
+			missing = append(missing, fmt.Sprintf("requests[%d].%s", i, field))
+		}
+	}
+	if len(missing) > 0 {
+		return nil, status.Error(codes.InvalidArgument, missingFieldsMessage(missing))
+	}
+
+	validationReqs := make([]luhn.CardValidationRequest, len(req.GetRequests()))
+	for i, item := range req.GetRequests() {
+		validationReqs[i] = s.config.toValidationRequest(item)
+	}
+
+	// Validate in parallel, stopping early if the caller cancels
+	cardInfos, err := luhn.ValidateBatch(ctx, validationReqs, luhn.DefaultBatchConcurrency)
+	if err != nil {
+		return nil, status.FromContextError(err).Err()
+	}
+
+	results := make([]*validatorpb.ValidateResponse, len(cardInfos))
+	for i, cardInfo := range cardInfos {
+		results[i] = toProtoResponse(cardInfo)
+		emitValidationEvent(ctx, validationReqs[i].CardNumber, cardInfo)
+	}
+
+	log.Info().Int("count", len(results)).Msg("gRPC batch validation completed")
+
+	return &validatorpb.ValidateBatchResponse{Results: results}, nil
+}
+
+// fromProtoRequest converts a protobuf request into an HTTP request so both share required field checks
+func fromProtoRequest(req *validatorpb.ValidateRequest) Request {
+	return Request{
+		CardNumber:    req.GetCardNumber(),
+		ExpiryDate:    req.GetExpiryDate(),
+		NewExpiryDate: req.GetNewExpiryDate(),
+		CVV:           req.GetCvv(),
+	}
+}
+
+// toValidationRequest converts a protobuf request into a validation request under the configured policy
+func (c Config) toValidationRequest(req *validatorpb.ValidateRequest) luhn.CardValidationRequest {
+	return luhn.CardValidationRequest{
+		CardNumber:    req.GetCardNumber(),
+		ExpiryDate:    req.GetExpiryDate(),
+		NewExpiryDate: req.GetNewExpiryDate(),
+		CVV:           req.GetCvv(),
+		Config:        c.Validation,
+	}
+}
+
+// toProtoResponse converts validation results into a protobuf response
+func toProtoResponse(cardInfo luhn.CardInfo) *validatorpb.ValidateResponse {
+	return &validatorpb.ValidateResponse{
+		Valid:          cardInfo.Valid,
+		Network:        cardInfo.Network,
+		CardLength:     int32(cardInfo.CardLength),
+		ExpiryValid:    cardInfo.ExpiryValid,
+		ExpiryFormatOk: cardInfo.ExpiryFormatOK,
+		CvvValid:       cardInfo.CVVValid,
+		Message:        buildResponseMessage(cardInfo, defaultLanguage),
+		NetworkStatus:  cardInfo.NetworkStatus,
+		Outcome:        cardInfo.Outcome,
+		Code:           responseCode(cardInfo),
+		Score:          int32(luhn.Score(cardInfo)),
+
+		NewExpiryValid:    cardInfo.NewExpiryValid,
+		NewExpiryFormatOk: cardInfo.NewExpiryFormatOK,
+		RenewalValid:      cardInfo.RenewalValid,
+	}
+}
@@ -0,0 +1,292 @@
+package api
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/jamesmeyerr/credit-card-validator/internal/api/validatorpb"
+	"github.com/jamesmeyerr/credit-card-validator/internal/luhn"
+	"github.com/jamesmeyerr/credit-card-validator/internal/middleware"
+)
+
+// newGRPCClient starts the service on an in-memory listener and returns a client for it
+func newGRPCClient(t *testing.T) validatorpb.CardValidatorClient {
+	t.Helper()
+	return newGRPCClientWithConfig(t, DefaultGRPCConfig())
+}
+
+// newGRPCClientWithConfig is newGRPCClient for a server built from config
+func newGRPCClientWithConfig(t *testing.T, config GRPCConfig) validatorpb.CardValidatorClient {
+	t.Helper()
+
+	listener := bufconn.Listen(1024 * 1024)
+	server := NewGRPCServer(config)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return validatorpb.NewCardValidatorClient(conn)
+}
+
+func TestGRPCValidate(t *testing.T) {
+	client := newGRPCClient(t)
+
+	resp, err := client.Validate(context.Background(), &validatorpb.ValidateRequest{
+		CardNumber: "4111 1111 1111 1111",
+		ExpiryDate: futureExpiry(),
+		Cvv:        "123",
+	})
+	if err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if !resp.Valid || resp.Network != "Visa" || resp.CardLength != 16 || !resp.ExpiryValid || !resp.CvvValid {
+		t.Errorf("response = %+v", resp)
+	}
+	if resp.Message == "" {
+		t.Error("message is empty")
+	}
+	if resp.Outcome != luhn.OutcomeValid || resp.Code != CodeValid || resp.NetworkStatus != luhn.NetworkIdentified || resp.Score != 100 {
+		t.Errorf("outcome %q, code %q, network status %q, score %d; want the /validate values", resp.Outcome, resp.Code, resp.NetworkStatus, resp.Score)
+	}
+
+	resp, err = client.Validate(context.Background(), &validatorpb.ValidateRequest{CardNumber: "4111111111111112"})
+	if err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if resp.Valid || resp.Outcome != luhn.OutcomeInvalidLuhn || resp.Code != CodeInvalidLuhn {
+		t.Errorf("failed checksum: valid %v, outcome %q, code %q", resp.Valid, resp.Outcome, resp.Code)
+	}
+}
+
+func TestGRPCRenewal(t *testing.T) {
+	client := newGRPCClient(t)
+	now := time.Now()
+
+	resp, err := client.Validate(context.Background(), &validatorpb.ValidateRequest{
+		CardNumber:    "4111111111111111",
+		ExpiryDate:    now.AddDate(1, 0, 0).Format("01/06"),
+		NewExpiryDate: now.AddDate(3, 0, 0).Format("01/06"),
+	})
+	if err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if !resp.NewExpiryValid || !resp.NewExpiryFormatOk || !resp.RenewalValid {
+		t.Errorf("renewal: %+v, want a valid later expiry", resp)
+	}
+
+	// A new expiry before the old one is not a renewal
+	resp, err = client.Validate(context.Background(), &validatorpb.ValidateRequest{
+		CardNumber:    "4111111111111111",
+		ExpiryDate:    now.AddDate(3, 0, 0).Format("01/06"),
+		NewExpiryDate: now.AddDate(1, 0, 0).Format("01/06"),
+	})
+	if err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if !resp.NewExpiryValid || resp.RenewalValid {
+		t.Errorf("earlier new expiry: new expiry valid %v, renewal valid %v; want true, false", resp.NewExpiryValid, resp.RenewalValid)
+	}
+
+	// The sanitizer checks the new expiry's format
+	_, err = client.Validate(context.Background(), &validatorpb.ValidateRequest{CardNumber: "4111111111111111", NewExpiryDate: "13/30"})
+	if status.Code(err) != codes.InvalidArgument || !strings.Contains(err.Error(), "new_expiry_date must be in MM/YY format") {
+		t.Errorf("bad new expiry: error = %v", err)
+	}
+}
+
+func TestGRPCRequiredMetadata(t *testing.T) {
+	config := DefaultGRPCConfig()
+	config.RequiredHeaderName, config.RequiredHeaderValue = "X-Gateway-Secret", "s3cret"
+	client := newGRPCClientWithConfig(t, config)
+	req := &validatorpb.ValidateRequest{CardNumber: "4111111111111111"}
+
+	tests := []struct {
+		name     string
+		metadata []string
+		want     codes.Code
+	}{
+		{"missing", nil, codes.PermissionDenied},
+		{"wrong value", []string{"x-gateway-secret", "s3cret!"}, codes.PermissionDenied},
+		{"correct value", []string{"x-gateway-secret", "s3cret"}, codes.OK},
+		{"other case", []string{"X-Gateway-Secret", "s3cret"}, codes.OK},
+	}
+	for _, tt := range tests {
+		ctx := metadata.AppendToOutgoingContext(context.Background(), tt.metadata...)
+		if _, err := client.Validate(ctx, req); status.Code(err) != tt.want {
+			t.Errorf("%s: error = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestGRPCRateLimit(t *testing.T) {
+	limiter, err := middleware.NewRateLimiter(middleware.RateLimiterConfig{Rate: 0.001, BucketSize: 2, CleanupInterval: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer limiter.Shutdown()
+	config := DefaultGRPCConfig()
+	config.RateLimiter = limiter
+	client := newGRPCClientWithConfig(t, config)
+
+	// Single and batch calls draw on the same bucket
+	if _, err := client.Validate(context.Background(), &validatorpb.ValidateRequest{CardNumber: "4111111111111111"}); err != nil {
+		t.Fatalf("first call: %v", err)
+	}
+	batch := &validatorpb.ValidateBatchRequest{Requests: []*validatorpb.ValidateRequest{{CardNumber: "4111111111111111"}}}
+	if _, err := client.ValidateBatch(context.Background(), batch); err != nil {
+		t.Fatalf("second call: %v", err)
+	}
+	_, err = client.Validate(context.Background(), &validatorpb.ValidateRequest{CardNumber: "4111111111111111"})
+	if status.Code(err) != codes.ResourceExhausted || !strings.Contains(err.Error(), "Rate limit exceeded") {
+		t.Errorf("third call: error = %v, want ResourceExhausted", err)
+	}
+}
+
+func TestGRPCSanitizesFields(t *testing.T) {
+	client := newGRPCClient(t)
+
+	tests := []struct {
+		name string
+		req  *validatorpb.ValidateRequest
+		want string
+	}{
+		{"too many digits", &validatorpb.ValidateRequest{CardNumber: "41111111111111111111"}, "card_number exceeds max length 19, received 20"},
+		{"no digits", &validatorpb.ValidateRequest{CardNumber: "---- ----"}, "No digits found in card number"},
+		{"bad expiry", &validatorpb.ValidateRequest{CardNumber: "4111111111111111", ExpiryDate: "13/30"}, "expiry_date must be in MM/YY format"},
+		{"long expiry", &validatorpb.ValidateRequest{CardNumber: "4111111111111111", ExpiryDate: "12/2030"}, "expiry_date exceeds max length 5, received 7"},
+		{"bad cvv", &validatorpb.ValidateRequest{CardNumber: "4111111111111111", Cvv: "12a"}, "cvv must be 3 or 4 digits"},
+		{"long cvv", &validatorpb.ValidateRequest{CardNumber: "4111111111111111", Cvv: "12345"}, "cvv exceeds max length 4, received 5"},
+		{"oversized input", &validatorpb.ValidateRequest{CardNumber: strings.Repeat(" ", 2000) + "4111111111111111"}, "request exceeds max size 1024 bytes"},
+	}
+	for _, tt := range tests {
+		_, err := client.Validate(context.Background(), tt.req)
+		if status.Code(err) != codes.InvalidArgument || status.Convert(err).Message() != tt.want {
+			t.Errorf("%s: error = %v, want InvalidArgument %q", tt.name, err, tt.want)
+		}
+	}
+
+	// Batch errors name the failing card
+	batch := &validatorpb.ValidateBatchRequest{Requests: []*validatorpb.ValidateRequest{{CardNumber: "4111111111111111"}, {CardNumber: "4111111111111111", Cvv: "1"}}}
+	if _, err := client.ValidateBatch(context.Background(), batch); status.Convert(err).Message() != "requests[1]: cvv must be 3 or 4 digits" {
+		t.Errorf("batch error = %v", err)
+	}
+}
+
+func TestGRPCLenientExpiry(t *testing.T) {
+	config := DefaultGRPCConfig()
+	config.Sanitization.LenientExpiry = true
+	client := newGRPCClientWithConfig(t, config)
+
+	expiry := time.Now().AddDate(2, 0, 0)
+	resp, err := client.Validate(context.Background(), &validatorpb.ValidateRequest{CardNumber: "4111 1111 1111 1111", ExpiryDate: expiry.Format("1/2006")})
+	if err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if !resp.ExpiryValid || !resp.ExpiryFormatOk {
+		t.Errorf("response = %+v, want the canonicalized expiry accepted", resp)
+	}
+}
+
+func TestGRPCValidateMissingCardNumber(t *testing.T) {
+	client := newGRPCClient(t)
+
+	_, err := client.Validate(context.Background(), &validatorpb.ValidateRequest{Cvv: "123"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("error = %v, want InvalidArgument", err)
+	}
+}
+
+func TestGRPCValidateBatch(t *testing.T) {
+	client := newGRPCClient(t)
+
+	numbers := []string{"4111111111111111", "4111111111111112", "378282246310005"}
+	req := &validatorpb.ValidateBatchRequest{}
+	for _, number := range numbers {
+		req.Requests = append(req.Requests, &validatorpb.ValidateRequest{CardNumber: number})
+	}
+
+	resp, err := client.ValidateBatch(context.Background(), req)
+	if err != nil {
+		t.Fatalf("ValidateBatch: %v", err)
+	}
+	if len(resp.Results) != len(numbers) {
+		t.Fatalf("%d results, want %d", len(resp.Results), len(numbers))
+	}
+
+	// Results come back in request order
+	want := []struct {
+		valid   bool
+		network string
+	}{{true, "Visa"}, {false, "Visa"}, {true, "American Express"}}
+	for i, result := range resp.Results {
+		if result.Valid != want[i].valid || result.Network != want[i].network {
+			t.Errorf("result %d = valid %v, network %q; want %v, %q", i, result.Valid, result.Network, want[i].valid, want[i].network)
+		}
+	}
+}
+
+func TestGRPCValidateBatchLimits(t *testing.T) {
+	client := newGRPCClient(t)
+
+	if _, err := client.ValidateBatch(context.Background(), &validatorpb.ValidateBatchRequest{}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("empty batch error = %v, want InvalidArgument", err)
+	}
+
+	tooMany := &validatorpb.ValidateBatchRequest{}
+	for i := 0; i <= MaxBatchSize; i++ {
+		tooMany.Requests = append(tooMany.Requests, &validatorpb.ValidateRequest{CardNumber: "4111111111111111"})
+	}
+	if _, err := client.ValidateBatch(context.Background(), tooMany); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("oversized batch error = %v, want InvalidArgument", err)
+	}
+
+	missing := &validatorpb.ValidateBatchRequest{Requests: []*validatorpb.ValidateRequest{
+		{CardNumber: "4111111111111111"},
+		{Cvv: "123"},
+	}}
+	_, err := client.ValidateBatch(context.Background(), missing)
+	if status.Code(err) != codes.InvalidArgument || !strings.Contains(status.Convert(err).Message(), "requests[1].card_number") {
+		t.Errorf("missing field error = %v, want InvalidArgument naming requests[1].card_number", err)
+	}
+}
+
+// futureExpiry returns an MM/YY expiry two years from now
+func futureExpiry() string {
+	return time.Now().AddDate(2, 0, 0).Format("01/06")
+}
+
+func TestGRPCValidationPolicy(t *testing.T) {
+	config := DefaultGRPCConfig()
+	config.Validation.CVVRequiredNetworks = []string{"American Express"}
+	client := newGRPCClientWithConfig(t, config)
+
+	// The policy from the shared Config applies to single and batch calls alike
+	amex := &validatorpb.ValidateRequest{CardNumber: "378282246310005"}
+	resp, err := client.Validate(context.Background(), amex)
+	if err != nil || resp.Outcome != luhn.OutcomeMissingCVV {
+		t.Errorf("Validate: outcome = %q, err = %v; want missing_cvv", resp.GetOutcome(), err)
+	}
+	batch, err := client.ValidateBatch(context.Background(), &validatorpb.ValidateBatchRequest{Requests: []*validatorpb.ValidateRequest{amex}})
+	if err != nil || batch.Results[0].Outcome != luhn.OutcomeMissingCVV {
+		t.Errorf("ValidateBatch: results = %v, err = %v; want missing_cvv", batch.GetResults(), err)
+	}
+}
@@ -1,34 +1,112 @@
 package api
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
-    "strings"
+	"time"
 	
 	"github.com/jamesmeyerr/credit-card-validator/internal/luhn"
 	"github.com/jamesmeyerr/credit-card-validator/internal/middleware"
+	"github.com/jamesmeyerr/credit-card-validator/internal/tracing"
 )
 
-// Request represents the JSON request structure
+// Request represents the JSON request structure.
+// Field changes must be mirrored in the Request schema in openapi.json.
 type Request struct {
 	CardNumber string `json:"card_number"`
 	ExpiryDate string `json:"expiry_date,omitempty"` // Format: MM/YY
 	CVV        string `json:"cvv,omitempty"`         // 3 or 4 digits
+
+	// NewExpiryDate is the renewed card's expiry (MM/YY) in card-update flows
+	NewExpiryDate string `json:"new_expiry_date,omitempty"`
+
+	// CheckAlgorithm is "luhn" (the default) or "none" for gift cards without a check digit
+	CheckAlgorithm string `json:"check_algorithm,omitempty"`
+
+	// LuhnOnly skips network detection and returns only the Luhn result, with network "N/A"
+	LuhnOnly bool `json:"luhn_only,omitempty"`
+
+	// CardNumberOriginal is set by the sanitizer when original formatting is preserved
+	CardNumberOriginal string `json:"card_number_original,omitempty"`
 }
 
-// Response represents the JSON response structure
+// Response represents the JSON response structure.
+// Field changes must be mirrored in the Response schema in openapi.json.
 type Response struct {
 	Valid         bool   `json:"valid"`
 	Network       string `json:"network,omitempty"`
+	NetworkStatus string `json:"network_status"`
+	BrandSlug     string `json:"brand_slug,omitempty"`
 	CardLength    int    `json:"card_length,omitempty"`
 	ExpiryValid   bool   `json:"expiry_valid,omitempty"`
 	ExpiryFormatOK bool  `json:"expiry_format_ok,omitempty"`
+	ExpiryISO     string `json:"expiry_iso,omitempty"`
+
+	// New expiry results are present when new_expiry_date was provided
+	NewExpiryValid    bool `json:"new_expiry_valid,omitempty"`
+	NewExpiryFormatOK bool `json:"new_expiry_format_ok,omitempty"`
+	RenewalValid      bool `json:"renewal_valid,omitempty"`
+
 	CVVValid      bool   `json:"cvv_valid,omitempty"`
+	CVVMissing    bool   `json:"cvv_missing,omitempty"`
+	SecurityCodeLocation string `json:"security_code_location,omitempty"`
 	Message       string `json:"message,omitempty"`
+	Code          string `json:"code"`
+	Outcome       string `json:"outcome"`
+
+	// Score is a 0-100 confidence value combining the individual checks; see luhn.Score
+	Score int `json:"score"`
+
+	// RecentValidations counts validations of this card within the tracking window, including
+	// this one; present only when duplicate tracking is enabled
+	RecentValidations int `json:"recent_validations,omitempty"`
+
+	// CardHash is a keyed hash of the card number for client-side deduplication; present only
+	// when the server has a card hash secret configured
+	CardHash string `json:"card_hash,omitempty"`
+
+	// CardNumberOriginal echoes the caller's formatted input when the sanitizer preserves it
+	CardNumberOriginal string `json:"card_number_original,omitempty"`
+
+	// BIN holds the leading digits used to identify the card (never more than 8)
+	BIN       string `json:"bin,omitempty"`
+	BINLength int    `json:"bin_length,omitempty"`
+
+	// Processor is present when a routing table is loaded and matches the card
+	Processor string `json:"processor,omitempty"`
+
+	// Issuer country codes are present when the routing table maps the card to a country
+	IssuerCountryAlpha2  string `json:"issuer_country_alpha2,omitempty"`
+	IssuerCountryAlpha3  string `json:"issuer_country_alpha3,omitempty"`
+	IssuerCountryNumeric string `json:"issuer_country_numeric,omitempty"`
+
+	// Index is the card's position in a batch request, present when a batch is filtered with ?only=
+	Index *int `json:"index,omitempty"`
+
+	// Candidates lists every matching network when requested with ?candidates=true
+	Candidates []luhn.NetworkCandidate `json:"candidates,omitempty"`
+
+	// Debug is only present when debug output is enabled and requested
+	Debug *DebugInfo `json:"debug,omitempty"`
 }
 
 // ValidationHandler handles credit card validation requests
-func ValidationHandler(w http.ResponseWriter, r *http.Request) {
+type ValidationHandler struct {
+	config Config
+}
+
+// NewValidationHandler creates a new validation handler
+func NewValidationHandler(config Config) *ValidationHandler {
+	return &ValidationHandler{
+		config: config,
+	}
+}
+
+// ServeHTTP validates a single card
+func (h *ValidationHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// Get logger with request context
 	logger := middleware.ApplicationLogger(r.Context())
 	
@@ -44,53 +122,61 @@ func ValidationHandler(w http.ResponseWriter, r *http.Request) {
 
 	// Parse the request
 	var req Request
-	decoder := json.NewDecoder(r.Body)
-	err := decoder.Decode(&req)
+	err := decodeJSON(r.Body, &req)
 	if err != nil {
 		logger.Warn().Err(err).Msg("Failed to parse JSON request")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid JSON payload"})
+		middleware.WriteError(w, r, http.StatusBadRequest, middleware.ErrCodeInvalidJSON, invalidJSONMessage(err))
 		return
 	}
 
-	// Validate the card number
-	if req.CardNumber == "" {
-		logger.Warn().Msg("Missing card number in request")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Card number is required"})
+	// Every missing required field is reported at once
+	if missing := h.config.missingFields(req); len(missing) > 0 {
+		logger.Warn().Strs("fields", missing).Msg("Missing required fields in request")
+		middleware.WriteFieldsError(w, r, http.StatusUnprocessableEntity, middleware.ErrCodeMissingField,
+			missingFieldsMessage(missing), missing)
+		return
+	}
+
+	// Create validation request - the digits-only card number is always what gets validated
+	validationReq, err := h.config.validationRequest(req)
+	if err != nil {
+		logger.Warn().Str("check_algorithm", req.CheckAlgorithm).Msg("Unknown check algorithm")
+		middleware.WriteError(w, r, h.config.Status.ValidationStatus(), middleware.ErrCodeInvalidCheckAlgorithm,
+			`check_algorithm must be "luhn" or "none"`)
 		return
 	}
 
 	// Mask sensitive data for logging
-	maskedCardNumber := maskCardNumber(req.CardNumber)
 	logger.Debug().
-		Str("card_prefix", maskedCardNumber[:6]+"...").
+		Str("card_prefix", luhn.Mask(req.CardNumber, 6, 0)).
 		Bool("has_expiry", req.ExpiryDate != "").
 		Bool("has_cvv", req.CVV != "").
 		Msg("Processing validation request")
 
-	// Create validation request
-	validationReq := luhn.CardValidationRequest{
-		CardNumber: req.CardNumber,
-		ExpiryDate: req.ExpiryDate,
-		CVV:        req.CVV,
-	}
-
 	// Get card information
-	cardInfo := luhn.ValidateCard(validationReq)
+	start := time.Now()
+	_, span := tracing.StartValidationSpan(r.Context())
+	cardInfo, err := luhn.ValidateCardContext(r.Context(), validationReq)
+	if err != nil {
+		span.End()
+		// The client is gone, so there is nobody to respond to
+		logger.Warn().Err(err).Msg("Validation cancelled")
+		return
+	}
+	elapsed := time.Since(start)
+	tracing.RecordValidation(span, cardInfo.Network, cardInfo.Outcome)
+	span.End()
 
-	// Prepare response message
-	message := buildResponseMessage(cardInfo)
+	// Notify the audit webhook, if configured
+	emitValidationEvent(r.Context(), req.CardNumber, cardInfo)
 
 	// Prepare response
-	resp := Response{
-		Valid:         cardInfo.Valid,
-		Network:       cardInfo.Network,
-		CardLength:    cardInfo.CardLength,
-		ExpiryValid:   cardInfo.ExpiryValid,
-		ExpiryFormatOK: cardInfo.ExpiryFormatOK,
-		CVVValid:      cardInfo.CVVValid,
-		Message:       message,
+	lang := negotiateLanguage(r)
+	resp := h.config.completeResponse(r, req, cardInfo, lang, elapsed)
+	w.Header().Set("Content-Language", lang)
+	if resp.CardNumberOriginal != "" {
+		// The echoed input is a full card number, so nothing may keep a copy of this response
+		w.Header().Set("Cache-Control", "no-store")
 	}
 
 	// Log result
@@ -101,54 +187,190 @@ func ValidationHandler(w http.ResponseWriter, r *http.Request) {
 		Bool("expiry_valid", cardInfo.ExpiryValid).
 		Bool("expiry_format_ok", cardInfo.ExpiryFormatOK).
 		Bool("cvv_valid", cardInfo.CVVValid).
+		Str("outcome", cardInfo.Outcome).
 		Msg("Card validation result")
 
+	// A card that is not valid is an error when 422 is enabled, so it gets the error envelope
+	if status := h.config.Status.OutcomeStatus(cardInfo.Outcome); status != http.StatusOK {
+		middleware.WriteError(w, r, status, middleware.OutcomeErrorCode(cardInfo.Outcome), resp.Message)
+		return
+	}
+
 	// Return response
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(resp)
+	encodeResponse(w, r, resp)
+}
+
+// validationRequest converts an API request into a validation request under the configured
+// policy. It returns an error for an unknown check algorithm, which callers report against
+// the right field.
+func (c Config) validationRequest(req Request) (luhn.CardValidationRequest, error) {
+	algorithm, err := luhn.ParseCheckAlgorithm(req.CheckAlgorithm)
+	if err != nil {
+		return luhn.CardValidationRequest{}, err
+	}
+	return luhn.CardValidationRequest{
+		CardNumber: req.CardNumber,
+		ExpiryDate: req.ExpiryDate,
+		CVV:        req.CVV,
+		Algorithm:  algorithm,
+		LuhnOnly:   req.LuhnOnly,
+		Config:     c.Validation,
+
+		NewExpiryDate: req.NewExpiryDate,
+	}, nil
+}
+
+// completeResponse builds the response for a single validated card with every per-request
+// addition /validate supports, so other single-card endpoints return the same result
+func (c Config) completeResponse(r *http.Request, req Request, cardInfo luhn.CardInfo, lang string, elapsed time.Duration) Response {
+	resp := buildResponse(cardInfo, lang)
+	resp.CardNumberOriginal = req.CardNumberOriginal
+	resp.RecentValidations = recordRecent(req.CardNumber)
+	resp.CardHash = c.cardHash(req.CardNumber)
+	if r.URL.Query().Get("candidates") == "true" {
+		resp.Candidates = luhn.NetworkCandidates(req.CardNumber)
+	}
+	if c.wantsDebug(r) {
+		resp.Debug = buildDebugInfo(req.CardNumber, elapsed)
+	}
+	return resp
+}
+
+// errTrailingData reports content after the JSON value
+var errTrailingData = errors.New("unexpected data after JSON object")
+
+// decodeError keeps the request body with a decoding error so the client message can show context
+type decodeError struct {
+	err  error
+	body []byte
+}
+
+func (e *decodeError) Error() string { return e.err.Error() }
+func (e *decodeError) Unwrap() error { return e.err }
+
+// decodeJSON decodes a single JSON value, rejecting any trailing content after it.
+// Callers bound the body with http.MaxBytesReader, so it is buffered whole.
+func decodeJSON(body io.Reader, v interface{}) error {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if err := decoder.Decode(v); err != nil {
+		return &decodeError{err: err, body: data}
+	}
+
+	// Anything other than EOF after the value means concatenated or garbage data
+	if _, err := decoder.Token(); err != io.EOF {
+		return errTrailingData
+	}
+	return nil
+}
+
+// invalidJSONMessage describes a decodeJSON error for the client, with its position when known
+func invalidJSONMessage(err error) string {
+	if errors.Is(err, errTrailingData) {
+		return "Invalid JSON: unexpected data after JSON object"
+	}
+	var decodeErr *decodeError
+	if errors.As(err, &decodeErr) {
+		return middleware.JSONErrorMessage(decodeErr.err, decodeErr.body)
+	}
+	return middleware.JSONErrorMessage(err, nil)
+}
+
+// buildResponse converts validation results into the API response
+func buildResponse(cardInfo luhn.CardInfo, lang string) Response {
+	return Response{
+		Valid:          cardInfo.Valid,
+		Network:        cardInfo.Network,
+		NetworkStatus:  cardInfo.NetworkStatus,
+		BrandSlug:      cardInfo.BrandSlug,
+		CardLength:     cardInfo.CardLength,
+		ExpiryValid:    cardInfo.ExpiryValid,
+		ExpiryFormatOK: cardInfo.ExpiryFormatOK,
+		ExpiryISO:      cardInfo.ExpiryISO,
+		NewExpiryValid:    cardInfo.NewExpiryValid,
+		NewExpiryFormatOK: cardInfo.NewExpiryFormatOK,
+		RenewalValid:      cardInfo.RenewalValid,
+		CVVValid:       cardInfo.CVVValid,
+		CVVMissing:     cardInfo.CVVMissing,
+		SecurityCodeLocation: cardInfo.SecurityCodeLocation,
+		Message:        buildResponseMessage(cardInfo, lang),
+		Code:           responseCode(cardInfo),
+		Outcome:        cardInfo.Outcome,
+		Score:          luhn.Score(cardInfo),
+		BIN:            cardInfo.BIN,
+		BINLength:      cardInfo.BINLength,
+		Processor:      cardInfo.Processor,
+
+		IssuerCountryAlpha2:  cardInfo.IssuerCountryAlpha2,
+		IssuerCountryAlpha3:  cardInfo.IssuerCountryAlpha3,
+		IssuerCountryNumeric: cardInfo.IssuerCountryNumeric,
+	}
+}
+
+// responseCode returns the language-independent code for the primary validation result
+func responseCode(cardInfo luhn.CardInfo) string {
+	switch {
+	case cardInfo.CardLength == 0:
+		return CodeNoDigits
+	case cardInfo.Outcome == luhn.OutcomePANLengthOutOfRange:
+		return CodePANLengthOutOfRange
+	case !cardInfo.LengthValid && cardInfo.PrefixNetwork != "":
+		return CodeLengthMismatch
+	case !cardInfo.Valid:
+		return CodeInvalidLuhn
+	}
+	return CodeValid
 }
 
 // buildResponseMessage creates a human-readable message based on validation results
-func buildResponseMessage(cardInfo luhn.CardInfo) string {
-	if !cardInfo.Valid {
-		return "Card number is invalid (failed Luhn check)"
+func buildResponseMessage(cardInfo luhn.CardInfo, lang string) string {
+	switch responseCode(cardInfo) {
+	case CodeNoDigits:
+		return localize(lang, msgNoDigits)
+	case CodePANLengthOutOfRange:
+		return localize(lang, msgPANLength, luhn.MinPANLength(), luhn.ISOMaxPANLength, cardInfo.CardLength)
+	case CodeLengthMismatch:
+		// Report length mismatches with the network's valid lengths so integrators can act on them
+		return localize(lang, msgLengthMismatch,
+			cardInfo.PrefixNetwork, formatLengths(luhn.NetworkLengths(cardInfo.PrefixNetwork), lang), cardInfo.CardLength)
+	case CodeInvalidLuhn:
+		return localize(lang, msgInvalidLuhn)
+	}
+
+	if cardInfo.NetworkStatus == luhn.NetworkSkipped {
+		return localize(lang, msgValidLuhnOnly)
 	}
 
-	networkInfo := "unknown network"
+	networkInfo := localize(lang, msgUnknownNetwork)
 	if cardInfo.Network != "Unknown" {
 		networkInfo = cardInfo.Network
 	}
 	
-	message := "Valid " + networkInfo + " card"
+	message := localize(lang, msgValidCard, networkInfo)
 
 	// Add expiry information if provided
 	if cardInfo.ExpiryFormatOK {
 		if cardInfo.ExpiryValid {
-			message += " with valid expiration date"
+			message += localize(lang, msgExpiryValid)
 		} else {
-			message += " with expired or invalid expiration date"
+			message += localize(lang, msgExpiryInvalid)
 		}
 	}
 
 	// Add CVV information if validated
 	if cardInfo.CVVValid {
-		message += " and valid security code (CVV)"
-	} else if cardInfo.CVVValid == false && cardInfo.Network != "" {
-		// Only mention invalid CVV if one was provided (otherwise CVVValid would be false by default)
-		if cardInfo.Network == "American Express" {
-			message += " but invalid security code (should be 4 digits)"
-		} else {
-			message += " but invalid security code (should be 3 digits)"
-		}
+		message += localize(lang, msgCVVValid)
+	} else if cardInfo.CVVProvided {
+		// Only mention invalid CVV if one was provided
+		message += localize(lang, msgCVVInvalid, luhn.SecurityCodeLength(cardInfo.Network))
+	} else if cardInfo.CVVMissing {
+		message += localize(lang, msgCVVMissing)
 	}
 
 	return message
 }
-
-// maskCardNumber hides all but first 6 and last 4 digits
-func maskCardNumber(cardNumber string) string {
-	if len(cardNumber) <= 10 {
-		return cardNumber
-	}
-	return cardNumber[:6] + strings.Repeat("*", len(cardNumber)-10) + cardNumber[len(cardNumber)-4:]
-}
\ No newline at end of file
@@ -0,0 +1,398 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jamesmeyerr/credit-card-validator/internal/luhn"
+	"github.com/jamesmeyerr/credit-card-validator/internal/middleware"
+)
+
+// validate posts a JSON body to a ValidationHandler with the default configuration
+func validate(t *testing.T, target, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	return validateWith(t, DefaultConfig(), target, body)
+}
+
+// validateWith posts a JSON body to a ValidationHandler with the given configuration
+func validateWith(t *testing.T, config Config, target, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	r := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	NewValidationHandler(config).ServeHTTP(w, r)
+	return w
+}
+
+// decodeResponse decodes a validation response, failing the test on error
+func decodeResponse(t *testing.T, w *httptest.ResponseRecorder) Response {
+	t.Helper()
+	var resp Response
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	return resp
+}
+
+func TestValidationHandlerValidCard(t *testing.T) {
+	w := validate(t, "/validate", `{"card_number":"4111111111111111"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+	resp := decodeResponse(t, w)
+	if !resp.Valid || resp.Network != "Visa" || resp.Outcome != luhn.OutcomeValid {
+		t.Errorf("response = %+v", resp)
+	}
+}
+
+func TestValidationHandlerOutcomeStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		outcome string
+	}{
+		{"valid", `{"card_number":"4111111111111111"}`, luhn.OutcomeValid},
+		{"failed checksum", `{"card_number":"4111111111111112"}`, luhn.OutcomeInvalidLuhn},
+		{"expired", `{"card_number":"4111111111111111","expiry_date":"01/20"}`, luhn.OutcomeExpired},
+	}
+
+	for _, enabled := range []bool{false, true} {
+		config := DefaultConfig()
+		config.Status.UnprocessableEntity = enabled
+		for _, tt := range tests {
+			w := validateWith(t, config, "/validate", tt.body)
+
+			want := http.StatusOK
+			if enabled && tt.outcome != luhn.OutcomeValid {
+				want = http.StatusUnprocessableEntity
+			}
+			if w.Code != want {
+				t.Errorf("%s with UnprocessableEntity=%v: status = %d, want %d", tt.name, enabled, w.Code, want)
+			}
+
+			// A 422 is an error envelope with the outcome as its code; a 200 is the full result
+			if want == http.StatusUnprocessableEntity {
+				if detail := decodeErrorDetail(t, w); detail.Code != middleware.OutcomeErrorCode(tt.outcome) || detail.Message == "" {
+					t.Errorf("%s: error = %+v, want code %s", tt.name, detail, middleware.OutcomeErrorCode(tt.outcome))
+				}
+				continue
+			}
+			if resp := decodeResponse(t, w); resp.Outcome != tt.outcome {
+				t.Errorf("%s: outcome = %q, want %q", tt.name, resp.Outcome, tt.outcome)
+			}
+		}
+	}
+}
+
+func TestValidationHandlerMalformedJSONIsAlways400(t *testing.T) {
+	for _, enabled := range []bool{false, true} {
+		config := DefaultConfig()
+		config.Status.UnprocessableEntity = enabled
+		w := validateWith(t, config, "/validate", `{"card_number":`)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("UnprocessableEntity=%v: status = %d, want 400", enabled, w.Code)
+		}
+	}
+}
+
+func TestValidationHandlerMissingCardNumber(t *testing.T) {
+	w := validate(t, "/validate", `{"cvv":"123"}`)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("status = %d, want 422", w.Code)
+	}
+	var resp middleware.ErrorResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Error.Code != middleware.ErrCodeMissingField {
+		t.Errorf("code = %q, want %q", resp.Error.Code, middleware.ErrCodeMissingField)
+	}
+}
+
+func TestExpiryWithoutCardNumber(t *testing.T) {
+	// The sanitizer passes a well-formed expiry through; the handler alone reports the missing card
+	sanitizer := middleware.NewInputSanitizer(middleware.DefaultSanitizationConfig())
+	handler := sanitizer.SanitizeMiddleware(NewValidationHandler(DefaultConfig()))
+
+	for _, body := range []string{`{"expiry_date":"09/27"}`, `{"card_number":"","expiry_date":"09/27"}`} {
+		r := httptest.NewRequest(http.MethodPost, "/validate", strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != http.StatusUnprocessableEntity {
+			t.Errorf("%s: status = %d, want 422", body, w.Code)
+		}
+		detail := decodeErrorDetail(t, w)
+		if detail.Code != middleware.ErrCodeMissingField || detail.Message != "card_number is required" ||
+			len(detail.Fields) != 1 || detail.Fields[0] != "card_number" {
+			t.Errorf("%s: error = %+v, want MISSING_FIELD for card_number", body, detail)
+		}
+	}
+
+	// A malformed expiry is still a format error from the sanitizer, reported before the missing card
+	r := httptest.NewRequest(http.MethodPost, "/validate", strings.NewReader(`{"expiry_date":"13/27"}`))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if detail := decodeErrorDetail(t, w); detail.Code != middleware.ErrCodeInvalidExpiry {
+		t.Errorf("malformed expiry without card: code = %q, want %q", detail.Code, middleware.ErrCodeInvalidExpiry)
+	}
+}
+
+func TestValidateNetworkStatus(t *testing.T) {
+	tests := map[string]string{
+		"4111":             luhn.NetworkIndeterminate,
+		"9000000000000008": luhn.NetworkUnknown,
+		"4111111111111111": luhn.NetworkIdentified,
+	}
+	for number, want := range tests {
+		resp := decodeResponse(t, validate(t, "/validate", `{"card_number":"`+number+`"}`))
+		if resp.NetworkStatus != want {
+			t.Errorf("%s: network_status = %q, want %q", number, resp.NetworkStatus, want)
+		}
+	}
+}
+
+func TestValidateRenewal(t *testing.T) {
+	later := time.Now().AddDate(3, 0, 0).Format("01/06")
+	body := `{"card_number":"4111111111111111","expiry_date":"` + futureExpiry() + `","new_expiry_date":"` + later + `"}`
+	resp := decodeResponse(t, validate(t, "/validate", body))
+	if !resp.NewExpiryFormatOK || !resp.NewExpiryValid || !resp.RenewalValid {
+		t.Errorf("renewal: format ok %v, new valid %v, renewal %v; want all true", resp.NewExpiryFormatOK, resp.NewExpiryValid, resp.RenewalValid)
+	}
+
+	// A downgrade keeps the new expiry valid but fails the renewal, and omits renewal_valid
+	body = `{"card_number":"4111111111111111","expiry_date":"` + later + `","new_expiry_date":"` + futureExpiry() + `"}`
+	w := validate(t, "/validate", body)
+	if strings.Contains(w.Body.String(), "renewal_valid") {
+		t.Errorf("downgrade response %s includes renewal_valid", w.Body.String())
+	}
+	resp = decodeResponse(t, w)
+	if !resp.NewExpiryValid || resp.RenewalValid {
+		t.Errorf("downgrade: new valid %v, renewal %v; want true, false", resp.NewExpiryValid, resp.RenewalValid)
+	}
+}
+
+func TestValidatePANLengthOutOfRange(t *testing.T) {
+	tests := map[string]string{
+		"4111111":              CodePANLengthOutOfRange,
+		"41111111":             CodePANLengthOutOfRange, // within ISO's 8 but shorter than any real card
+		"41111111111111111111": CodePANLengthOutOfRange,
+		"4111111111111111":     CodeValid,
+	}
+	for number, want := range tests {
+		resp := decodeResponse(t, validate(t, "/validate", `{"card_number":"`+number+`"}`))
+		if resp.Code != want {
+			t.Errorf("%d digits: code = %q, want %q", len(number), resp.Code, want)
+		}
+		// One gate, so the outcome always agrees with the code
+		if (resp.Outcome == luhn.OutcomePANLengthOutOfRange) != (want == CodePANLengthOutOfRange) {
+			t.Errorf("%d digits: outcome = %q with code %q", len(number), resp.Outcome, resp.Code)
+		}
+		if want == CodePANLengthOutOfRange && !strings.Contains(resp.Message, "12 to 19 digits; received "+strconv.Itoa(len(number))) {
+			t.Errorf("%d digits: message = %q", len(number), resp.Message)
+		}
+	}
+}
+
+func TestValidateSecurityCodeLocation(t *testing.T) {
+	tests := map[string]string{
+		"378282246310005":  "front",
+		"4111111111111111": "back",
+		"5500000000000004": "back",
+	}
+	for number, want := range tests {
+		resp := decodeResponse(t, validate(t, "/validate", `{"card_number":"`+number+`"}`))
+		if resp.SecurityCodeLocation != want {
+			t.Errorf("%s: security_code_location = %q, want %q", number, resp.SecurityCodeLocation, want)
+		}
+	}
+}
+
+func TestValidateCVVRequired(t *testing.T) {
+	config := DefaultConfig()
+	config.Validation.CVVRequiredNetworks = []string{"American Express"}
+
+	resp := decodeResponse(t, validateWith(t, config, "/validate", `{"card_number":"378282246310005"}`))
+	if !resp.CVVMissing || resp.Outcome != luhn.OutcomeMissingCVV || !strings.Contains(resp.Message, "CVV") {
+		t.Errorf("Amex without CVV: response = %+v, want cvv_missing", resp)
+	}
+
+	resp = decodeResponse(t, validateWith(t, config, "/validate", `{"card_number":"4111111111111111"}`))
+	if resp.CVVMissing || resp.Outcome != luhn.OutcomeValid {
+		t.Errorf("Visa without CVV: response = %+v, want valid", resp)
+	}
+
+	// The default configuration requires no CVV
+	resp = decodeResponse(t, validate(t, "/validate", `{"card_number":"378282246310005"}`))
+	if resp.CVVMissing || resp.Outcome != luhn.OutcomeValid {
+		t.Errorf("Amex without CVV and no policy: response = %+v, want valid", resp)
+	}
+}
+
+func TestValidateGracePeriod(t *testing.T) {
+	// Last month's expiry is expired by default, and valid with 40 days of grace
+	now := time.Now()
+	lastMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()).AddDate(0, -1, 0).Format("01/06")
+	body := `{"card_number":"4111111111111111","expiry_date":"` + lastMonth + `"}`
+
+	if resp := decodeResponse(t, validate(t, "/validate", body)); resp.Outcome != luhn.OutcomeExpired {
+		t.Errorf("no grace: outcome = %q, want expired", resp.Outcome)
+	}
+	config := DefaultConfig()
+	config.Validation.GracePeriodDays = 40
+	if resp := decodeResponse(t, validateWith(t, config, "/validate", body)); resp.Outcome != luhn.OutcomeValid {
+		t.Errorf("40 days grace: outcome = %q, want valid", resp.Outcome)
+	}
+}
+
+func TestValidationHandlerUnknownCheckAlgorithm(t *testing.T) {
+	w := validate(t, "/validate", `{"card_number":"4111111111111111","check_algorithm":"bogus"}`)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400", w.Code)
+	}
+}
+
+func TestValidationHandlerCheckAlgorithmNone(t *testing.T) {
+	// 4111111111111112 fails Luhn but is a length-valid Visa
+	resp := decodeResponse(t, validate(t, "/validate", `{"card_number":"4111111111111112","check_algorithm":"none"}`))
+	if !resp.Valid || resp.Network != "Visa" {
+		t.Errorf("check_algorithm none: valid %v, network %q; want a valid Visa", resp.Valid, resp.Network)
+	}
+
+	resp = decodeResponse(t, validate(t, "/validate", `{"card_number":"4111111111111112","check_algorithm":"luhn"}`))
+	if resp.Valid {
+		t.Errorf("check_algorithm luhn: valid, want a checksum failure")
+	}
+}
+
+func TestValidationHandlerRejectsTrailingData(t *testing.T) {
+	for _, body := range []string{
+		`{"card_number":"4111111111111111"}{"card_number":"5500000000000004"}`,
+		`{"card_number":"4111111111111111"} garbage`,
+	} {
+		w := validate(t, "/validate", body)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", body, w.Code)
+		}
+		var resp middleware.ErrorResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.Error.Code != middleware.ErrCodeInvalidJSON || !strings.Contains(resp.Error.Message, "unexpected data after JSON object") {
+			t.Errorf("%s: error = %+v", body, resp.Error)
+		}
+	}
+
+	// Trailing whitespace is not data
+	if w := validate(t, "/validate", "{\"card_number\":\"4111111111111111\"}\n  \n"); w.Code != http.StatusOK {
+		t.Errorf("trailing whitespace: status = %d, want 200", w.Code)
+	}
+}
+
+func TestValidationHandlerJSONErrorDetail(t *testing.T) {
+	tests := []struct {
+		body string
+		want string
+	}{
+		{`{"card_number":"4111111111111111" "cvv":"123"}`, "byte offset 35"},
+		{`{"card_number":4111111111111111}`, `field "card_number" must be a string, got number`},
+	}
+
+	for _, tt := range tests {
+		w := validate(t, "/validate", tt.body)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", tt.body, w.Code)
+		}
+		detail := decodeErrorDetail(t, w)
+		if detail.Code != middleware.ErrCodeInvalidJSON || !strings.Contains(detail.Message, tt.want) {
+			t.Errorf("%s: error = %+v, want %s mentioning %q", tt.body, detail, middleware.ErrCodeInvalidJSON, tt.want)
+		}
+		if strings.Contains(detail.Message, "4111") {
+			t.Errorf("%s: message %q echoes the card number", tt.body, detail.Message)
+		}
+	}
+}
+
+func TestValidateReportsProcessor(t *testing.T) {
+	table, err := luhn.LoadRoutingTable(strings.NewReader("411100,411199,acme\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	luhn.SetRoutingTable(table)
+	t.Cleanup(func() { luhn.SetRoutingTable(nil) })
+
+	resp := decodeResponse(t, validate(t, "/validate", `{"card_number":"4111111111111111"}`))
+	if resp.Processor != "acme" {
+		t.Errorf("processor = %q, want acme", resp.Processor)
+	}
+
+	// Cards outside every range carry no processor
+	resp = decodeResponse(t, validate(t, "/validate", `{"card_number":"5555555555554444"}`))
+	if resp.Processor != "" {
+		t.Errorf("unrouted card processor = %q, want none", resp.Processor)
+	}
+}
+
+func TestValidateCandidates(t *testing.T) {
+	body := `{"card_number":"6221260000000000"}`
+
+	if resp := decodeResponse(t, validate(t, "/validate", body)); resp.Candidates != nil {
+		t.Errorf("candidates without ?candidates=true: %+v", resp.Candidates)
+	}
+
+	resp := decodeResponse(t, validate(t, "/validate?candidates=true", body))
+	if len(resp.Candidates) != 2 || resp.Candidates[0].Network != "Discover" || resp.Candidates[1].Network != "UnionPay" {
+		t.Errorf("candidates = %+v, want Discover then UnionPay", resp.Candidates)
+	}
+}
+
+func TestValidateReportsScore(t *testing.T) {
+	tests := []struct {
+		body string
+		want int
+	}{
+		{`{"card_number":"4111111111111111","expiry_date":"` + futureExpiry() + `"}`, 100},
+		{`{"card_number":"4111111111111111","expiry_date":"01/20"}`, 70},
+		{`{"card_number":"4111111111111112"}`, 40},
+	}
+
+	for _, tt := range tests {
+		w := validate(t, "/validate", tt.body)
+		if resp := decodeResponse(t, w); resp.Score != tt.want {
+			t.Errorf("%s: score = %d, want %d", tt.body, resp.Score, tt.want)
+		}
+	}
+}
+
+func TestValidateInputWithoutDigits(t *testing.T) {
+	// Without the sanitizer in front, the handler still reports the specific code
+	for _, cardNumber := range []string{"----", "    ", "- -"} {
+		body, _ := json.Marshal(map[string]string{"card_number": cardNumber})
+		resp := decodeResponse(t, validate(t, "/validate", string(body)))
+		if resp.Valid || resp.Code != CodeNoDigits || resp.Message != "No digits found in card number" {
+			t.Errorf("%q: valid %v, code %q, message %q; want %s", cardNumber, resp.Valid, resp.Code, resp.Message, CodeNoDigits)
+		}
+	}
+}
+
+func TestValidateCancelledRequestReturnsEarly(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	r := httptest.NewRequest(http.MethodPost, "/validate", strings.NewReader(`{"card_number":"4111111111111111"}`)).WithContext(ctx)
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	NewValidationHandler(DefaultConfig()).ServeHTTP(w, r)
+
+	if w.Body.Len() != 0 {
+		t.Errorf("cancelled request got a response: %s", w.Body)
+	}
+}
@@ -0,0 +1,8 @@
+package api
+
+import "github.com/jamesmeyerr/credit-card-validator/internal/luhn"
+
+// cardHash returns the keyed hash of a card number, or "" when no key is configured
+func (c Config) cardHash(cardNumber string) string {
+	return luhn.CardHash(cardNumber, c.CardHashKey)
+}
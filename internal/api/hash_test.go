@@ -0,0 +1,50 @@
+package api
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/jamesmeyerr/credit-card-validator/internal/luhn"
+)
+
+// hashConfig returns the default configuration with a card hash secret
+func hashConfig(key string) Config {
+	config := DefaultConfig()
+	config.CardHashKey = []byte(key)
+	return config
+}
+
+func TestCardHashOmittedWithoutKey(t *testing.T) {
+	w := validate(t, "/validate", `{"card_number":"4111111111111111"}`)
+	if strings.Contains(w.Body.String(), "card_hash") {
+		t.Errorf("response without a key includes card_hash: %s", w.Body.String())
+	}
+}
+
+func TestCardHashStableAcrossRequests(t *testing.T) {
+	config := hashConfig("test-secret")
+	first := decodeResponse(t, validateWith(t, config, "/validate", `{"card_number":"4111111111111111"}`)).CardHash
+	second := decodeResponse(t, validateWith(t, config, "/validate", `{"card_number":"4111 1111 1111 1111"}`)).CardHash
+	if first == "" || first != second {
+		t.Errorf("hashes %q and %q, want the same non-empty hash", first, second)
+	}
+	if want := luhn.CardHash("4111111111111111", []byte("test-secret")); first != want {
+		t.Errorf("card_hash = %q, want %q", first, want)
+	}
+
+	// Batch results use the same hash
+	var resp BatchResponse
+	w := postBatchWith(t, config, "/validate/batch", batchBody(t, []string{"4111-1111-1111-1111", "5500000000000004"}))
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Results[0].CardHash != first || resp.Results[1].CardHash == first {
+		t.Errorf("batch hashes = %q, %q; want %q first", resp.Results[0].CardHash, resp.Results[1].CardHash, first)
+	}
+
+	// A new key changes every hash
+	if rotated := decodeResponse(t, validateWith(t, hashConfig("rotated-secret"), "/validate", `{"card_number":"4111111111111111"}`)).CardHash; rotated == "" || rotated == first {
+		t.Errorf("hash after key rotation = %q, want a new hash", rotated)
+	}
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jamesmeyerr/credit-card-validator/internal/luhn"
+)
+
+// postLuhnOnly sends body to /validate and decodes the response
+func postLuhnOnly(t *testing.T, body string) Response {
+	t.Helper()
+	r := httptest.NewRequest(http.MethodPost, "/validate", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	NewValidationHandler(DefaultConfig()).ServeHTTP(w, r)
+	var resp Response
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	return resp
+}
+
+func TestValidateLuhnOnly(t *testing.T) {
+	resp := postLuhnOnly(t, `{"card_number":"9900000000000002","luhn_only":true}`)
+	if resp.Network != luhn.NetworkNotApplicable || resp.NetworkStatus != luhn.NetworkSkipped || !resp.Valid {
+		t.Errorf("luhn_only: network %q, status %q, valid %v; want N/A, skipped, valid", resp.Network, resp.NetworkStatus, resp.Valid)
+	}
+	if !strings.Contains(resp.Message, "network detection was skipped") {
+		t.Errorf("luhn_only: message = %q", resp.Message)
+	}
+
+	// Without the flag the same number is an unrecognized network
+	resp = postLuhnOnly(t, `{"card_number":"9900000000000002"}`)
+	if resp.Network != "Unknown" {
+		t.Errorf("detected: network %q, want Unknown", resp.Network)
+	}
+
+	// Batch cards set the flag individually
+	var batch BatchResponse
+	r := httptest.NewRequest(http.MethodPost, "/validate/batch", strings.NewReader(`{"cards":[{"card_number":"4111111111111111","luhn_only":true},{"card_number":"4111111111111111"}]}`))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	NewBatchHandler(DefaultBatchConfig()).ServeHTTP(w, r)
+	if err := json.NewDecoder(w.Body).Decode(&batch); err != nil {
+		t.Fatal(err)
+	}
+	if batch.Results[0].Network != luhn.NetworkNotApplicable || batch.Results[1].Network != "Visa" {
+		t.Errorf("batch networks = %q, %q; want N/A, Visa", batch.Results[0].Network, batch.Results[1].Network)
+	}
+}
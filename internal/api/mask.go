@@ -0,0 +1,84 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/jamesmeyerr/credit-card-validator/internal/luhn"
+	"github.com/jamesmeyerr/credit-card-validator/internal/middleware"
+)
+
+// MaskRequest represents the JSON request for masking a card number
+type MaskRequest struct {
+	CardNumber string `json:"card_number"`
+}
+
+// MaskResponse represents the JSON response with the masked card number
+type MaskResponse struct {
+	Masked string `json:"masked"`
+}
+
+// MaskHandler returns the canonical masked form of a card number without validating it:
+// the digits only, with all but the first 6 and last 4 replaced by asterisks
+type MaskHandler struct {
+	config Config
+}
+
+// NewMaskHandler creates a new masking handler
+func NewMaskHandler(config Config) *MaskHandler {
+	return &MaskHandler{
+		config: config,
+	}
+}
+
+// ServeHTTP masks a single card number
+func (h *MaskHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Get logger with request context
+	logger := middleware.ApplicationLogger(r.Context())
+
+	if r.Method != http.MethodPost {
+		logger.Warn().Str("method", r.Method).Msg("Invalid HTTP method")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Parse the request
+	var req MaskRequest
+	r.Body = http.MaxBytesReader(w, r.Body, maxSingleCardRequestSize)
+	if err := decodeJSON(r.Body, &req); err != nil {
+		logger.Warn().Err(err).Msg("Failed to parse mask request")
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			middleware.WriteError(w, r, http.StatusRequestEntityTooLarge, middleware.ErrCodeRequestTooLarge,
+				fmt.Sprintf("request body exceeds max size %d bytes", maxSingleCardRequestSize))
+			return
+		}
+		middleware.WriteError(w, r, http.StatusBadRequest, middleware.ErrCodeInvalidJSON, invalidJSONMessage(err))
+		return
+	}
+
+	// /mask is served outside the sanitizer, so apply its card number limits here. The raw
+	// input may hold a separator after every digit, but no more.
+	if maxInput := 2 * luhn.ISOMaxPANLength; len(req.CardNumber) > maxInput {
+		middleware.WriteError(w, r, h.config.Status.ValidationStatus(), middleware.ErrCodeFieldTooLong,
+			fmt.Sprintf("card_number exceeds max length %d, received %d", maxInput, len(req.CardNumber)))
+		return
+	}
+	digits := luhn.Clean(req.CardNumber)
+	if digits == "" {
+		middleware.WriteError(w, r, h.config.Status.ValidationStatus(), middleware.ErrCodeNoDigits, "No digits found in card number")
+		return
+	}
+	if len(digits) > luhn.ISOMaxPANLength {
+		middleware.WriteError(w, r, h.config.Status.ValidationStatus(), middleware.ErrCodeFieldTooLong,
+			fmt.Sprintf("card_number exceeds max length %d, received %d", luhn.ISOMaxPANLength, len(digits)))
+		return
+	}
+
+	// Return response
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(MaskResponse{Masked: luhn.Mask(digits, 6, 4)})
+}
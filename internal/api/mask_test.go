@@ -0,0 +1,81 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jamesmeyerr/credit-card-validator/internal/luhn"
+	"github.com/jamesmeyerr/credit-card-validator/internal/middleware"
+)
+
+// mask posts a body to a MaskHandler with the default configuration
+func mask(t *testing.T, method, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	r := httptest.NewRequest(method, "/mask", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	NewMaskHandler(DefaultConfig()).ServeHTTP(w, r)
+	return w
+}
+
+func TestMaskHandler(t *testing.T) {
+	tests := map[string]string{
+		"4111111111111111":    "411111******1111",
+		"4111 1111-1111 1111": "411111******1111",
+		"378282246310005":     "378282*****0005",
+		"6011000990139424000": "601100*********4000", // the longest PAN ISO allows
+		"4111111111111112":    "411111******1112",    // masked without validating
+	}
+
+	for cardNumber, want := range tests {
+		body, _ := json.Marshal(MaskRequest{CardNumber: cardNumber})
+		w := mask(t, http.MethodPost, string(body))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%q: status = %d, want 200", cardNumber, w.Code)
+		}
+
+		// Only the masked number comes back: no validation fields and no digits beyond the mask
+		var fields map[string]interface{}
+		if err := json.NewDecoder(w.Body).Decode(&fields); err != nil {
+			t.Fatal(err)
+		}
+		if len(fields) != 1 || fields["masked"] != want {
+			t.Errorf("%q: response = %v, want only masked %q", cardNumber, fields, want)
+		}
+	}
+}
+
+func TestMaskHandlerErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		body   string
+		status int
+		code   string
+	}{
+		{"GET", http.MethodGet, "", http.StatusMethodNotAllowed, ""},
+		{"malformed JSON", http.MethodPost, `{"card_number":`, http.StatusBadRequest, middleware.ErrCodeInvalidJSON},
+		{"no digits", http.MethodPost, `{"card_number":"----"}`, http.StatusBadRequest, middleware.ErrCodeNoDigits},
+		{"too many digits", http.MethodPost, `{"card_number":"` + strings.Repeat("4", luhn.ISOMaxPANLength+1) + `"}`,
+			http.StatusBadRequest, middleware.ErrCodeFieldTooLong},
+		{"too long", http.MethodPost, `{"card_number":"4111` + strings.Repeat(" ", 2*luhn.ISOMaxPANLength) + `1111"}`,
+			http.StatusBadRequest, middleware.ErrCodeFieldTooLong},
+		{"too large", http.MethodPost, `{"card_number":"` + strings.Repeat("4", maxSingleCardRequestSize) + `"}`,
+			http.StatusRequestEntityTooLarge, middleware.ErrCodeRequestTooLarge},
+	}
+
+	for _, tt := range tests {
+		w := mask(t, tt.method, tt.body)
+		if w.Code != tt.status {
+			t.Errorf("%s: status = %d, want %d", tt.name, w.Code, tt.status)
+		}
+		if tt.code != "" {
+			if detail := decodeErrorDetail(t, w); detail.Code != tt.code {
+				t.Errorf("%s: code = %q, want %q", tt.name, detail.Code, tt.code)
+			}
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// Message codes identify the primary result of a validation, independent of language
+const (
+	CodeNoDigits            = "NO_DIGITS"
+	CodePANLengthOutOfRange = "PAN_LENGTH_OUT_OF_RANGE"
+	CodeLengthMismatch      = "LENGTH_MISMATCH"
+	CodeInvalidLuhn         = "INVALID_LUHN"
+	CodeValid               = "VALID"
+)
+
+// Message catalog keys for the fragments that make up a response message
+const (
+	msgNoDigits       = "no_digits"
+	msgPANLength      = "pan_length"
+	msgLengthMismatch = "length_mismatch"
+	msgInvalidLuhn    = "invalid_luhn"
+	msgValidCard      = "valid_card"
+	msgUnknownNetwork = "unknown_network"
+	msgValidLuhnOnly  = "valid_luhn_only"
+	msgExpiryValid    = "expiry_valid"
+	msgExpiryInvalid  = "expiry_invalid"
+	msgCVVValid       = "cvv_valid"
+	msgCVVInvalid     = "cvv_invalid"
+	msgCVVMissing     = "cvv_missing"
+	msgLengthsOr      = "lengths_or"
+	msgLengthsRange   = "lengths_range"
+	msgLengthsAny     = "lengths_any"
+)
+
+// defaultLanguage is used when the client asks for nothing we support
+const defaultLanguage = "en"
+
+// messageCatalog holds the translated message fragments, keyed by language then message key
+var messageCatalog = map[string]map[string]string{
+	"en": {
+		msgNoDigits:       "No digits found in card number",
+		msgPANLength:      "Card number length must be %d to %d digits; received %d",
+		msgLengthMismatch: "%s cards must be %s digits; received %d",
+		msgInvalidLuhn:    "Card number is invalid (failed Luhn check)",
+		msgValidCard:      "Valid %s card",
+		msgUnknownNetwork: "unknown network",
+		msgValidLuhnOnly:  "Card number passes the Luhn check; network detection was skipped",
+		msgExpiryValid:    " with valid expiration date",
+		msgExpiryInvalid:  " with expired or invalid expiration date",
+		msgCVVValid:       " and valid security code (CVV)",
+		msgCVVInvalid:     " but invalid security code (should be %d digits)",
+		msgCVVMissing:     " but a security code (CVV) is required",
+		msgLengthsOr:      "or",
+		msgLengthsRange:   "%d to %d",
+		msgLengthsAny:     "a valid number of",
+	},
+	"es": {
+		msgNoDigits:       "No se encontraron dígitos en el número de tarjeta",
+		msgPANLength:      "La longitud del número de tarjeta debe ser de %d a %d dígitos; se recibieron %d",
+		msgLengthMismatch: "Las tarjetas %s deben tener %s dígitos; se recibieron %d",
+		msgInvalidLuhn:    "El número de tarjeta no es válido (falló la verificación de Luhn)",
+		msgValidCard:      "Tarjeta %s válida",
+		msgUnknownNetwork: "de red desconocida",
+		msgValidLuhnOnly:  "El número de tarjeta supera la verificación de Luhn; no se detectó la red",
+		msgExpiryValid:    " con fecha de vencimiento válida",
+		msgExpiryInvalid:  " con fecha de vencimiento caducada o no válida",
+		msgCVVValid:       " y código de seguridad (CVV) válido",
+		msgCVVInvalid:     " pero código de seguridad no válido (debe tener %d dígitos)",
+		msgCVVMissing:     " pero se requiere el código de seguridad (CVV)",
+		msgLengthsOr:      "o",
+		msgLengthsRange:   "de %d a %d",
+		msgLengthsAny:     "un número válido de",
+	},
+	"fr": {
+		msgNoDigits:       "Aucun chiffre trouvé dans le numéro de carte",
+		msgPANLength:      "La longueur du numéro de carte doit être de %d à %d chiffres ; %d reçus",
+		msgLengthMismatch: "Les cartes %s doivent comporter %s chiffres ; %d reçus",
+		msgInvalidLuhn:    "Le numéro de carte est invalide (échec de la vérification de Luhn)",
+		msgValidCard:      "Carte %s valide",
+		msgUnknownNetwork: "de réseau inconnu",
+		msgValidLuhnOnly:  "Le numéro de carte passe la vérification de Luhn ; la détection du réseau a été ignorée",
+		msgExpiryValid:    " avec une date d'expiration valide",
+		msgExpiryInvalid:  " avec une date d'expiration dépassée ou invalide",
+		msgCVVValid:       " et un code de sécurité (CVV) valide",
+		msgCVVInvalid:     " mais un code de sécurité invalide (doit comporter %d chiffres)",
+		msgCVVMissing:     " mais le code de sécurité (CVV) est obligatoire",
+		msgLengthsOr:      "ou",
+		msgLengthsRange:   "%d à %d",
+		msgLengthsAny:     "un nombre valide de",
+	},
+}
+
+// supportedLanguages lists catalog languages for Accept-Language matching; the first is the fallback
+var supportedLanguages = []language.Tag{language.English, language.Spanish, language.French}
+
+// languageMatcher picks the best supported language for an Accept-Language header
+var languageMatcher = language.NewMatcher(supportedLanguages)
+
+// negotiateLanguage selects the response language from ?lang= or Accept-Language, falling back to English
+func negotiateLanguage(r *http.Request) string {
+	if lang := strings.ToLower(r.URL.Query().Get("lang")); lang != "" {
+		if _, ok := messageCatalog[lang]; ok {
+			return lang
+		}
+		return defaultLanguage
+	}
+
+	header := r.Header.Get("Accept-Language")
+	if header == "" {
+		return defaultLanguage
+	}
+
+	tags, _, err := language.ParseAcceptLanguage(header)
+	if err != nil || len(tags) == 0 {
+		return defaultLanguage
+	}
+	_, index, confidence := languageMatcher.Match(tags...)
+	if confidence == language.No {
+		return defaultLanguage
+	}
+	base, _ := supportedLanguages[index].Base()
+	return base.String()
+}
+
+// localize formats a catalog message in the given language, falling back to English
+func localize(lang, key string, args ...interface{}) string {
+	catalog, ok := messageCatalog[lang]
+	if !ok {
+		catalog = messageCatalog[defaultLanguage]
+	}
+	format, ok := catalog[key]
+	if !ok {
+		format = messageCatalog[defaultLanguage][key]
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// formatLengths renders a list of lengths for messages, e.g. "13, 16, or 19" or "16 to 19"
+func formatLengths(lengths []int, lang string) string {
+	switch {
+	case len(lengths) == 0:
+		return localize(lang, msgLengthsAny)
+	case len(lengths) == 1:
+		return strconv.Itoa(lengths[0])
+	case len(lengths) > 2 && lengths[len(lengths)-1]-lengths[0] == len(lengths)-1:
+		return localize(lang, msgLengthsRange, lengths[0], lengths[len(lengths)-1])
+	case len(lengths) == 2:
+		return fmt.Sprintf("%d %s %d", lengths[0], localize(lang, msgLengthsOr), lengths[1])
+	}
+
+	parts := make([]string, len(lengths))
+	for i, length := range lengths {
+		parts[i] = strconv.Itoa(length)
+	}
+
+	// Only English uses the serial comma
+	separator := " "
+	if lang == defaultLanguage {
+		separator = ", "
+	}
+	return strings.Join(parts[:len(parts)-1], ", ") + separator + localize(lang, msgLengthsOr) + " " + parts[len(parts)-1]
+}
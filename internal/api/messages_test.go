@@ -0,0 +1,117 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jamesmeyerr/credit-card-validator/internal/luhn"
+)
+
+func TestLengthMismatchMessages(t *testing.T) {
+	tests := []struct {
+		number string
+		want   string
+	}{
+		{"3400000000000009", "American Express cards must be 15 digits; received 16"},
+		{"41111111111111", "Visa cards must be 13, 16, or 19 digits; received 14"},
+		{"601100000000000", "Discover cards must be 16 to 19 digits; received 15"},
+		{"5500000000000000004", "Mastercard cards must be 16 digits; received 19"},
+	}
+
+	for _, tt := range tests {
+		info := luhn.ValidateCard(luhn.CardValidationRequest{CardNumber: tt.number})
+		if code := responseCode(info); code != CodeLengthMismatch {
+			t.Errorf("%s: code = %s, want %s", tt.number, code, CodeLengthMismatch)
+		}
+		if got := buildResponseMessage(info, "en"); got != tt.want {
+			t.Errorf("%s: message = %q, want %q", tt.number, got, tt.want)
+		}
+	}
+}
+
+func TestFormatLengths(t *testing.T) {
+	tests := []struct {
+		lengths []int
+		want    string
+	}{
+		{nil, "a valid number of"},
+		{[]int{15}, "15"},
+		{[]int{13, 16, 19}, "13, 16, or 19"},
+		{[]int{16, 17, 18, 19}, "16 to 19"},
+	}
+	for _, tt := range tests {
+		if got := formatLengths(tt.lengths, "en"); got != tt.want {
+			t.Errorf("formatLengths(%v) = %q, want %q", tt.lengths, got, tt.want)
+		}
+	}
+}
+
+func TestNegotiateLanguage(t *testing.T) {
+	tests := []struct {
+		target         string
+		acceptLanguage string
+		want           string
+	}{
+		{"/validate", "", "en"},
+		{"/validate", "es", "es"},
+		{"/validate", "es-MX,es;q=0.9", "es"},
+		{"/validate", "fr-CA", "fr"},
+		{"/validate", "de-DE, fr;q=0.5", "fr"},
+		{"/validate", "de", "en"},
+		{"/validate", "not a language", "en"},
+		{"/validate?lang=fr", "es", "fr"},
+		{"/validate?lang=ES", "", "es"},
+		{"/validate?lang=de", "fr", "en"},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodPost, tt.target, nil)
+		if tt.acceptLanguage != "" {
+			r.Header.Set("Accept-Language", tt.acceptLanguage)
+		}
+		if got := negotiateLanguage(r); got != tt.want {
+			t.Errorf("%s with Accept-Language %q = %q, want %q", tt.target, tt.acceptLanguage, got, tt.want)
+		}
+	}
+}
+
+func TestLocalizedResponseMessages(t *testing.T) {
+	tests := []struct {
+		target string
+		lang   string
+		body   string
+		want   string
+	}{
+		{"/validate", "es", `{"card_number":"4111111111111111"}`, "Tarjeta Visa válida"},
+		{"/validate", "fr", `{"card_number":"4111111111111111"}`, "Carte Visa valide"},
+		{"/validate?lang=es", "", `{"card_number":"4111111111111112"}`, "El número de tarjeta no es válido (falló la verificación de Luhn)"},
+		{"/validate", "fr", `{"card_number":"4111111111111111","cvv":"1234"}`, "Carte Visa valide mais un code de sécurité invalide (doit comporter 3 chiffres)"},
+		{"/validate", "de", `{"card_number":"4111111111111111"}`, "Valid Visa card"},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodPost, tt.target, strings.NewReader(tt.body))
+		r.Header.Set("Content-Type", "application/json")
+		if tt.lang != "" {
+			r.Header.Set("Accept-Language", tt.lang)
+		}
+		w := httptest.NewRecorder()
+		NewValidationHandler(DefaultConfig()).ServeHTTP(w, r)
+
+		if resp := decodeResponse(t, w); resp.Message != tt.want {
+			t.Errorf("%s (%s) %s: message = %q, want %q", tt.target, tt.lang, tt.body, resp.Message, tt.want)
+		}
+	}
+}
+
+func TestMessageCatalogComplete(t *testing.T) {
+	for lang, catalog := range messageCatalog {
+		for key := range messageCatalog[defaultLanguage] {
+			if catalog[key] == "" {
+				t.Errorf("%s catalog is missing %q", lang, key)
+			}
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// jsonFields returns the JSON names of a struct's fields
+func jsonFields(v interface{}) []string {
+	var names []string
+	typ := reflect.TypeOf(v)
+	for i := 0; i < typ.NumField(); i++ {
+		name := strings.Split(typ.Field(i).Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func TestOpenAPIHandler(t *testing.T) {
+	w := httptest.NewRecorder()
+	OpenAPIHandler(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+
+	var spec struct {
+		OpenAPI    string                     `json:"openapi"`
+		Paths      map[string]json.RawMessage `json:"paths"`
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]json.RawMessage `json:"properties"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &spec); err != nil {
+		t.Fatalf("spec is not valid JSON: %v", err)
+	}
+	if !strings.HasPrefix(spec.OpenAPI, "3.") {
+		t.Errorf("openapi = %q, want 3.x", spec.OpenAPI)
+	}
+	if _, ok := spec.Paths["/validate"]; !ok {
+		t.Error("spec does not describe /validate")
+	}
+
+	// The schemas list every field the structs serialize, except card_number_original on
+	// requests, which only the sanitizer sets
+	for schema, v := range map[string]interface{}{"Request": Request{}, "Response": Response{}} {
+		properties := spec.Components.Schemas[schema].Properties
+		for _, field := range jsonFields(v) {
+			if schema == "Request" && field == "card_number_original" {
+				continue
+			}
+			if _, ok := properties[field]; !ok {
+				t.Errorf("schema %s is missing field %q", schema, field)
+			}
+		}
+	}
+}
+
+func TestOpenAPIHandlerRejectsPost(t *testing.T) {
+	w := httptest.NewRecorder()
+	OpenAPIHandler(w, httptest.NewRequest(http.MethodPost, "/openapi.json", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("status = %d, want 405", w.Code)
+	}
+}
@@ -0,0 +1,22 @@
+package api
+
+import (
+	"github.com/jamesmeyerr/credit-card-validator/internal/luhn"
+	"github.com/jamesmeyerr/credit-card-validator/internal/recent"
+)
+
+// RecentTracker counts repeat validations of the same card when configured
+var RecentTracker *recent.Tracker
+
+// recordRecent returns how often the card number was validated within the tracker's
+// window, or 0 when tracking is disabled. Formatting is ignored.
+func recordRecent(cardNumber string) int {
+	if RecentTracker == nil {
+		return 0
+	}
+	digits := luhn.Clean(cardNumber)
+	if digits == "" {
+		return 0
+	}
+	return RecentTracker.Record(digits)
+}
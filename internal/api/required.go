@@ -0,0 +1,68 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// requestFields maps each request field that can be required to its value
+var requestFields = map[string]func(Request) string{
+	"card_number":     func(req Request) string { return req.CardNumber },
+	"expiry_date":     func(req Request) string { return req.ExpiryDate },
+	"cvv":             func(req Request) string { return req.CVV },
+	"new_expiry_date": func(req Request) string { return req.NewExpiryDate },
+}
+
+// ParseRequiredFields normalizes a list of field names into a Config.RequiredFields value, for
+// merchants that need more than the card number. card_number is always required and first.
+func ParseRequiredFields(fields []string) ([]string, error) {
+	required := []string{"card_number"}
+	for _, field := range fields {
+		field = strings.ToLower(strings.TrimSpace(field))
+		if field == "" || field == "card_number" {
+			continue
+		}
+		if _, ok := requestFields[field]; !ok {
+			return nil, fmt.Errorf("unknown request field %q", field)
+		}
+		required = append(required, field)
+	}
+	return required, nil
+}
+
+// LoadRequiredFieldsFile reads the required field set from a JSON array of field names
+func LoadRequiredFieldsFile(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields []string
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("required fields file: %w", err)
+	}
+	return ParseRequiredFields(fields)
+}
+
+// missingFields returns every required field absent from the request. Required fields are
+// checked here, and only here, so every missing-field error looks the same; the sanitizer
+// only checks the format of fields that are present.
+func (c Config) missingFields(req Request) []string {
+	var missing []string
+	for _, field := range c.RequiredFields {
+		if requestFields[field](req) == "" {
+			missing = append(missing, field)
+		}
+	}
+	return missing
+}
+
+// missingFieldsMessage describes the missing fields for the error envelope
+func missingFieldsMessage(missing []string) string {
+	if len(missing) == 1 {
+		return missing[0] + " is required"
+	}
+	return "missing required fields: " + strings.Join(missing, ", ")
+}
@@ -0,0 +1,169 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jamesmeyerr/credit-card-validator/internal/api/validatorpb"
+	"github.com/jamesmeyerr/credit-card-validator/internal/middleware"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// requiredConfig returns the default configuration with extra required fields
+func requiredConfig(t testing.TB, fields ...string) Config {
+	t.Helper()
+	required, err := ParseRequiredFields(fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := DefaultConfig()
+	config.RequiredFields = required
+	return config
+}
+
+func TestParseRequiredFields(t *testing.T) {
+	if got := DefaultConfig().RequiredFields; !reflect.DeepEqual(got, []string{"card_number"}) {
+		t.Errorf("default required fields = %v", got)
+	}
+
+	// Names are normalized and card_number is always first
+	got, err := ParseRequiredFields([]string{" CVV ", "", "card_number", "expiry_date"})
+	if want := []string{"card_number", "cvv", "expiry_date"}; err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("required fields = %v, %v; want %v", got, err, want)
+	}
+
+	if _, err := ParseRequiredFields([]string{"cvv", "zip_code"}); err == nil || !strings.Contains(err.Error(), "zip_code") {
+		t.Errorf("unknown field: err = %v", err)
+	}
+}
+
+func TestRequiredFieldCombinations(t *testing.T) {
+	tests := []struct {
+		name     string
+		required []string
+		body     string
+		missing  []string
+	}{
+		{"card number only", nil, `{"expiry_date":"` + futureExpiry() + `"}`, []string{"card_number"}},
+		{"card number only, present", nil, `{"card_number":"4111111111111111"}`, nil},
+		{"cvv and expiry, both missing", []string{"cvv", "expiry_date"}, `{"card_number":"4111111111111111"}`, []string{"cvv", "expiry_date"}},
+		{"cvv and expiry, cvv missing", []string{"cvv", "expiry_date"}, `{"card_number":"4111111111111111","expiry_date":"` + futureExpiry() + `"}`, []string{"cvv"}},
+		{"cvv and expiry, present", []string{"cvv", "expiry_date"}, `{"card_number":"4111111111111111","expiry_date":"` + futureExpiry() + `","cvv":"123"}`, nil},
+		{"everything missing", []string{"expiry_date", "cvv", "new_expiry_date"}, `{}`, []string{"card_number", "expiry_date", "cvv", "new_expiry_date"}},
+		{"empty string is missing", []string{"cvv"}, `{"card_number":"4111111111111111","cvv":""}`, []string{"cvv"}},
+	}
+
+	for _, tt := range tests {
+		w := validateWith(t, requiredConfig(t, tt.required...), "/validate", tt.body)
+		if tt.missing == nil {
+			if w.Code != http.StatusOK {
+				t.Errorf("%s: status = %d, want 200: %s", tt.name, w.Code, w.Body.String())
+			}
+			continue
+		}
+
+		if w.Code != http.StatusUnprocessableEntity {
+			t.Errorf("%s: status = %d, want 422", tt.name, w.Code)
+			continue
+		}
+		detail := decodeErrorDetail(t, w)
+		if detail.Code != middleware.ErrCodeMissingField || !reflect.DeepEqual(detail.Fields, tt.missing) {
+			t.Errorf("%s: code %q, fields %v; want %q, %v", tt.name, detail.Code, detail.Fields, middleware.ErrCodeMissingField, tt.missing)
+		}
+		if detail.Message != missingFieldsMessage(tt.missing) {
+			t.Errorf("%s: message = %q", tt.name, detail.Message)
+		}
+	}
+}
+
+func TestMissingFieldsMessage(t *testing.T) {
+	if got := missingFieldsMessage([]string{"cvv"}); got != "cvv is required" {
+		t.Errorf("one field: %q", got)
+	}
+	if got := missingFieldsMessage([]string{"cvv", "expiry_date"}); got != "missing required fields: cvv, expiry_date" {
+		t.Errorf("two fields: %q", got)
+	}
+}
+
+func TestLoadRequiredFieldsFile(t *testing.T) {
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "required.json")
+	if err := os.WriteFile(path, []byte(`["expiry_date", "cvv"]`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadRequiredFieldsFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"card_number", "expiry_date", "cvv"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("required fields = %v, want %v", got, want)
+	}
+
+	// Bad files are errors
+	bad := filepath.Join(dir, "bad.json")
+	if err := os.WriteFile(bad, []byte(`{"cvv": true}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []string{bad, filepath.Join(dir, "missing.json")} {
+		if _, err := LoadRequiredFieldsFile(p); err == nil {
+			t.Errorf("%s: no error", filepath.Base(p))
+		}
+	}
+}
+
+func TestBatchRequiredFields(t *testing.T) {
+	body := `{"cards":[{"card_number":"4111111111111111","cvv":"123"},{"card_number":"5500000000000004"},{}]}`
+	w := postBatchWith(t, requiredConfig(t, "cvv"), "/validate/batch", body)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("status = %d, want 422", w.Code)
+	}
+	want := []string{"cards[1].cvv", "cards[2].card_number", "cards[2].cvv"}
+	if detail := decodeErrorDetail(t, w); !reflect.DeepEqual(detail.Fields, want) {
+		t.Errorf("fields = %v, want %v", detail.Fields, want)
+	}
+}
+
+func TestGRPCRequiredFields(t *testing.T) {
+	config := DefaultGRPCConfig()
+	config.Config = requiredConfig(t, "expiry_date")
+	client := newGRPCClientWithConfig(t, config)
+
+	_, err := client.Validate(context.Background(), &validatorpb.ValidateRequest{CardNumber: "4111111111111111"})
+	if status.Code(err) != codes.InvalidArgument || !strings.Contains(err.Error(), "expiry_date is required") {
+		t.Errorf("Validate without expiry: err = %v", err)
+	}
+
+	_, err = client.ValidateBatch(context.Background(), &validatorpb.ValidateBatchRequest{
+		Requests: []*validatorpb.ValidateRequest{{CardNumber: "4111111111111111", ExpiryDate: futureExpiry()}, {ExpiryDate: futureExpiry()}},
+	})
+	if status.Code(err) != codes.InvalidArgument || !strings.Contains(err.Error(), "requests[1].card_number") {
+		t.Errorf("ValidateBatch with a missing card number: err = %v", err)
+	}
+
+	// new_expiry_date is carried over gRPC, so it can be required there too
+	config.Config = requiredConfig(t, "new_expiry_date")
+	client = newGRPCClientWithConfig(t, config)
+	_, err = client.Validate(context.Background(), &validatorpb.ValidateRequest{CardNumber: "4111111111111111", ExpiryDate: futureExpiry()})
+	if status.Code(err) != codes.InvalidArgument || !strings.Contains(err.Error(), "new_expiry_date is required") {
+		t.Errorf("Validate without new expiry: err = %v", err)
+	}
+	if _, err := client.Validate(context.Background(), &validatorpb.ValidateRequest{CardNumber: "4111111111111111", NewExpiryDate: futureExpiry()}); err != nil {
+		t.Errorf("Validate with new expiry: %v", err)
+	}
+}
+
+func BenchmarkMissingFields(b *testing.B) {
+	config := requiredConfig(b, "expiry_date", "cvv")
+	req := Request{CardNumber: "4111111111111111", ExpiryDate: "09/27", CVV: "123"}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		config.missingFields(req)
+	}
+}
@@ -0,0 +1,200 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/jamesmeyerr/credit-card-validator/internal/luhn"
+	"github.com/jamesmeyerr/credit-card-validator/internal/middleware"
+)
+
+// sseKeepAlive is how often an idle stream sends a comment so proxies keep it open
+const sseKeepAlive = 15 * time.Second
+
+// sseBuffer is the number of results queued for a stream before submissions are refused
+const sseBuffer = 16
+
+// SSEHandler streams validation results to the web UI as Server-Sent Events.
+//
+// A client opens the stream with GET and receives a "session" event holding its session ID.
+// It then POSTs cards to the same path with ?session=<id>, and each result arrives on the
+// stream as a "result" event.
+type SSEHandler struct {
+	config   Config
+	mu       sync.Mutex
+	sessions map[string]chan interface{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewSSEHandler creates a new SSE validation handler
+func NewSSEHandler(config Config) *SSEHandler {
+	return &SSEHandler{
+		config:   config,
+		sessions: make(map[string]chan interface{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// ServeHTTP opens a stream on GET and accepts a card for an open stream on POST
+func (h *SSEHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.stream(w, r)
+	case http.MethodPost:
+		h.submit(w, r)
+	default:
+		logger := middleware.ApplicationLogger(r.Context())
+		logger.Warn().Str("method", r.Method).Msg("Invalid HTTP method")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// Shutdown ends every open stream so the server can stop without waiting for them
+func (h *SSEHandler) Shutdown() {
+	h.stopOnce.Do(func() { close(h.done) })
+}
+
+// stream holds the connection open, writing one event per result until the client
+// disconnects or the server shuts down
+func (h *SSEHandler) stream(w http.ResponseWriter, r *http.Request) {
+	logger := middleware.ApplicationLogger(r.Context())
+	controller := http.NewResponseController(w)
+
+	// Streams outlive the server's write timeout
+	if err := controller.SetWriteDeadline(time.Time{}); err != nil {
+		logger.Warn().Err(err).Msg("Could not clear write deadline for event stream")
+	}
+
+	id, results, err := h.open()
+	if err != nil {
+		middleware.WriteError(w, r, http.StatusInternalServerError, middleware.ErrCodeInternal, "Could not open event stream")
+		return
+	}
+	defer h.close(id)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	writeEvent(w, "session", map[string]string{"session_id": id})
+	if err := controller.Flush(); err != nil {
+		logger.Warn().Err(err).Msg("Event stream does not support flushing")
+		return
+	}
+
+	logger.Info().Msg("Event stream opened")
+
+	keepAlive := time.NewTicker(sseKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			logger.Info().Msg("Event stream closed by client")
+			return
+		case <-h.done:
+			logger.Info().Msg("Event stream closed for shutdown")
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		case resp := <-results:
+			writeEvent(w, "result", resp)
+		}
+		if err := controller.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// submit validates a card and queues the result on the session's stream
+func (h *SSEHandler) submit(w http.ResponseWriter, r *http.Request) {
+	logger := middleware.ApplicationLogger(r.Context())
+
+	h.mu.Lock()
+	results, ok := h.sessions[r.URL.Query().Get("session")]
+	h.mu.Unlock()
+	if !ok {
+		middleware.WriteError(w, r, http.StatusNotFound, middleware.ErrCodeNotFound, "No open event stream for this session")
+		return
+	}
+
+	var req Request
+	r.Body = http.MaxBytesReader(w, r.Body, maxSingleCardRequestSize)
+	if err := decodeJSON(r.Body, &req); err != nil {
+		logger.Warn().Err(err).Msg("Failed to parse event stream submission")
+		middleware.WriteError(w, r, http.StatusBadRequest, middleware.ErrCodeInvalidJSON, invalidJSONMessage(err))
+		return
+	}
+	if missing := h.config.missingFields(req); len(missing) > 0 {
+		middleware.WriteFieldsError(w, r, http.StatusUnprocessableEntity, middleware.ErrCodeMissingField,
+			missingFieldsMessage(missing), missing)
+		return
+	}
+
+	// Requests and results are built as /validate builds them, so both return the same result
+	validationReq, err := h.config.validationRequest(req)
+	if err != nil {
+		middleware.WriteError(w, r, h.config.Status.ValidationStatus(), middleware.ErrCodeInvalidCheckAlgorithm,
+			`check_algorithm must be "luhn" or "none"`)
+		return
+	}
+
+	start := time.Now()
+	cardInfo, err := luhn.ValidateCardContext(r.Context(), validationReq)
+	if err != nil {
+		logger.Warn().Err(err).Msg("Validation cancelled")
+		return
+	}
+	elapsed := time.Since(start)
+	emitValidationEvent(r.Context(), req.CardNumber, cardInfo)
+
+	// Display options come from the submission, since that is where the client sets them per card
+	resp := h.config.completeResponse(r, req, cardInfo, negotiateLanguage(r), elapsed)
+
+	select {
+	case results <- presentedResponse(r, resp):
+		w.WriteHeader(http.StatusAccepted)
+	default:
+		middleware.WriteError(w, r, http.StatusServiceUnavailable, middleware.ErrCodeStreamBusy,
+			"Event stream is not keeping up; retry shortly")
+	}
+}
+
+// open registers a new session and returns its ID and result queue
+func (h *SSEHandler) open() (string, chan interface{}, error) {
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return "", nil, err
+	}
+	id := hex.EncodeToString(raw)
+	results := make(chan interface{}, sseBuffer)
+
+	h.mu.Lock()
+	h.sessions[id] = results
+	h.mu.Unlock()
+
+	return id, results, nil
+}
+
+// close forgets a session once its stream ends
+func (h *SSEHandler) close(id string) {
+	h.mu.Lock()
+	delete(h.sessions, id)
+	h.mu.Unlock()
+}
+
+// writeEvent writes a single named SSE event with a JSON payload
+func writeEvent(w http.ResponseWriter, event string, payload interface{}) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+}
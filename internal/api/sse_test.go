@@ -0,0 +1,138 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sseEvent is one parsed Server-Sent Event
+type sseEvent struct {
+	name string
+	data string
+}
+
+// readEvent reads the next event from a stream, skipping keep-alive comments
+func readEvent(t *testing.T, reader *bufio.Reader) sseEvent {
+	t.Helper()
+	var event sseEvent
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read event: %v", err)
+		}
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case line == "" && event.name != "":
+			return event
+		case strings.HasPrefix(line, "event: "):
+			event.name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			event.data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+// openStream opens an event stream and returns its reader and session ID
+func openStream(t *testing.T, url string) (*http.Response, *bufio.Reader, string) {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q, want text/event-stream", ct)
+	}
+	if cc := resp.Header.Get("Cache-Control"); cc != "no-cache" {
+		t.Errorf("Cache-Control = %q, want no-cache", cc)
+	}
+
+	reader := bufio.NewReader(resp.Body)
+	event := readEvent(t, reader)
+	var session map[string]string
+	if event.name != "session" || json.Unmarshal([]byte(event.data), &session) != nil || session["session_id"] == "" {
+		t.Fatalf("first event = %+v, want a session event", event)
+	}
+	return resp, reader, session["session_id"]
+}
+
+func TestSSEStreamsResults(t *testing.T) {
+	handler := NewSSEHandler(DefaultConfig())
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	defer handler.Shutdown() // server.Close waits for open streams
+
+	_, reader, session := openStream(t, server.URL)
+
+	cards := []struct {
+		number  string
+		network string
+		valid   bool
+	}{
+		{"4111111111111111", "Visa", true},
+		{"5500000000000005", "Mastercard", false},
+	}
+	for _, card := range cards {
+		resp, err := http.Post(server.URL+"?session="+session, "application/json",
+			strings.NewReader(`{"card_number":"`+card.number+`"}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusAccepted {
+			t.Fatalf("submit %s: status = %d, want 202", card.number, resp.StatusCode)
+		}
+	}
+
+	// Results arrive in submission order, each flushed as its own event
+	for _, card := range cards {
+		event := readEvent(t, reader)
+		var result Response
+		if event.name != "result" || json.Unmarshal([]byte(event.data), &result) != nil {
+			t.Fatalf("event = %+v, want a result event", event)
+		}
+		if result.Network != card.network || result.Valid != card.valid {
+			t.Errorf("%s: network %q, valid %v; want %q, %v", card.number, result.Network, result.Valid, card.network, card.valid)
+		}
+	}
+}
+
+func TestSSEUnknownSession(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/validate/sse?session=missing", strings.NewReader(`{"card_number":"4111111111111111"}`))
+	w := httptest.NewRecorder()
+	NewSSEHandler(DefaultConfig()).ServeHTTP(w, r)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", w.Code)
+	}
+}
+
+func TestSSEShutdownEndsStreams(t *testing.T) {
+	handler := NewSSEHandler(DefaultConfig())
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	resp, _, _ := openStream(t, server.URL)
+
+	ended := make(chan struct{})
+	go func() {
+		io.Copy(io.Discard, resp.Body)
+		close(ended)
+	}()
+
+	handler.Shutdown()
+	select {
+	case <-ended:
+	case <-time.After(2 * time.Second):
+		t.Fatal("stream still open 2s after Shutdown")
+	}
+
+	// Shutdown is safe to call more than once
+	handler.Shutdown()
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/jamesmeyerr/credit-card-validator/internal/middleware"
+)
+
+// recordSpans installs an in-memory exporter as the global tracer provider for one test
+func recordSpans(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	previousProvider, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		provider.Shutdown(context.Background())
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+	return exporter
+}
+
+func TestValidationSpans(t *testing.T) {
+	exporter := recordSpans(t)
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	r := httptest.NewRequest(http.MethodPost, "/validate", strings.NewReader(`{"card_number":"4111111111111111","cvv":"123"}`))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+	middleware.TracingMiddleware(NewValidationHandler(DefaultConfig())).ServeHTTP(w, r)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("%d spans, want the server span and the validation span", len(spans))
+	}
+	validation, server := spans[0], spans[1]
+
+	if server.Name != "POST /validate" || server.SpanKind != trace.SpanKindServer {
+		t.Errorf("server span = %q (%v)", server.Name, server.SpanKind)
+	}
+	if server.SpanContext.TraceID().String() != traceID {
+		t.Errorf("server span trace ID = %s, want the traceparent's %s", server.SpanContext.TraceID(), traceID)
+	}
+	if validation.Name != "luhn.ValidateCard" || validation.Parent.SpanID() != server.SpanContext.SpanID() {
+		t.Errorf("validation span = %q with parent %s, want a child of %s", validation.Name, validation.Parent.SpanID(), server.SpanContext.SpanID())
+	}
+
+	attributes := make(map[string]string)
+	for _, span := range spans {
+		for _, kv := range span.Attributes {
+			value := kv.Value.Emit()
+			attributes[string(kv.Key)] = value
+			if strings.Contains(value, "4111111111111111") || value == "123" {
+				t.Errorf("span %q attribute %s carries card data: %s", span.Name, kv.Key, value)
+			}
+		}
+	}
+	if attributes["card.network"] != "Visa" || attributes["card.outcome"] != "valid" || attributes["http.response.status_code"] != "200" {
+		t.Errorf("attributes = %v", attributes)
+	}
+}
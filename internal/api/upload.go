@@ -0,0 +1,210 @@
+package api
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/jamesmeyerr/credit-card-validator/internal/luhn"
+	"github.com/jamesmeyerr/credit-card-validator/internal/middleware"
+)
+
+// UploadConfig defines CSV upload limits
+type UploadConfig struct {
+	Config // settings shared with the other validation endpoints
+
+	MaxFileSize int64 // in bytes, for the whole multipart body
+	MaxRows     int   // maximum cards per file, excluding an optional header row
+	Concurrency int   // maximum cards validated in parallel
+}
+
+// DefaultUploadConfig returns a default configuration
+func DefaultUploadConfig() UploadConfig {
+	return UploadConfig{
+		Config:      DefaultConfig(),
+		MaxFileSize: 1024 * 1024, // 1MB is roughly 25,000 formatted rows
+		MaxRows:     10000,
+		Concurrency: luhn.DefaultBatchConcurrency,
+	}
+}
+
+// errTooManyRows is returned when an uploaded file exceeds MaxRows
+var errTooManyRows = errors.New("too many rows")
+
+// UploadHandler validates cards from a CSV file posted as multipart/form-data.
+// Rows are card_number[,expiry_date[,cvv]] with an optional header row.
+type UploadHandler struct {
+	config UploadConfig
+}
+
+// NewUploadHandler creates a new CSV upload validation handler
+func NewUploadHandler(config UploadConfig) *UploadHandler {
+	return &UploadHandler{
+		config: config,
+	}
+}
+
+// ServeHTTP validates every row of the uploaded file, returning JSON results in row
+// order, or a CSV results file when requested with ?format=csv
+func (h *UploadHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Get logger with request context
+	logger := middleware.ApplicationLogger(r.Context())
+
+	if r.Method != http.MethodPost {
+		logger.Warn().Str("method", r.Method).Msg("Invalid HTTP method")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/form-data" {
+		middleware.WriteError(w, r, http.StatusUnsupportedMediaType, middleware.ErrCodeUnsupportedMediaType,
+			"Content-Type must be multipart/form-data")
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, h.config.MaxFileSize)
+	file, err := openUploadedFile(r)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			middleware.WriteError(w, r, http.StatusRequestEntityTooLarge, middleware.ErrCodeRequestTooLarge,
+				fmt.Sprintf("upload exceeds max size %d bytes", h.config.MaxFileSize))
+			return
+		}
+		middleware.WriteError(w, r, http.StatusBadRequest, middleware.ErrCodeInvalidUpload, "a CSV file is required in the \"file\" field")
+		return
+	}
+
+	validationReqs, err := readUploadRows(file, h.config.MaxRows)
+	if err != nil {
+		// Never include the parse error itself: it can quote the offending row
+		var maxBytesErr *http.MaxBytesError
+		switch {
+		case errors.As(err, &maxBytesErr):
+			middleware.WriteError(w, r, http.StatusRequestEntityTooLarge, middleware.ErrCodeRequestTooLarge,
+				fmt.Sprintf("upload exceeds max size %d bytes", h.config.MaxFileSize))
+		case errors.Is(err, errTooManyRows):
+			middleware.WriteError(w, r, http.StatusRequestEntityTooLarge, middleware.ErrCodeRequestTooLarge,
+				fmt.Sprintf("upload exceeds max row count %d", h.config.MaxRows))
+		default:
+			middleware.WriteError(w, r, http.StatusBadRequest, middleware.ErrCodeInvalidUpload, "file is not valid CSV")
+		}
+		return
+	}
+	if len(validationReqs) == 0 {
+		middleware.WriteError(w, r, http.StatusBadRequest, middleware.ErrCodeInvalidUpload, "file contains no card rows")
+		return
+	}
+	for i := range validationReqs {
+		validationReqs[i].Config = h.config.Validation
+	}
+
+	// Validate in parallel, stopping early if the client goes away
+	cardInfos, err := luhn.ValidateBatch(r.Context(), validationReqs, h.config.Concurrency)
+	if err != nil {
+		logger.Warn().Err(err).Msg("Upload validation cancelled")
+		return
+	}
+
+	lang := negotiateLanguage(r)
+	resp := BatchResponse{Results: make([]Response, len(cardInfos))}
+	for i, cardInfo := range cardInfos {
+		resp.Results[i] = buildResponse(cardInfo, lang)
+		emitValidationEvent(r.Context(), validationReqs[i].CardNumber, cardInfo)
+	}
+
+	logger.Info().Int("count", len(cardInfos)).Msg("Upload validation completed")
+
+	if r.URL.Query().Get("format") == "csv" {
+		writeUploadCSV(w, validationReqs, resp.Results)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	encodeBatchResponse(w, r, resp)
+}
+
+// openUploadedFile finds the "file" part of the multipart body without buffering it to disk
+func openUploadedFile(r *http.Request) (io.Reader, error) {
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			return nil, err
+		}
+		if part.FormName() == "file" {
+			return part, nil
+		}
+	}
+}
+
+// readUploadRows parses card_number[,expiry_date[,cvv]] rows, skipping blank rows and a
+// header row whose first field contains no digits
+func readUploadRows(file io.Reader, maxRows int) ([]luhn.CardValidationRequest, error) {
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var reqs []luhn.CardValidationRequest
+	for first := true; ; first = false {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return reqs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		cardNumber := strings.TrimSpace(record[0])
+		if cardNumber == "" {
+			continue
+		}
+		if first && !strings.ContainsAny(cardNumber, "0123456789") {
+			continue
+		}
+		if len(reqs) == maxRows {
+			return nil, errTooManyRows
+		}
+
+		req := luhn.CardValidationRequest{CardNumber: cardNumber}
+		if len(record) > 1 {
+			req.ExpiryDate = strings.TrimSpace(record[1])
+		}
+		if len(record) > 2 {
+			req.CVV = strings.TrimSpace(record[2])
+		}
+		reqs = append(reqs, req)
+	}
+}
+
+// writeUploadCSV writes the results as a downloadable CSV file. Card numbers are cleaned and masked.
+func writeUploadCSV(w http.ResponseWriter, reqs []luhn.CardValidationRequest, results []Response) {
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", `attachment; filename="validation-results.csv"`)
+	w.WriteHeader(http.StatusOK)
+
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"row", "card_number", "valid", "network", "code", "outcome", "message"})
+	for i, result := range results {
+		writer.Write([]string{
+			strconv.Itoa(i + 1),
+			luhn.Mask(luhn.Clean(reqs[i].CardNumber), 6, 4),
+			strconv.FormatBool(result.Valid),
+			result.Network,
+			result.Code,
+			result.Outcome,
+			result.Message,
+		})
+	}
+	writer.Flush()
+}
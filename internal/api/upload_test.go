@@ -0,0 +1,143 @@
+package api
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jamesmeyerr/credit-card-validator/internal/middleware"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// upload posts contents as the "file" field of a multipart form to an UploadHandler
+func upload(t *testing.T, config UploadConfig, target, contents string) *httptest.ResponseRecorder {
+	t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", "cards.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write([]byte(contents))
+	form.Close()
+
+	r := httptest.NewRequest(http.MethodPost, target, &body)
+	r.Header.Set("Content-Type", form.FormDataContentType())
+	w := httptest.NewRecorder()
+	NewUploadHandler(config).ServeHTTP(w, r)
+	return w
+}
+
+var uploadCSV = "card_number,expiry_date,cvv\n" +
+	"4111 1111 1111 1111," + futureExpiry() + ",123\n" +
+	"4111111111111112\n" +
+	"\n" +
+	"378282246310005,,1234\n"
+
+func TestUploadJSONResults(t *testing.T) {
+	w := upload(t, DefaultUploadConfig(), "/validate/upload", uploadCSV)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body)
+	}
+
+	var resp BatchResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		valid   bool
+		network string
+	}{
+		{true, "Visa"},
+		{false, ""},
+		{true, "American Express"},
+	}
+	if len(resp.Results) != len(want) {
+		t.Fatalf("%d results, want %d (header and blank rows skipped)", len(resp.Results), len(want))
+	}
+	for i, result := range resp.Results {
+		if result.Valid != want[i].valid || (want[i].network != "" && result.Network != want[i].network) {
+			t.Errorf("row %d: valid %v, network %q; want %v, %q", i+1, result.Valid, result.Network, want[i].valid, want[i].network)
+		}
+	}
+	if !resp.Results[2].CVVValid {
+		t.Error("row 3: 4-digit Amex CVV was not read from the third column")
+	}
+}
+
+func TestUploadCSVResults(t *testing.T) {
+	w := upload(t, DefaultUploadConfig(), "/validate/upload?format=csv", uploadCSV)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "text/csv" {
+		t.Errorf("Content-Type = %q, want text/csv", ct)
+	}
+
+	records, err := csv.NewReader(w.Body).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 4 {
+		t.Fatalf("%d records, want a header and 3 rows", len(records))
+	}
+	if got := records[1]; got[0] != "1" || got[1] != "411111******1111" || got[2] != "true" || got[3] != "Visa" {
+		t.Errorf("row 1 = %v", got)
+	}
+	if got := records[2]; got[1] != "411111******1112" || got[2] != "false" {
+		t.Errorf("row 2 = %v", got)
+	}
+}
+
+func TestUploadLimits(t *testing.T) {
+	config := DefaultUploadConfig()
+	config.MaxRows = 2
+	w := upload(t, config, "/validate/upload", uploadCSV)
+	if w.Code != http.StatusRequestEntityTooLarge || decodeErrorDetail(t, w).Code != middleware.ErrCodeRequestTooLarge {
+		t.Errorf("too many rows: status %d", w.Code)
+	}
+
+	config = DefaultUploadConfig()
+	config.MaxFileSize = 64
+	w = upload(t, config, "/validate/upload", strings.Repeat("4111111111111111\n", 20))
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("oversized file: status %d, want 413", w.Code)
+	}
+}
+
+func TestUploadRejectsBadRequests(t *testing.T) {
+	w := upload(t, DefaultUploadConfig(), "/validate/upload", "card_number\n")
+	if w.Code != http.StatusBadRequest || decodeErrorDetail(t, w).Code != middleware.ErrCodeInvalidUpload {
+		t.Errorf("header-only file: status %d, want 400", w.Code)
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/validate/upload", strings.NewReader(`{"card_number":"4111111111111111"}`))
+	r.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	NewUploadHandler(DefaultUploadConfig()).ServeHTTP(w, r)
+	if w.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("JSON body: status %d, want 415", w.Code)
+	}
+}
+
+func TestUploadDoesNotLogPANs(t *testing.T) {
+	var logs bytes.Buffer
+	previous := log.Logger
+	log.Logger = zerolog.New(&logs).Level(zerolog.DebugLevel)
+	t.Cleanup(func() { log.Logger = previous })
+
+	upload(t, DefaultUploadConfig(), "/validate/upload", uploadCSV)
+	upload(t, DefaultUploadConfig(), "/validate/upload", "4111111111111111,\"unterminated\n")
+
+	for _, pan := range []string{"4111111111111111", "4111 1111 1111 1111", "378282246310005"} {
+		if strings.Contains(logs.String(), pan) {
+			t.Errorf("log contains %s: %s", pan, logs.String())
+		}
+	}
+}
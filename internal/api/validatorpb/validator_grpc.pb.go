@@ -0,0 +1,150 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             v4.24.4
+// source: validator/v1/validator.proto
+
+package validatorpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	CardValidator_Validate_FullMethodName      = "/validator.v1.CardValidator/Validate"
+	CardValidator_ValidateBatch_FullMethodName = "/validator.v1.CardValidator/ValidateBatch"
+)
+
+// CardValidatorClient is the client API for CardValidator service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type CardValidatorClient interface {
+	// Validate checks a single card
+	Validate(ctx context.Context, in *ValidateRequest, opts ...grpc.CallOption) (*ValidateResponse, error)
+	// ValidateBatch checks several cards in one call, preserving input order
+	ValidateBatch(ctx context.Context, in *ValidateBatchRequest, opts ...grpc.CallOption) (*ValidateBatchResponse, error)
+}
+
+type cardValidatorClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewCardValidatorClient(cc grpc.ClientConnInterface) CardValidatorClient {
+	return &cardValidatorClient{cc}
+}
+
+func (c *cardValidatorClient) Validate(ctx context.Context, in *ValidateRequest, opts ...grpc.CallOption) (*ValidateResponse, error) {
+	out := new(ValidateResponse)
+	err := c.cc.Invoke(ctx, CardValidator_Validate_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cardValidatorClient) ValidateBatch(ctx context.Context, in *ValidateBatchRequest, opts ...grpc.CallOption) (*ValidateBatchResponse, error) {
+	out := new(ValidateBatchResponse)
+	err := c.cc.Invoke(ctx, CardValidator_ValidateBatch_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CardValidatorServer is the server API for CardValidator service.
+// All implementations must embed UnimplementedCardValidatorServer
+// for forward compatibility
+type CardValidatorServer interface {
+	// Validate checks a single card
+	Validate(context.Context, *ValidateRequest) (*ValidateResponse, error)
+	// ValidateBatch checks several cards in one call, preserving input order
+	ValidateBatch(context.Context, *ValidateBatchRequest) (*ValidateBatchResponse, error)
+	mustEmbedUnimplementedCardValidatorServer()
+}
+
+// UnimplementedCardValidatorServer must be embedded to have forward compatible implementations.
+type UnimplementedCardValidatorServer struct {
+}
+
+func (UnimplementedCardValidatorServer) Validate(context.Context, *ValidateRequest) (*ValidateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Validate not implemented")
+}
+func (UnimplementedCardValidatorServer) ValidateBatch(context.Context, *ValidateBatchRequest) (*ValidateBatchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ValidateBatch not implemented")
+}
+func (UnimplementedCardValidatorServer) mustEmbedUnimplementedCardValidatorServer() {}
+
+// UnsafeCardValidatorServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to CardValidatorServer will
+// result in compilation errors.
+type UnsafeCardValidatorServer interface {
+	mustEmbedUnimplementedCardValidatorServer()
+}
+
+func RegisterCardValidatorServer(s grpc.ServiceRegistrar, srv CardValidatorServer) {
+	s.RegisterService(&CardValidator_ServiceDesc, srv)
+}
+
+func _CardValidator_Validate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ValidateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CardValidatorServer).Validate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CardValidator_Validate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CardValidatorServer).Validate(ctx, req.(*ValidateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CardValidator_ValidateBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ValidateBatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CardValidatorServer).ValidateBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CardValidator_ValidateBatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CardValidatorServer).ValidateBatch(ctx, req.(*ValidateBatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// CardValidator_ServiceDesc is the grpc.ServiceDesc for CardValidator service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var CardValidator_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "validator.v1.CardValidator",
+	HandlerType: (*CardValidatorServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Validate",
+			Handler:    _CardValidator_Validate_Handler,
+		},
+		{
+			MethodName: "ValidateBatch",
+			Handler:    _CardValidator_ValidateBatch_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "validator/v1/validator.proto",
+}
@@ -0,0 +1,160 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// AuditConfig defines where audit records are written
+type AuditConfig struct {
+	// Path is the audit file, opened for appending. It is ignored when Writer is set.
+	Path string
+
+	// Writer receives records instead of a file, for example os.Stdout. It is never rotated.
+	Writer io.Writer
+
+	// MaxSizeBytes rotates the file before a record would take it past this size; 0 disables rotation
+	MaxSizeBytes int64
+
+	// MaxBackups is the number of rotated files kept as Path.1 (newest) to Path.N
+	MaxBackups int
+}
+
+// DefaultAuditConfig returns a default configuration for the given audit file
+func DefaultAuditConfig(path string) AuditConfig {
+	return AuditConfig{
+		Path:         path,
+		MaxSizeBytes: 100 * 1024 * 1024,
+		MaxBackups:   5,
+	}
+}
+
+// AuditLogger writes one JSON line per validation decision to an append-only trail kept
+// separate from the operational request log. Records are the same masked ValidationEvent
+// sent to the webhook, so raw PANs and CVVs never reach it.
+type AuditLogger struct {
+	config AuditConfig
+
+	mu   sync.Mutex
+	out  io.Writer
+	file *os.File // set when writing to Path
+	size int64    // bytes in the current file
+}
+
+// NewAuditLogger opens the audit file, or uses the configured writer
+func NewAuditLogger(config AuditConfig) (*AuditLogger, error) {
+	a := &AuditLogger{config: config, out: config.Writer}
+	if a.out == nil {
+		if config.Path == "" {
+			return nil, fmt.Errorf("audit log path or writer is required")
+		}
+		if err := a.open(); err != nil {
+			return nil, err
+		}
+	}
+	return a, nil
+}
+
+// Log appends a record for the event. It is safe to call on a nil logger, which discards the event.
+func (a *AuditLogger) Log(event ValidationEvent) error {
+	if a == nil {
+		return nil
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now().UTC()
+	}
+	event.MaskedNumber = ensureMasked(event.MaskedNumber)
+
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.file != nil && a.config.MaxSizeBytes > 0 && a.size > 0 && a.size+int64(len(line)) > a.config.MaxSizeBytes {
+		if err := a.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := a.out.Write(line)
+	a.size += int64(n)
+	return err
+}
+
+// Close closes the audit file. It is safe to call on a nil logger.
+func (a *AuditLogger) Close() error {
+	if a == nil {
+		return nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.file == nil {
+		return nil
+	}
+	return a.file.Close()
+}
+
+// open opens Path for appending, creating it readable only by the service
+func (a *AuditLogger) open() error {
+	file, err := os.OpenFile(a.config.Path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return fmt.Errorf("open audit log: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("open audit log: %w", err)
+	}
+	a.file, a.out, a.size = file, file, info.Size()
+	return nil
+}
+
+// rotate shifts Path.1..Path.N-1 up by one, moves the current file to Path.1, and
+// starts a new file. The oldest backup is overwritten. The caller holds a.mu.
+func (a *AuditLogger) rotate() error {
+	if err := a.file.Close(); err != nil {
+		return fmt.Errorf("rotate audit log: %w", err)
+	}
+
+	if a.config.MaxBackups > 0 {
+		for i := a.config.MaxBackups - 1; i >= 1; i-- {
+			os.Rename(backupPath(a.config.Path, i), backupPath(a.config.Path, i+1))
+		}
+		if err := os.Rename(a.config.Path, backupPath(a.config.Path, 1)); err != nil {
+			return fmt.Errorf("rotate audit log: %w", err)
+		}
+	} else if err := os.Remove(a.config.Path); err != nil {
+		return fmt.Errorf("rotate audit log: %w", err)
+	}
+
+	return a.open()
+}
+
+// backupPath names the nth rotated audit file
+func backupPath(path string, n int) string {
+	return fmt.Sprintf("%s.%d", path, n)
+}
+
+// ensureMasked guards against a caller passing an unmasked number: a value with digits
+// but no masked positions has every digit hidden
+func ensureMasked(number string) string {
+	if strings.ContainsRune(number, '*') {
+		return number
+	}
+	return strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) {
+			return '*'
+		}
+		return r
+	}, number)
+}
@@ -0,0 +1,248 @@
+package events
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// auditLines returns the lines of an audit file, failing the test if it cannot be read
+func auditLines(t *testing.T, path string) []string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+}
+
+func TestAuditRecordFormat(t *testing.T) {
+	var buf bytes.Buffer
+	audit, err := NewAuditLogger(AuditConfig{Writer: &buf})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	at := time.Date(2026, time.March, 4, 5, 6, 7, 0, time.UTC)
+	if err := audit.Log(ValidationEvent{MaskedNumber: "411111******1111", Network: "Visa", Outcome: "valid", RequestID: "req-1", Timestamp: at}); err != nil {
+		t.Fatal(err)
+	}
+
+	line := buf.String()
+	if strings.Count(line, "\n") != 1 || !strings.HasSuffix(line, "\n") {
+		t.Fatalf("record = %q, want one JSON line", line)
+	}
+	var record map[string]interface{}
+	if err := json.Unmarshal([]byte(line), &record); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"timestamp":     "2026-03-04T05:06:07Z",
+		"request_id":    "req-1",
+		"masked_number": "411111******1111",
+		"network":       "Visa",
+		"outcome":       "valid",
+	}
+	if !reflect.DeepEqual(record, want) {
+		t.Errorf("record = %v, want %v", record, want)
+	}
+}
+
+func TestAuditFillsTimestamp(t *testing.T) {
+	var buf bytes.Buffer
+	audit, _ := NewAuditLogger(AuditConfig{Writer: &buf})
+	before := time.Now().UTC().Add(-time.Second)
+	audit.Log(ValidationEvent{MaskedNumber: "411111******1111", Outcome: "valid"})
+
+	var event ValidationEvent
+	if err := json.Unmarshal(buf.Bytes(), &event); err != nil {
+		t.Fatal(err)
+	}
+	if event.Timestamp.Before(before) || event.Timestamp.Location() != time.UTC {
+		t.Errorf("timestamp = %v, want the current UTC time", event.Timestamp)
+	}
+}
+
+func TestAuditNeverWritesRawNumbers(t *testing.T) {
+	var buf bytes.Buffer
+	audit, _ := NewAuditLogger(AuditConfig{Writer: &buf})
+
+	tests := map[string]string{
+		"4111111111111111":    "****************",
+		"4111 1111 1111 1111": "**** **** **** ****",
+		"411111******1111":    "411111******1111",
+		"":                    "",
+	}
+	for number, want := range tests {
+		if got := ensureMasked(number); got != want {
+			t.Errorf("ensureMasked(%q) = %q, want %q", number, got, want)
+		}
+		buf.Reset()
+		audit.Log(ValidationEvent{MaskedNumber: number, Outcome: "valid"})
+		if strings.Contains(buf.String(), "4111111111111111") || strings.Contains(buf.String(), "4111 1111") {
+			t.Errorf("record for %q contains the raw number: %s", number, buf.String())
+		}
+	}
+}
+
+func TestAuditNilLogger(t *testing.T) {
+	var audit *AuditLogger
+	if err := audit.Log(ValidationEvent{MaskedNumber: "411111******1111"}); err != nil {
+		t.Errorf("nil Log: %v", err)
+	}
+	if err := audit.Close(); err != nil {
+		t.Errorf("nil Close: %v", err)
+	}
+}
+
+func TestAuditRequiresDestination(t *testing.T) {
+	if _, err := NewAuditLogger(AuditConfig{}); err == nil {
+		t.Error("no path or writer: no error")
+	}
+	if _, err := NewAuditLogger(AuditConfig{Path: filepath.Join(t.TempDir(), "missing", "audit.log")}); err == nil {
+		t.Error("unwritable path: no error")
+	}
+}
+
+func TestAuditFileAppendsAndIsPrivate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	for i := 0; i < 2; i++ {
+		audit, err := NewAuditLogger(DefaultAuditConfig(path))
+		if err != nil {
+			t.Fatal(err)
+		}
+		audit.Log(ValidationEvent{MaskedNumber: "411111******1111", Outcome: "valid"})
+		audit.Close()
+	}
+
+	if lines := auditLines(t, path); len(lines) != 2 {
+		t.Errorf("%d records after reopening, want 2", len(lines))
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0o600 {
+		t.Errorf("mode = %v, want 0600", mode)
+	}
+}
+
+func TestAuditRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	event := ValidationEvent{MaskedNumber: "411111******1111", Network: "Visa", Outcome: "valid", RequestID: "a", Timestamp: time.Unix(0, 0).UTC()}
+	record, _ := json.Marshal(event)
+	recordSize := int64(len(record) + 1)
+
+	// Each file holds three records and two backups are kept
+	audit, err := NewAuditLogger(AuditConfig{Path: path, MaxSizeBytes: 3 * recordSize, MaxBackups: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer audit.Close()
+	for i := 0; i < 10; i++ {
+		event.RequestID = string(rune('a' + i))
+		if err := audit.Log(event); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Records a-j fill files of three: a-c are dropped, d-f are in .2, g-i in .1, and j is current
+	want := map[string][]string{path: {"j"}, path + ".1": {"g", "h", "i"}, path + ".2": {"d", "e", "f"}}
+	for file, ids := range want {
+		var got []string
+		for _, line := range auditLines(t, file) {
+			var e ValidationEvent
+			if err := json.Unmarshal([]byte(line), &e); err != nil {
+				t.Fatalf("%s: %v", filepath.Base(file), err)
+			}
+			got = append(got, e.RequestID)
+		}
+		if !reflect.DeepEqual(got, ids) {
+			t.Errorf("%s: request IDs %v, want %v", filepath.Base(file), got, ids)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("%s.3 exists beyond MaxBackups", filepath.Base(path))
+	}
+}
+
+func TestAuditRotationWithoutBackups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	audit, err := NewAuditLogger(AuditConfig{Path: path, MaxSizeBytes: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer audit.Close()
+	for i := 0; i < 3; i++ {
+		audit.Log(ValidationEvent{MaskedNumber: "411111******1111", RequestID: string(rune('a' + i))})
+	}
+
+	// A record larger than the limit still gets a file to itself
+	if lines := auditLines(t, path); len(lines) != 1 || !strings.Contains(lines[0], `"request_id":"c"`) {
+		t.Errorf("lines = %v, want only the last record", lines)
+	}
+	if _, err := os.Stat(path + ".1"); !os.IsNotExist(err) {
+		t.Error("backup kept with MaxBackups 0")
+	}
+}
+
+func TestAuditConcurrentRecordsStayWhole(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	audit, err := NewAuditLogger(AuditConfig{Path: path, MaxSizeBytes: 4096, MaxBackups: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				audit.Log(ValidationEvent{MaskedNumber: "411111******1111", Network: "Visa", Outcome: "valid"})
+			}
+		}()
+	}
+	wg.Wait()
+	audit.Close()
+
+	files, _ := filepath.Glob(path + "*")
+	sort.Strings(files)
+	total := 0
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			var e ValidationEvent
+			if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+				t.Errorf("%s: corrupt record %q", filepath.Base(file), scanner.Text())
+			}
+			total++
+		}
+		f.Close()
+	}
+	if total != 400 {
+		t.Errorf("%d records across %d files, want 400", total, len(files))
+	}
+}
+
+func BenchmarkAuditLog(b *testing.B) {
+	audit, _ := NewAuditLogger(AuditConfig{Writer: io.Discard})
+	event := ValidationEvent{MaskedNumber: "411111******1111", Network: "Visa", Outcome: "valid", RequestID: "req-1"}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		audit.Log(event)
+	}
+}
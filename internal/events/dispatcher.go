@@ -0,0 +1,192 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ValidationEvent is the payload posted to the webhook. It must never carry the raw PAN or CVV.
+type ValidationEvent struct {
+	MaskedNumber string    `json:"masked_number"`
+	Network      string    `json:"network"`
+	Outcome      string    `json:"outcome"`
+	RequestID    string    `json:"request_id,omitempty"`
+	Timestamp    time.Time `json:"timestamp"`
+}
+
+// DispatcherConfig defines webhook delivery behaviour
+type DispatcherConfig struct {
+	URL            string
+	QueueSize      int           // events buffered before new ones are dropped
+	MaxRetries     int           // delivery attempts after the first failure
+	InitialBackoff time.Duration // doubled after each failed attempt
+	Timeout        time.Duration // per-attempt HTTP timeout
+}
+
+// DefaultDispatcherConfig returns a default configuration for the given webhook URL
+func DefaultDispatcherConfig(url string) DispatcherConfig {
+	return DispatcherConfig{
+		URL:            url,
+		QueueSize:      1000,
+		MaxRetries:     3,
+		InitialBackoff: 500 * time.Millisecond,
+		Timeout:        5 * time.Second,
+	}
+}
+
+// Dispatcher delivers validation events to a webhook asynchronously
+type Dispatcher struct {
+	config DispatcherConfig
+	client *http.Client
+	queue  chan ValidationEvent
+	done   chan struct{}
+	wg     sync.WaitGroup
+}
+
+// NewDispatcher creates a dispatcher and starts its delivery worker
+func NewDispatcher(config DispatcherConfig) *Dispatcher {
+	d := &Dispatcher{
+		config: config,
+		client: &http.Client{Timeout: config.Timeout},
+		queue:  make(chan ValidationEvent, config.QueueSize),
+		done:   make(chan struct{}),
+	}
+
+	d.wg.Add(1)
+	go d.run()
+
+	return d
+}
+
+// Dispatch queues an event without blocking. Events are dropped with a warning if the queue is full.
+// It is safe to call on a nil dispatcher, which discards the event.
+func (d *Dispatcher) Dispatch(event ValidationEvent) {
+	if d == nil {
+		return
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now().UTC()
+	}
+
+	select {
+	case d.queue <- event:
+	default:
+		log.Warn().
+			Str("request_id", event.RequestID).
+			Int("queue_size", d.config.QueueSize).
+			Msg("Webhook queue full, dropping validation event")
+	}
+}
+
+// Shutdown stops accepting retries and waits for queued events to be sent or the context to expire
+func (d *Dispatcher) Shutdown(ctx context.Context) error {
+	if d == nil {
+		return nil
+	}
+	close(d.done)
+
+	finished := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run delivers queued events until shutdown, then drains what is left
+func (d *Dispatcher) run() {
+	defer d.wg.Done()
+	for {
+		select {
+		case event := <-d.queue:
+			d.deliver(event)
+		case <-d.done:
+			for {
+				select {
+				case event := <-d.queue:
+					d.deliver(event)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// deliver posts an event, retrying with exponential backoff
+func (d *Dispatcher) deliver(event ValidationEvent) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to encode validation event")
+		return
+	}
+
+	backoff := d.config.InitialBackoff
+	attempts := 0
+	for attempt := 0; attempt <= d.config.MaxRetries; attempt++ {
+		// An attempt started after shutdown began is the last one, including for events
+		// drained from the queue during shutdown
+		final := d.stopping()
+		if attempt > 0 && !final {
+			select {
+			case <-time.After(backoff):
+				backoff *= 2
+			case <-d.done:
+				// Shutting down: make one last attempt without waiting, then give up
+				final = true
+			}
+		}
+
+		attempts++
+		err = d.post(body)
+		if err == nil || final {
+			break
+		}
+	}
+	if err == nil {
+		return
+	}
+
+	log.Warn().
+		Err(err).
+		Str("request_id", event.RequestID).
+		Int("attempts", attempts).
+		Msg("Failed to deliver validation event to webhook")
+}
+
+// stopping reports whether shutdown has begun
+func (d *Dispatcher) stopping() bool {
+	select {
+	case <-d.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// post sends a single delivery attempt
+func (d *Dispatcher) post(body []byte) error {
+	resp, err := d.client.Post(d.config.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
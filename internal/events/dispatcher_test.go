@@ -0,0 +1,204 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// stubWebhook records the events posted to it. It fails the first failures attempts, or every
+// attempt when failures is negative.
+type stubWebhook struct {
+	*httptest.Server
+	failures int32
+	attempts atomic.Int32
+
+	mu     sync.Mutex
+	events []ValidationEvent
+}
+
+func newStubWebhook(t *testing.T, failures int32) *stubWebhook {
+	t.Helper()
+	stub := &stubWebhook{failures: failures}
+	stub.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if n := stub.attempts.Add(1); n <= stub.failures || stub.failures < 0 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("Content-Type = %q, want application/json", ct)
+		}
+		var event ValidationEvent
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			t.Errorf("decode event: %v", err)
+		}
+		stub.mu.Lock()
+		stub.events = append(stub.events, event)
+		stub.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(stub.Close)
+	return stub
+}
+
+// received returns the events delivered so far
+func (s *stubWebhook) received() []ValidationEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]ValidationEvent(nil), s.events...)
+}
+
+// testDispatcherConfig retries quickly against the stub
+func testDispatcherConfig(url string) DispatcherConfig {
+	config := DefaultDispatcherConfig(url)
+	config.InitialBackoff = time.Millisecond
+	config.Timeout = time.Second
+	return config
+}
+
+// shutdown stops the dispatcher, failing the test if it does not finish promptly
+func shutdown(t *testing.T, d *Dispatcher) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := d.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+}
+
+func TestDispatcherDeliversEvent(t *testing.T) {
+	stub := newStubWebhook(t, 0)
+	d := NewDispatcher(testDispatcherConfig(stub.URL))
+
+	d.Dispatch(ValidationEvent{MaskedNumber: "411111******1111", Network: "Visa", Outcome: "valid", RequestID: "req-1"})
+	shutdown(t, d)
+
+	events := stub.received()
+	if len(events) != 1 {
+		t.Fatalf("received %d events, want 1", len(events))
+	}
+	event := events[0]
+	if event.MaskedNumber != "411111******1111" || event.Network != "Visa" || event.Outcome != "valid" || event.RequestID != "req-1" {
+		t.Errorf("event = %+v", event)
+	}
+	if event.Timestamp.IsZero() {
+		t.Error("timestamp was not set")
+	}
+}
+
+func TestDispatcherRetries(t *testing.T) {
+	stub := newStubWebhook(t, 2)
+	d := NewDispatcher(testDispatcherConfig(stub.URL))
+
+	d.Dispatch(ValidationEvent{MaskedNumber: "411111******1111"})
+	deadline := time.Now().Add(2 * time.Second)
+	for len(stub.received()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	shutdown(t, d)
+
+	if got := stub.attempts.Load(); got != 3 {
+		t.Errorf("%d attempts, want 3", got)
+	}
+	if len(stub.received()) != 1 {
+		t.Errorf("received %d events, want 1", len(stub.received()))
+	}
+}
+
+func TestDispatcherGivesUpAfterMaxRetries(t *testing.T) {
+	stub := newStubWebhook(t, -1)
+	config := testDispatcherConfig(stub.URL)
+	config.MaxRetries = 2
+	d := NewDispatcher(config)
+
+	d.Dispatch(ValidationEvent{MaskedNumber: "411111******1111"})
+	deadline := time.Now().Add(2 * time.Second)
+	for stub.attempts.Load() < 3 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	shutdown(t, d)
+
+	if got := stub.attempts.Load(); got != 3 {
+		t.Errorf("%d attempts, want 3", got)
+	}
+}
+
+func TestDispatcherShutdownMakesOneFinalAttempt(t *testing.T) {
+	stub := newStubWebhook(t, -1)
+	config := testDispatcherConfig(stub.URL)
+	config.InitialBackoff = time.Hour
+	d := NewDispatcher(config)
+
+	d.Dispatch(ValidationEvent{MaskedNumber: "411111******1111"})
+	deadline := time.Now().Add(2 * time.Second)
+	for stub.attempts.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	// The dispatcher is now waiting an hour to retry; shutdown cuts that short
+	start := time.Now()
+	shutdown(t, d)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Shutdown took %v", elapsed)
+	}
+	if got := stub.attempts.Load(); got != 2 {
+		t.Errorf("%d attempts, want the first and one final attempt", got)
+	}
+}
+
+func TestDispatcherEventsQueuedAfterShutdownAreTriedOnce(t *testing.T) {
+	stub := newStubWebhook(t, -1)
+	d := NewDispatcher(testDispatcherConfig(stub.URL))
+
+	// Stop the worker from seeing the events until shutdown has begun
+	close(d.done)
+	d.Dispatch(ValidationEvent{MaskedNumber: "411111******1111"})
+	d.Dispatch(ValidationEvent{MaskedNumber: "555555******4444"})
+	d.wg.Wait()
+
+	if got := stub.attempts.Load(); got != 2 {
+		t.Errorf("%d attempts for 2 events, want 2", got)
+	}
+}
+
+func TestDispatcherDropsWhenQueueFull(t *testing.T) {
+	block := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-block
+	}))
+	defer server.Close()
+	defer close(block)
+
+	config := testDispatcherConfig(server.URL)
+	config.QueueSize = 1
+	d := NewDispatcher(config)
+
+	// One event in flight, one queued, and the rest dropped without blocking
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 10; i++ {
+			d.Dispatch(ValidationEvent{MaskedNumber: strings.Repeat("*", 16)})
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Dispatch blocked on a full queue")
+	}
+}
+
+func TestNilDispatcher(t *testing.T) {
+	var d *Dispatcher
+	d.Dispatch(ValidationEvent{})
+	if err := d.Shutdown(context.Background()); err != nil {
+		t.Errorf("Shutdown on nil dispatcher: %v", err)
+	}
+}
@@ -0,0 +1,105 @@
+package luhn
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CheckAlgorithm selects how a card number's check digit is verified.
+// Some closed-loop gift cards use no check digit or a proprietary one.
+type CheckAlgorithm int
+
+const (
+	// CheckLuhn is the standard Luhn mod-10 check used by payment cards (the default)
+	CheckLuhn CheckAlgorithm = iota
+
+	// CheckNone skips the check digit; only the network length rules apply
+	CheckNone
+
+	// CheckCustom calls CardValidationRequest.CustomCheck. It is only available to Go callers.
+	CheckCustom
+)
+
+// String returns the name used in API requests
+func (a CheckAlgorithm) String() string {
+	switch a {
+	case CheckLuhn:
+		return "luhn"
+	case CheckNone:
+		return "none"
+	case CheckCustom:
+		return "custom"
+	}
+	return fmt.Sprintf("CheckAlgorithm(%d)", int(a))
+}
+
+// ParseCheckAlgorithm converts an API name into a CheckAlgorithm. An empty name means CheckLuhn.
+// CheckCustom cannot be selected by name because it needs a Go function.
+func ParseCheckAlgorithm(name string) (CheckAlgorithm, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "luhn":
+		return CheckLuhn, nil
+	case "none":
+		return CheckNone, nil
+	}
+	return CheckLuhn, fmt.Errorf("unknown check algorithm %q", name)
+}
+
+// CheckDigit configures the Luhn check for private-label numbering that moves the check digit
+// or uses another modulus. Digits other than the check digit are weighted as Luhn does when
+// computing a check digit: starting next to it on the right and alternating doubled and not.
+// The number is valid when that sum plus the check digit is a multiple of Modulus.
+// The zero value is standard Luhn: modulus 10 with the check digit last.
+type CheckDigit struct {
+	Modulus  int // 0 means 10; the check digit itself is always a single decimal digit
+	Position int // index of the check digit counted from the right, so 0 is the last digit
+}
+
+// Valid reports whether a digits-only number passes the configured check
+func (c CheckDigit) Valid(digits string) bool {
+	modulus := c.Modulus
+	if modulus == 0 {
+		modulus = 10
+	}
+	if modulus == 10 && c.Position == 0 {
+		return isLuhnValid(digits)
+	}
+	if modulus < 2 || c.Position < 0 || c.Position >= len(digits) || len(digits) < 2 || !isDigits(digits) {
+		return false
+	}
+
+	checkIndex := len(digits) - 1 - c.Position
+	sum := 0
+	double := true
+	for i := len(digits) - 1; i >= 0; i-- {
+		if i == checkIndex {
+			continue
+		}
+		digit := int(digits[i] - '0')
+		if double {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+		double = !double
+	}
+
+	return (sum+int(digits[checkIndex]-'0'))%modulus == 0
+}
+
+// passesCheck verifies a digits-only number with the request's check algorithm. Standard Luhn
+// uses the precomputed sum, so the digits are not scanned again.
+func passesCheck(cleanedNumber string, sum int, request CardValidationRequest) bool {
+	switch request.Algorithm {
+	case CheckNone:
+		return true
+	case CheckCustom:
+		return request.CustomCheck != nil && request.CustomCheck(cleanedNumber)
+	}
+	if request.CheckDigit == (CheckDigit{}) {
+		return len(cleanedNumber) >= 2 && sum%10 == 0
+	}
+	return request.CheckDigit.Valid(cleanedNumber)
+}
@@ -0,0 +1,100 @@
+package luhn
+
+import "testing"
+
+func TestParseCheckAlgorithm(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    CheckAlgorithm
+		wantErr bool
+	}{
+		{"", CheckLuhn, false},
+		{"luhn", CheckLuhn, false},
+		{" LUHN ", CheckLuhn, false},
+		{"none", CheckNone, false},
+		{"None", CheckNone, false},
+		{"custom", CheckLuhn, true},
+		{"mod11", CheckLuhn, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseCheckAlgorithm(tt.name)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("ParseCheckAlgorithm(%q) = %v, %v; want %v, error %v", tt.name, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestCheckAlgorithmString(t *testing.T) {
+	for _, algorithm := range []CheckAlgorithm{CheckLuhn, CheckNone} {
+		parsed, err := ParseCheckAlgorithm(algorithm.String())
+		if err != nil || parsed != algorithm {
+			t.Errorf("ParseCheckAlgorithm(%q) = %v, %v; want %v", algorithm.String(), parsed, err, algorithm)
+		}
+	}
+}
+
+func TestCheckLuhnIsDefault(t *testing.T) {
+	valid := luhnNumber("4", 16)
+	invalid := valid[:15] + string('0'+(valid[15]-'0'+1)%10)
+
+	for _, algorithm := range []CheckAlgorithm{0, CheckLuhn} {
+		if info := ValidateCard(CardValidationRequest{CardNumber: valid, Algorithm: algorithm}); !info.Valid || !info.ChecksumValid {
+			t.Errorf("%v: %s valid %v, checksum valid %v; want both", algorithm, valid, info.Valid, info.ChecksumValid)
+		}
+		if info := ValidateCard(CardValidationRequest{CardNumber: invalid, Algorithm: algorithm}); info.Valid || info.ChecksumValid {
+			t.Errorf("%v: %s valid %v, checksum valid %v; want neither", algorithm, invalid, info.Valid, info.ChecksumValid)
+		}
+	}
+}
+
+func TestCheckNoneAcceptsAnyLengthValidNumber(t *testing.T) {
+	// Every check digit passes when the length is right for the network
+	body := luhnNumber("4", 16)[:15]
+	for digit := '0'; digit <= '9'; digit++ {
+		number := body + string(digit)
+		info := ValidateCard(CardValidationRequest{CardNumber: number, Algorithm: CheckNone})
+		if !info.Valid || !info.ChecksumValid || info.Network != "Visa" {
+			t.Errorf("%s: valid %v, checksum valid %v, network %q; want a valid Visa", number, info.Valid, info.ChecksumValid, info.Network)
+		}
+	}
+}
+
+func TestCheckNoneKeepsLengthRules(t *testing.T) {
+	// Amex is 15 digits only; skipping the check digit does not relax that
+	number := "3400000000000001"
+	info := ValidateCard(CardValidationRequest{CardNumber: number, Algorithm: CheckNone})
+	if info.Valid || info.LengthValid {
+		t.Errorf("%s: valid %v, length valid %v; want a length failure", number, info.Valid, info.LengthValid)
+	}
+
+	info = ValidateCard(CardValidationRequest{CardNumber: "4111abc", Algorithm: CheckNone})
+	if info.Valid {
+		t.Errorf("4111abc: valid with CheckNone, want invalid")
+	}
+}
+
+func TestCheckCustom(t *testing.T) {
+	evenLast := func(digits string) bool { return (digits[len(digits)-1]-'0')%2 == 0 }
+	body := luhnNumber("4", 16)[:15]
+
+	if info := ValidateCard(CardValidationRequest{CardNumber: body + "2", Algorithm: CheckCustom, CustomCheck: evenLast}); !info.Valid {
+		t.Errorf("%s2: invalid under the custom check, want valid", body)
+	}
+	if info := ValidateCard(CardValidationRequest{CardNumber: body + "3", Algorithm: CheckCustom, CustomCheck: evenLast}); info.Valid {
+		t.Errorf("%s3: valid under the custom check, want invalid", body)
+	}
+
+	// Without a function nothing passes, rather than silently falling back to Luhn
+	if info := ValidateCard(CardValidationRequest{CardNumber: luhnNumber("4", 16), Algorithm: CheckCustom}); info.Valid {
+		t.Errorf("CheckCustom with no function: valid, want invalid")
+	}
+}
+
+func BenchmarkValidateCardCheckNone(b *testing.B) {
+	request := CardValidationRequest{CardNumber: "4111111111111111", Algorithm: CheckNone}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ValidateCard(request)
+	}
+}
@@ -0,0 +1,58 @@
+package luhn
+
+import (
+	"context"
+	"sync"
+)
+
+// DefaultBatchConcurrency is the number of workers used when no limit is given
+const DefaultBatchConcurrency = 8
+
+// ValidateBatch validates cards in parallel using a bounded worker pool.
+// Results are returned in the same order as the requests. If the context is
+// cancelled, workers stop picking up new cards and the context error is returned.
+func ValidateBatch(ctx context.Context, requests []CardValidationRequest, concurrency int) ([]CardInfo, error) {
+	if concurrency <= 0 {
+		concurrency = DefaultBatchConcurrency
+	}
+	if concurrency > len(requests) {
+		concurrency = len(requests)
+	}
+
+	results := make([]CardInfo, len(requests))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range jobs {
+				results[index] = ValidateCleaned(requests[index])
+			}
+		}()
+	}
+
+	// Feed work until done or cancelled
+	var err error
+feed:
+	for index := range requests {
+		// select picks at random when both cases are ready, so check for cancellation first
+		if err = ctx.Err(); err != nil {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+			break feed
+		case jobs <- index:
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
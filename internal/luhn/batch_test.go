@@ -0,0 +1,53 @@
+package luhn
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestValidateBatch(t *testing.T) {
+	requests := []CardValidationRequest{
+		{CardNumber: "4111111111111111"},
+		{CardNumber: "4111111111111112"},
+		{CardNumber: "378282246310005"},
+	}
+
+	results, err := ValidateBatch(context.Background(), requests, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []bool{true, false, true}
+	for i, result := range results {
+		if result.Valid != want[i] {
+			t.Errorf("result %d: valid %v, want %v", i, result.Valid, want[i])
+		}
+	}
+}
+
+func TestValidateBatchCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	requests := make([]CardValidationRequest, 1000)
+	for i := range requests {
+		requests[i] = CardValidationRequest{CardNumber: "4111111111111111"}
+	}
+
+	results, err := ValidateBatch(ctx, requests, 1)
+	if !errors.Is(err, context.Canceled) || results != nil {
+		t.Errorf("ValidateBatch = %d results, %v; want nil, context.Canceled", len(results), err)
+	}
+}
+
+func TestValidateCardContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := ValidateCardContext(ctx, CardValidationRequest{CardNumber: "4111111111111111"}); !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+	if info, err := ValidateCardContext(context.Background(), CardValidationRequest{CardNumber: "4111111111111111"}); err != nil || !info.Valid {
+		t.Errorf("live context: valid %v, err %v", info.Valid, err)
+	}
+}
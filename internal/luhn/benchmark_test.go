@@ -0,0 +1,133 @@
+package luhn
+
+import (
+	"strings"
+	"testing"
+)
+
+// referenceLuhnValid is the original implementation, which cleaned into a builder and then
+// converted to an []int of digits. The single-pass code must agree with it.
+func referenceLuhnValid(input string) bool {
+	var cleaned strings.Builder
+	for _, r := range input {
+		if r >= '0' && r <= '9' {
+			cleaned.WriteRune(r)
+		}
+	}
+	number := cleaned.String()
+	if len(number) < 2 {
+		return false
+	}
+
+	digits := make([]int, len(number))
+	for i, r := range number {
+		digits[i] = int(r - '0')
+	}
+	sum := 0
+	for i := len(digits) - 1; i >= 0; i-- {
+		digit := digits[i]
+		if (len(digits)-1-i)%2 == 1 {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+	}
+	return sum%10 == 0
+}
+
+// benchmarkNumbers are ASCII inputs in the shapes the API sees
+var benchmarkNumbers = []struct {
+	name, number string
+}{
+	{"plain", "4111111111111111"},
+	{"spaced", "4111 1111 1111 1111"},
+	{"dashed", "3782-822463-10005"},
+	{"invalid", "4111111111111112"},
+	{"19 digits", "6011000990139424000"},
+}
+
+func TestLuhnSumMatchesReference(t *testing.T) {
+	inputs := []string{"", "0", "00", "18", "79927398713", "79927398710", "4111-1111 1111.1111", "abc"}
+	for _, bn := range benchmarkNumbers {
+		inputs = append(inputs, bn.number)
+	}
+	for i := 0; i < 500; i++ {
+		inputs = append(inputs, randomDigits(2+i%18))
+	}
+
+	for _, input := range inputs {
+		want := referenceLuhnValid(input)
+		if got := isLuhnValid(Clean(input)); got != want {
+			t.Errorf("isLuhnValid(%q) = %v, reference %v", input, got, want)
+		}
+		if len(Clean(input)) >= 2 {
+			if got := Checksum(input) == 0; got != want {
+				t.Errorf("Checksum(%q) == 0 is %v, reference %v", input, got, want)
+			}
+		}
+	}
+}
+
+func TestLuhnAllocations(t *testing.T) {
+	for _, bn := range benchmarkNumbers {
+		if allocs := testing.AllocsPerRun(100, func() { luhnSum(bn.number) }); allocs != 0 {
+			t.Errorf("%s: luhnSum allocates %.0f times, want 0", bn.name, allocs)
+		}
+		// Only the cleaned copy of the number is allocated
+		if allocs := testing.AllocsPerRun(100, func() { cleanAndSum(bn.number) }); allocs != 1 {
+			t.Errorf("%s: cleanAndSum allocates %.0f times, want 1", bn.name, allocs)
+		}
+		request := CardValidationRequest{CardNumber: bn.number}
+		if allocs := testing.AllocsPerRun(100, func() { ValidateCard(request) }); allocs > 1 {
+			t.Errorf("%s: ValidateCard allocates %.0f times, want at most 1", bn.name, allocs)
+		}
+	}
+}
+
+func BenchmarkLuhnSum(b *testing.B) {
+	for _, bn := range benchmarkNumbers {
+		b.Run(bn.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				luhnSum(bn.number)
+			}
+		})
+	}
+}
+
+func BenchmarkCleanAndSum(b *testing.B) {
+	for _, bn := range benchmarkNumbers {
+		b.Run(bn.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				cleanAndSum(bn.number)
+			}
+		})
+	}
+}
+
+// BenchmarkReferenceLuhn is the allocating baseline for BenchmarkLuhnSum
+func BenchmarkReferenceLuhn(b *testing.B) {
+	for _, bn := range benchmarkNumbers {
+		b.Run(bn.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				referenceLuhnValid(bn.number)
+			}
+		})
+	}
+}
+
+func BenchmarkValidateCardShapes(b *testing.B) {
+	for _, bn := range benchmarkNumbers {
+		request := CardValidationRequest{CardNumber: bn.number}
+		b.Run(bn.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				ValidateCard(request)
+			}
+		})
+	}
+}
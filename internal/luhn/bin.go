@@ -0,0 +1,26 @@
+package luhn
+
+// StandardBINLength is the ISO/IEC 7812 issuer identification number length
+const StandardBINLength = 6
+
+// extendedBINLength is the 8-digit BIN length networks are migrating to
+const extendedBINLength = 8
+
+// extractBIN returns the leading digits that identified the card and their count.
+//
+// The BIN is not cardholder data the way the full PAN is, but together with the
+// last four digits it is the most PCI DSS permits to be shown. Revealing 8 leading
+// digits is only permitted for PANs of 16 or more digits, so shorter cards are
+// capped at 6. Nothing beyond the BIN is ever returned.
+func extractBIN(cardNumber string, binLength int) (string, int) {
+	if binLength > extendedBINLength {
+		binLength = extendedBINLength
+	}
+	if binLength > StandardBINLength && len(cardNumber) < 16 {
+		binLength = StandardBINLength
+	}
+	if len(cardNumber) < MinCardLength || len(cardNumber) < binLength {
+		return "", 0
+	}
+	return cardNumber[:binLength], binLength
+}
@@ -0,0 +1,37 @@
+package luhn
+
+// DigitStep records how the Luhn algorithm treated one digit
+type DigitStep struct {
+	Position int  `json:"position"` // 1-based, from the left
+	Digit    int  `json:"digit"`
+	Doubled  bool `json:"doubled"`
+	Value    int  `json:"value"` // Contribution to the sum after doubling and subtracting 9
+}
+
+// LuhnBreakdown returns the per-digit doubling decisions for a card number and
+// the resulting sum. The sum modulo 10 is 0 for a valid number.
+func LuhnBreakdown(cardNumber string) ([]DigitStep, int) {
+	cleaned := Clean(cardNumber)
+	steps := make([]DigitStep, len(cleaned))
+
+	// Every second digit counting from the rightmost is doubled
+	sum := 0
+	parity := len(cleaned) % 2
+	for i, r := range cleaned {
+		digit := int(r - '0')
+		step := DigitStep{Position: i + 1, Digit: digit, Value: digit}
+
+		if i%2 == parity {
+			step.Doubled = true
+			step.Value = digit * 2
+			if step.Value > 9 {
+				step.Value -= 9
+			}
+		}
+
+		steps[i] = step
+		sum += step.Value
+	}
+
+	return steps, sum
+}
@@ -0,0 +1,51 @@
+package luhn
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLuhnBreakdownKnownNumber(t *testing.T) {
+	// The textbook example: every second digit from the right is doubled
+	steps, sum := LuhnBreakdown("7992 7398 713")
+
+	want := []DigitStep{
+		{Position: 1, Digit: 7, Value: 7},
+		{Position: 2, Digit: 9, Doubled: true, Value: 9},
+		{Position: 3, Digit: 9, Value: 9},
+		{Position: 4, Digit: 2, Doubled: true, Value: 4},
+		{Position: 5, Digit: 7, Value: 7},
+		{Position: 6, Digit: 3, Doubled: true, Value: 6},
+		{Position: 7, Digit: 9, Value: 9},
+		{Position: 8, Digit: 8, Doubled: true, Value: 7},
+		{Position: 9, Digit: 7, Value: 7},
+		{Position: 10, Digit: 1, Doubled: true, Value: 2},
+		{Position: 11, Digit: 3, Value: 3},
+	}
+	if !reflect.DeepEqual(steps, want) {
+		t.Errorf("steps = %+v\nwant    %+v", steps, want)
+	}
+	if sum != 70 {
+		t.Errorf("sum = %d, want 70", sum)
+	}
+}
+
+func TestLuhnBreakdownAgreesWithValidation(t *testing.T) {
+	for _, number := range []string{"4111111111111111", "4111111111111112", "378282246310005", "6011111111111117"} {
+		steps, sum := LuhnBreakdown(number)
+
+		total := 0
+		for _, step := range steps {
+			total += step.Value
+		}
+		if total != sum {
+			t.Errorf("%s: step values sum to %d, reported sum %d", number, total, sum)
+		}
+		if got := sum%10 == 0; got != isLuhnValid(number) {
+			t.Errorf("%s: breakdown valid %v, isLuhnValid %v", number, got, isLuhnValid(number))
+		}
+		if steps[len(steps)-1].Doubled {
+			t.Errorf("%s: check digit was doubled", number)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package luhn
+
+import (
+	"math"
+	"sort"
+)
+
+// NetworkCandidate is a network whose prefix matches a card number, with a
+// confidence score relative to the other candidates
+type NetworkCandidate struct {
+	Network      string  `json:"network"`
+	PrefixLength int     `json:"prefix_length"` // Digits of the most specific matching prefix
+	LengthMatch  bool    `json:"length_match"`  // Whether the card length is valid for the network
+	Score        float64 `json:"score"`         // Normalized so all candidate scores sum to 1
+}
+
+// NetworkCandidates returns every network whose prefix matches the card number,
+// ranked by specificity: longest matching prefix first, then valid length.
+// Each candidate's raw weight is 2 per prefix digit plus 1 for a length match,
+// so prefix length always dominates, and weights are normalized into scores.
+func NetworkCandidates(cardNumber string) []NetworkCandidate {
+	cleaned := Clean(cardNumber)
+
+	// Keep the most specific rule per network
+	best := make(map[string]NetworkCandidate)
+	var order []string
+	for _, rule := range Rules() {
+		if !rule.matchesPrefix(cleaned) {
+			continue
+		}
+		candidate := NetworkCandidate{
+			Network:      rule.Network,
+			PrefixLength: len(rule.PrefixLow),
+			LengthMatch:  rule.matchesLength(len(cleaned)),
+		}
+
+		current, seen := best[rule.Network]
+		if !seen {
+			order = append(order, rule.Network)
+		}
+		if !seen || candidateWeight(candidate) > candidateWeight(current) {
+			best[rule.Network] = candidate
+		}
+	}
+
+	candidates := make([]NetworkCandidate, 0, len(order))
+	total := 0
+	for _, network := range order {
+		candidates = append(candidates, best[network])
+		total += candidateWeight(best[network])
+	}
+
+	for i := range candidates {
+		score := float64(candidateWeight(candidates[i])) / float64(total)
+		candidates[i].Score = math.Round(score*100) / 100
+	}
+
+	// Stable sort keeps rule order for ties
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].PrefixLength != candidates[j].PrefixLength {
+			return candidates[i].PrefixLength > candidates[j].PrefixLength
+		}
+		return candidates[i].LengthMatch && !candidates[j].LengthMatch
+	})
+
+	return candidates
+}
+
+// candidateWeight computes the raw specificity weight of a candidate
+func candidateWeight(candidate NetworkCandidate) int {
+	weight := 2 * candidate.PrefixLength
+	if candidate.LengthMatch {
+		weight++
+	}
+	return weight
+}
@@ -0,0 +1,58 @@
+package luhn
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestNetworkCandidatesAmbiguousNumber(t *testing.T) {
+	// 622126 is inside both Discover's 622126-622925 and UnionPay's 62
+	got := NetworkCandidates(luhnNumber("622126", 16))
+
+	want := []NetworkCandidate{
+		{Network: "Discover", PrefixLength: 6, LengthMatch: true, Score: 0.72},
+		{Network: "UnionPay", PrefixLength: 2, LengthMatch: true, Score: 0.28},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("candidates = %+v, want %+v", got, want)
+	}
+}
+
+func TestNetworkCandidatesPrefixOutranksLength(t *testing.T) {
+	// RuPay's 6521 allows only 16 digits, but its longer prefix still ranks above Discover's 65
+	got := NetworkCandidates(luhnNumber("6521", 17))
+	if len(got) != 2 {
+		t.Fatalf("candidates = %+v, want RuPay and Discover", got)
+	}
+	if got[0].Network != "RuPay" || got[0].LengthMatch {
+		t.Errorf("first candidate = %+v, want RuPay without a length match", got[0])
+	}
+	if got[1].Network != "Discover" || !got[1].LengthMatch {
+		t.Errorf("second candidate = %+v, want Discover with a length match", got[1])
+	}
+}
+
+func TestNetworkCandidatesScoresSumToOne(t *testing.T) {
+	for _, prefix := range []string{"622126", "6521", "6011", "4", "34"} {
+		candidates := NetworkCandidates(luhnNumber(prefix, 16))
+		if len(candidates) == 0 {
+			t.Errorf("%s: no candidates", prefix)
+			continue
+		}
+		total := 0.0
+		for _, candidate := range candidates {
+			total += candidate.Score
+		}
+		// Scores are rounded to two places
+		if math.Abs(total-1) > 0.01*float64(len(candidates)) {
+			t.Errorf("%s: scores sum to %v", prefix, total)
+		}
+	}
+}
+
+func TestNetworkCandidatesUnknown(t *testing.T) {
+	if got := NetworkCandidates(luhnNumber("99", 16)); len(got) != 0 {
+		t.Errorf("candidates = %+v, want none", got)
+	}
+}
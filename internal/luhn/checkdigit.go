@@ -0,0 +1,29 @@
+package luhn
+
+// ResolveCheckDigit computes the check digit for a number whose final digit is
+// unreadable, marked with 'X', 'x', or '?'. Separators are ignored. It returns
+// false if there is not exactly one placeholder or it is not the final digit.
+func ResolveCheckDigit(numberWithPlaceholder string) (int, bool) {
+	digits := make([]byte, 0, len(numberWithPlaceholder))
+	placeholders := 0
+	placeholderLast := false
+
+	for _, r := range numberWithPlaceholder {
+		switch {
+		case r >= '0' && r <= '9':
+			digits = append(digits, byte(r))
+			placeholderLast = false
+		case r == 'X' || r == 'x' || r == '?':
+			placeholders++
+			placeholderLast = true
+		}
+	}
+
+	if placeholders != 1 || !placeholderLast || len(digits) == 0 {
+		return 0, false
+	}
+
+	// With a zero in the check position, the check digit is whatever brings the sum to a multiple of 10
+	sum := luhnSum(string(append(digits, '0')))
+	return (10 - sum%10) % 10, true
+}
@@ -0,0 +1,44 @@
+package luhn
+
+import "testing"
+
+func TestResolveCheckDigit(t *testing.T) {
+	tests := []struct {
+		input string
+		want  int
+		ok    bool
+	}{
+		{"411111111111111X", 1, true},
+		{"411111111111111x", 1, true},
+		{"411111111111111?", 1, true},
+		{"4111 1111 1111 111X", 1, true},
+		{"37828224631000X", 5, true},
+		{"555555555555444?", 4, true},
+		{"4111111111111111", 0, false}, // no placeholder
+		{"41111111111111XX", 0, false}, // two placeholders
+		{"X111111111111111", 0, false}, // placeholder not last
+		{"X", 0, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := ResolveCheckDigit(tt.input)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("ResolveCheckDigit(%q) = %d, %v; want %d, %v", tt.input, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestResolveCheckDigitPassesLuhn(t *testing.T) {
+	for _, prefix := range []string{"4", "34", "6011", "5018", "222100", "35280000000"} {
+		for length := len(prefix) + 1; length <= 19; length++ {
+			body := prefix + "123456789012345678"[:length-len(prefix)-1]
+			digit, ok := ResolveCheckDigit(body + "X")
+			if !ok {
+				t.Fatalf("ResolveCheckDigit(%sX) failed", body)
+			}
+			if number := body + string(rune('0'+digit)); !isLuhnValid(number) {
+				t.Errorf("%s completed with %d fails the Luhn check", body, digit)
+			}
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package luhn
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// moveCheckDigit moves the last digit of number to position, counted from the right
+func moveCheckDigit(number string, position int) string {
+	body, check := number[:len(number)-1], number[len(number)-1:]
+	at := len(body) - position
+	return body[:at] + check + body[at:]
+}
+
+// withLuhnDigit appends the digit that makes body pass standard Luhn
+func withLuhnDigit(body string) string {
+	for d := byte('0'); d <= '9'; d++ {
+		if isLuhnValid(body + string(d)) {
+			return body + string(d)
+		}
+	}
+	return body
+}
+
+// digitString returns n random digits from rng
+func digitString(rng *rand.Rand, n int) string {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte('0' + rng.Intn(10))
+	}
+	return string(b)
+}
+
+func TestCheckDigitModTenLast(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	// The zero value and an explicit mod-10, last-position config are both standard Luhn
+	for i := 0; i < 500; i++ {
+		number := digitString(rng, 12+i%8)
+		want := isLuhnValid(number)
+		for _, config := range []CheckDigit{{}, {Modulus: 10}, {Modulus: 10, Position: 0}} {
+			if got := config.Valid(number); got != want {
+				t.Errorf("%+v.Valid(%s) = %v, isLuhnValid %v", config, number, got, want)
+			}
+		}
+	}
+}
+
+func TestCheckDigitCustomPosition(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, position := range []int{1, 2, 5, 15} {
+		config := CheckDigit{Position: position}
+		for i := 0; i < 100; i++ {
+			number := moveCheckDigit(withLuhnDigit("4"+digitString(rng, 14)), position)
+			if !config.Valid(number) {
+				t.Errorf("position %d: %s invalid, want valid", position, number)
+			}
+
+			// Changing the check digit breaks it
+			at := len(number) - 1 - position
+			changed := number[:at] + string('0'+(number[at]-'0'+1)%10) + number[at+1:]
+			if config.Valid(changed) {
+				t.Errorf("position %d: %s valid after changing the check digit", position, changed)
+			}
+		}
+	}
+}
+
+func TestCheckDigitModulus(t *testing.T) {
+	// 123 weights to 2+2+6 = 10, so mod 7 needs a check digit of 4
+	tests := []struct {
+		config CheckDigit
+		number string
+		want   bool
+	}{
+		{CheckDigit{Modulus: 7}, "1234", true},
+		{CheckDigit{Modulus: 7}, "1235", false},
+		{CheckDigit{Modulus: 7, Position: 3}, "4123", true},
+		{CheckDigit{Modulus: 5}, "1230", true},
+		{CheckDigit{Modulus: 5}, "1235", true},
+		{CheckDigit{Modulus: 5}, "1234", false},
+		{CheckDigit{Modulus: 11}, "1231", true},
+	}
+	for _, tt := range tests {
+		if got := tt.config.Valid(tt.number); got != tt.want {
+			t.Errorf("%+v.Valid(%s) = %v, want %v", tt.config, tt.number, got, tt.want)
+		}
+	}
+}
+
+func TestCheckDigitRejectsBadInput(t *testing.T) {
+	tests := []struct {
+		config CheckDigit
+		number string
+	}{
+		{CheckDigit{Modulus: 1}, "1234"},
+		{CheckDigit{Modulus: -10}, "1234"},
+		{CheckDigit{Position: -1}, "1234"},
+		{CheckDigit{Position: 4}, "1234"},
+		{CheckDigit{Position: 1}, "4"},
+		{CheckDigit{Modulus: 7}, "12a4"},
+		{CheckDigit{}, "0"},
+	}
+	for _, tt := range tests {
+		if tt.config.Valid(tt.number) {
+			t.Errorf("%+v.Valid(%q) = true, want false", tt.config, tt.number)
+		}
+	}
+}
+
+func TestValidateCardWithCheckDigit(t *testing.T) {
+	moved := moveCheckDigit("4000000000000002", 3)
+	if info := ValidateCard(CardValidationRequest{CardNumber: moved}); info.Valid {
+		t.Errorf("%s passes standard Luhn, want it to need the moved check digit", moved)
+	}
+	info := ValidateCard(CardValidationRequest{CardNumber: moved, CheckDigit: CheckDigit{Position: 3}})
+	if !info.Valid || info.Network != "Visa" {
+		t.Errorf("%s with position 3: valid %v, network %q; want a valid Visa", moved, info.Valid, info.Network)
+	}
+
+	// CheckNone still ignores the configured check digit
+	info = ValidateCard(CardValidationRequest{CardNumber: "4000000000000003", Algorithm: CheckNone, CheckDigit: CheckDigit{Position: 3}})
+	if !info.Valid {
+		t.Error("CheckNone with a CheckDigit config: invalid")
+	}
+}
+
+func BenchmarkCheckDigitCustomPosition(b *testing.B) {
+	config := CheckDigit{Modulus: 10, Position: 3}
+	number := moveCheckDigit("4000000000000002", 3)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		config.Valid(number)
+	}
+}
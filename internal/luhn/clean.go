@@ -0,0 +1,75 @@
+package luhn
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Clean normalizes a card number the way the validator does. It only retains decimal
+// digits: separators, whitespace, and anything else are removed. Decimal digits from
+// other scripts, such as Arabic-Indic or fullwidth digits, are converted to ASCII, so
+// "４１１１ ١١١١" becomes "41111111".
+func Clean(input string) string {
+	var cleaned strings.Builder
+	cleaned.Grow(len(input))
+	for _, r := range input {
+		if r >= '0' && r <= '9' {
+			cleaned.WriteRune(r)
+		} else if d, ok := digitValue(r); ok {
+			cleaned.WriteByte(byte('0' + d))
+		}
+	}
+	return cleaned.String()
+}
+
+// cleanAndSum is Clean fused with the Luhn sum, so ValidateCard reads the input once.
+// A digit's doubling depends on its distance from the right end, which is unknown until
+// the end of the input, so both candidate sums are kept and the right one chosen at the end.
+func cleanAndSum(input string) (string, int) {
+	var cleaned strings.Builder
+	cleaned.Grow(len(input))
+	var sums [2]int // sums[p] doubles the digits at even (p=0) or odd (p=1) offsets from the left
+	n := 0
+	for _, r := range input {
+		digit := int(r - '0')
+		if r < '0' || r > '9' {
+			d, ok := digitValue(r)
+			if !ok {
+				continue
+			}
+			digit = d
+		}
+		cleaned.WriteByte(byte('0' + digit))
+
+		doubled := digit * 2
+		if doubled > 9 {
+			doubled -= 9
+		}
+		sums[n%2] += doubled
+		sums[1-n%2] += digit
+		n++
+	}
+
+	// The rightmost digit is never doubled, so the doubled offsets are those with n's parity
+	return cleaned.String(), sums[n%2]
+}
+
+// digitValue returns the value of a non-ASCII decimal digit. Unicode encodes each script's
+// decimal digits as a contiguous run from zero to nine, and every range in the unicode.Digit
+// table is a whole number of such runs, so the value is the offset into the range modulo 10.
+func digitValue(r rune) (int, bool) {
+	if r < 0x80 || !unicode.IsDigit(r) {
+		return 0, false
+	}
+	for _, rng := range unicode.Digit.R16 {
+		if uint32(r) >= uint32(rng.Lo) && uint32(r) <= uint32(rng.Hi) {
+			return int(uint32(r)-uint32(rng.Lo)) % 10, true
+		}
+	}
+	for _, rng := range unicode.Digit.R32 {
+		if uint32(r) >= rng.Lo && uint32(r) <= rng.Hi {
+			return int(uint32(r)-rng.Lo) % 10, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,73 @@
+package luhn
+
+import "testing"
+
+func TestClean(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"digits only", "4111111111111111", "4111111111111111"},
+		{"spaces", "4111 1111 1111 1111", "4111111111111111"},
+		{"mixed separators", " 4111-1111.1111/1111\t\n", "4111111111111111"},
+		{"letters and punctuation", "card#4111(1111)1111*1111!", "4111111111111111"},
+		{"fullwidth digits", "４１１１ １１１１", "41111111"},
+		{"Arabic-Indic digits", "٤١١١ ١١١١", "41111111"},
+		{"Devanagari digits", "४१११", "4111"},
+		{"mixed scripts", "４111-١١١١", "41111111"},
+		{"superscripts are not decimal digits", "4111²", "4111"},
+		{"Roman numerals are not decimal digits", "Ⅳ4111", "4111"},
+		{"no digits", "---  ...", ""},
+		{"empty", "", ""},
+	}
+
+	for _, tt := range tests {
+		if got := Clean(tt.input); got != tt.want {
+			t.Errorf("%s: Clean(%q) = %q, want %q", tt.name, tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestCleanValidatesLikeValidateCard(t *testing.T) {
+	// A number typed in another script validates the same as its ASCII form
+	for _, input := range []string{"٤١١١ ١١١١ ١١١١ ١١١١", "４１１１-１１１１-１１１１-１１１１"} {
+		info := ValidateCard(CardValidationRequest{CardNumber: input})
+		if !info.Valid || info.Network != "Visa" || info.CardLength != 16 {
+			t.Errorf("%q: valid %v, network %q, length %d; want a valid 16-digit Visa", input, info.Valid, info.Network, info.CardLength)
+		}
+	}
+}
+
+// FuzzCleanAndSum checks that the fused pass in ValidateCard agrees with Clean and luhnSum
+func FuzzCleanAndSum(f *testing.F) {
+	for _, seed := range []string{"4111 1111 1111 1111", "٤١١١", "４１１１-１１", "", "abc"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, input string) {
+		cleaned, sum := cleanAndSum(input)
+		if want := Clean(input); cleaned != want {
+			t.Fatalf("cleanAndSum(%q) cleaned = %q, Clean = %q", input, cleaned, want)
+		}
+		if want := luhnSum(cleaned); sum%10 != want%10 {
+			t.Errorf("cleanAndSum(%q) sum = %d, luhnSum = %d", input, sum, want)
+		}
+		if !isDigits(cleaned) && cleaned != "" {
+			t.Errorf("Clean(%q) = %q has non-digits", input, cleaned)
+		}
+	})
+}
+
+func BenchmarkClean(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		Clean("4111 1111-1111 1111")
+	}
+}
+
+func BenchmarkCleanUnicode(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		Clean("٤١١١ ١١١١ ١١١١ ١١١١")
+	}
+}
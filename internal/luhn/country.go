@@ -0,0 +1,35 @@
+package luhn
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// Country holds the ISO 3166-1 codes for a country
+type Country struct {
+	Alpha2  string // e.g. "US"
+	Alpha3  string // e.g. "USA"
+	Numeric string // three digits with leading zeros, e.g. "840" or "004"
+}
+
+// LookupCountry resolves an ISO 3166-1 alpha-2, alpha-3, or numeric code into all three forms.
+// The codes come from the single ISO region table in golang.org/x/text, so they always agree.
+// Deprecated codes such as "UK" are canonicalized; regions that are not countries are rejected.
+func LookupCountry(code string) (Country, bool) {
+	region, err := language.ParseRegion(strings.TrimSpace(code))
+	if err != nil {
+		return Country{}, false
+	}
+	region = region.Canonicalize()
+	if !region.IsCountry() || region.ISO3() == "ZZZ" {
+		return Country{}, false
+	}
+
+	return Country{
+		Alpha2:  region.String(),
+		Alpha3:  region.ISO3(),
+		Numeric: fmt.Sprintf("%03d", region.M49()),
+	}, true
+}
@@ -0,0 +1,66 @@
+package luhn
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLookupCountry(t *testing.T) {
+	tests := []struct {
+		code string
+		want Country
+	}{
+		{"US", Country{"US", "USA", "840"}},
+		{"usa", Country{"US", "USA", "840"}},
+		{"840", Country{"US", "USA", "840"}},
+		{"GB", Country{"GB", "GBR", "826"}},
+		{"UK", Country{"GB", "GBR", "826"}},
+		{"AF", Country{"AF", "AFG", "004"}},
+		{" 004 ", Country{"AF", "AFG", "004"}},
+		{"DE", Country{"DE", "DEU", "276"}},
+	}
+	for _, tt := range tests {
+		got, ok := LookupCountry(tt.code)
+		if !ok || got != tt.want {
+			t.Errorf("LookupCountry(%q) = %+v, %v; want %+v", tt.code, got, ok, tt.want)
+		}
+	}
+
+	// Continents and unknown codes are not countries
+	for _, code := range []string{"", "ZZ", "XX", "150", "Europe", "US1"} {
+		if got, ok := LookupCountry(code); ok {
+			t.Errorf("LookupCountry(%q) = %+v, want no country", code, got)
+		}
+	}
+}
+
+func TestIssuerCountryFromRoutingTable(t *testing.T) {
+	setRoutingTable(t, "411111,411111,acme,US\n"+
+		"555555,555555,acme,GBR\n"+
+		"378282,378282,other,276\n"+
+		"601100,601100,other\n")
+
+	tests := []struct {
+		number string
+		want   Country
+	}{
+		{"4111111111111111", Country{"US", "USA", "840"}},
+		{"5555555555554444", Country{"GB", "GBR", "826"}},
+		{"378282246310005", Country{"DE", "DEU", "276"}},
+		{"6011000990139424", Country{}},
+		{"4222222222222", Country{}},
+	}
+	for _, tt := range tests {
+		info := ValidateCard(CardValidationRequest{CardNumber: tt.number})
+		got := Country{info.IssuerCountryAlpha2, info.IssuerCountryAlpha3, info.IssuerCountryNumeric}
+		if got != tt.want {
+			t.Errorf("%s: issuer country %+v, want %+v", tt.number, got, tt.want)
+		}
+	}
+}
+
+func TestLoadRoutingTableRejectsBadCountry(t *testing.T) {
+	if _, err := LoadRoutingTable(strings.NewReader("411111,411111,acme,Narnia\n")); err == nil {
+		t.Error("expected an error for an unknown country")
+	}
+}
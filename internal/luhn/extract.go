@@ -0,0 +1,60 @@
+package luhn
+
+// ExtractCardNumber finds a card number inside free text such as pasted form contents.
+// Digits may be grouped with single spaces or dashes, as cards are usually written; any
+// other character ends a group, so "4111 1111 1111 1111 exp 09/27" does not absorb the
+// expiry date. Every run of consecutive groups holding MinPANLength to ISOMaxPANLength digits is
+// considered, and the longest one that passes the Luhn check is returned as digits only.
+// Ties go to the earliest run. It returns false if no run passes.
+func ExtractCardNumber(text string) (string, bool) {
+	var best string
+	for _, chain := range digitChains(text) {
+		// Try every window of consecutive groups in the chain
+		for start := range chain {
+			digits := ""
+			for end := start; end < len(chain); end++ {
+				digits += chain[end]
+				if len(digits) > ISOMaxPANLength {
+					break
+				}
+				if len(digits) >= MinPANLength() && len(digits) > len(best) && isLuhnValid(digits) {
+					best = digits
+				}
+			}
+		}
+	}
+	return best, best != ""
+}
+
+// digitChains splits text into chains of digit groups. Groups in a chain are separated by a
+// single space or dash; anything else starts a new chain.
+func digitChains(text string) [][]string {
+	var chains [][]string
+	var chain []string
+	start := -1 // index where the current group began, or -1 between groups
+
+	isDigit := func(i int) bool { return i < len(text) && text[i] >= '0' && text[i] <= '9' }
+
+	for i := 0; i <= len(text); i++ {
+		if isDigit(i) {
+			if start == -1 {
+				start = i
+			}
+			continue
+		}
+
+		if start != -1 {
+			chain = append(chain, text[start:i])
+			start = -1
+			// A lone separator between digits continues the chain
+			if i < len(text) && (text[i] == ' ' || text[i] == '-') && isDigit(i+1) {
+				continue
+			}
+		}
+		if len(chain) > 0 {
+			chains = append(chains, chain)
+			chain = nil
+		}
+	}
+	return chains
+}
@@ -0,0 +1,56 @@
+package luhn
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExtractCardNumber(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"spaced with expiry", "My card is 4111 1111 1111 1111 exp 09/27", "4111111111111111"},
+		{"dashed", "card: 5500-0000-0000-0004, thanks", "5500000000000004"},
+		{"unbroken", "pan=378282246310005;cvv=1234", "378282246310005"},
+		{"expiry before number", "exp 09/27 number 6011 1111 1111 1117", "6011111111111117"},
+		{"phone number noise", "call 555 0100 or use 4111111111111111", "4111111111111111"},
+		{"longest wins", "4111111111111111 and 4000000000000000006", "4000000000000000006"},
+		{"earliest of equal length", "5500000000000004 then 4111111111111111", "5500000000000004"},
+		{"invalid run skipped", "4111111111111112 but 5500000000000004", "5500000000000004"},
+		{"inside an invalid longer run", "4111 1111 1111 1111 1234", "4111111111111111"},
+		{"unicode around digits", "カード番号：4111 1111 1111 1111。", "4111111111111111"},
+	}
+
+	for _, tt := range tests {
+		got, ok := ExtractCardNumber(tt.text)
+		if !ok || got != tt.want {
+			t.Errorf("%s: ExtractCardNumber(%q) = %q, %v; want %q", tt.name, tt.text, got, ok, tt.want)
+		}
+	}
+}
+
+func TestExtractCardNumberNotFound(t *testing.T) {
+	for _, text := range []string{
+		"",
+		"no digits here",
+		"4111 1111 1111 1112",     // fails Luhn
+		"4111  1111 1111 1111",    // a double space splits the groups
+		"order 12345 on 09/27",    // too short
+		"4111/1111/1111/1111",     // slashes are not group separators
+		"41111111111111111111111", // too long, and no window of it passes
+	} {
+		if got, ok := ExtractCardNumber(text); ok || got != "" {
+			t.Errorf("ExtractCardNumber(%q) = %q, %v; want nothing", text, got, ok)
+		}
+	}
+}
+
+func BenchmarkExtractCardNumber(b *testing.B) {
+	text := strings.Repeat("lorem ipsum 12 34 ", 50) + "My card is 4111 1111 1111 1111 exp 09/27"
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ExtractCardNumber(text)
+	}
+}
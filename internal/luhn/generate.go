@@ -0,0 +1,62 @@
+package luhn
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// maxGenerateAttempts bounds the retries for networks whose ranges overlap an earlier rule
+const maxGenerateAttempts = 100
+
+// GenerateForNetwork returns a Luhn-valid number that classifies as the given network,
+// matched case-insensitively against Rules(). The same seed always yields the same number
+// for the same rules, so it is suitable for stable test fixtures. Numbers are never real cards.
+func GenerateForNetwork(network string, seed int64) (string, error) {
+	var rules []NetworkRule
+	for _, rule := range Rules() {
+		if strings.EqualFold(rule.Network, network) {
+			rules = append(rules, rule)
+		}
+	}
+	if len(rules) == 0 {
+		return "", fmt.Errorf("unknown network %q", network)
+	}
+
+	rng := rand.New(rand.NewSource(seed))
+
+	// A number in a rule's range can still belong to an earlier, more specific rule
+	// (for example Discover inside UnionPay's 62), so draw again until it classifies as requested
+	for attempt := 0; attempt < maxGenerateAttempts; attempt++ {
+		rule := rules[rng.Intn(len(rules))]
+		number, ok := generateFromRule(rule, rng)
+		if ok && isLuhnValid(number) && identifyCardNetwork(number) == rule.Network {
+			return number, nil
+		}
+	}
+	return "", fmt.Errorf("could not generate a %s number", network)
+}
+
+// generateFromRule builds a Luhn-valid number with a prefix and length allowed by the rule
+func generateFromRule(rule NetworkRule, rng *rand.Rand) (string, bool) {
+	low, errLow := strconv.Atoi(rule.PrefixLow)
+	high, errHigh := strconv.Atoi(rule.PrefixHigh)
+	if errLow != nil || errHigh != nil || high < low || len(rule.Lengths) == 0 {
+		return "", false
+	}
+	prefix := fmt.Sprintf("%0*d", len(rule.PrefixLow), low+rng.Intn(high-low+1))
+	length := rule.Lengths[rng.Intn(len(rule.Lengths))]
+	if length <= len(prefix) {
+		return "", false
+	}
+
+	var b strings.Builder
+	b.WriteString(prefix)
+	for b.Len() < length-1 {
+		b.WriteByte(byte('0' + rng.Intn(10)))
+	}
+
+	checkDigit, _ := ResolveCheckDigit(b.String() + "X")
+	return b.String() + strconv.Itoa(checkDigit), true
+}
@@ -0,0 +1,57 @@
+package luhn
+
+import "testing"
+
+func TestGenerateForNetworkSameSeedSameNumber(t *testing.T) {
+	for _, rule := range Rules() {
+		for seed := int64(0); seed < 20; seed++ {
+			first, err := GenerateForNetwork(rule.Network, seed)
+			if err != nil {
+				t.Fatalf("GenerateForNetwork(%q, %d): %v", rule.Network, seed, err)
+			}
+			second, err := GenerateForNetwork(rule.Network, seed)
+			if err != nil {
+				t.Fatalf("GenerateForNetwork(%q, %d): %v", rule.Network, seed, err)
+			}
+			if first != second {
+				t.Errorf("GenerateForNetwork(%q, %d) = %s then %s", rule.Network, seed, first, second)
+			}
+		}
+	}
+}
+
+func TestGenerateForNetworkClassifiesAsNetwork(t *testing.T) {
+	for _, rule := range Rules() {
+		for seed := int64(0); seed < 50; seed++ {
+			number, err := GenerateForNetwork(rule.Network, seed)
+			if err != nil {
+				t.Fatalf("GenerateForNetwork(%q, %d): %v", rule.Network, seed, err)
+			}
+			info := ValidateCard(CardValidationRequest{CardNumber: number})
+			if !info.Valid || info.Network != rule.Network {
+				t.Errorf("GenerateForNetwork(%q, %d) = %s: valid %v, network %q",
+					rule.Network, seed, number, info.Valid, info.Network)
+			}
+		}
+	}
+}
+
+func TestGenerateForNetworkIgnoresCase(t *testing.T) {
+	lower, err := GenerateForNetwork("visa", 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	upper, err := GenerateForNetwork("VISA", 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lower != upper {
+		t.Errorf("visa gave %s, VISA gave %s", lower, upper)
+	}
+}
+
+func TestGenerateForNetworkUnknown(t *testing.T) {
+	if _, err := GenerateForNetwork("NotANetwork", 1); err == nil {
+		t.Error("expected an error for an unknown network")
+	}
+}
@@ -0,0 +1,22 @@
+package luhn
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// CardHash returns a hex-encoded HMAC-SHA256 of the cleaned card number under key. The same
+// card always hashes the same way regardless of formatting, so callers can deduplicate cards
+// without storing them, but the hash cannot be reversed or recomputed without the key.
+// It returns "" when key is empty or the number has no digits.
+func CardHash(cardNumber string, key []byte) string {
+	digits := Clean(cardNumber)
+	if len(key) == 0 || digits == "" {
+		return ""
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(digits))
+	return hex.EncodeToString(mac.Sum(nil))
+}
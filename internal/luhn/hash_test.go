@@ -0,0 +1,45 @@
+package luhn
+
+import "testing"
+
+func TestCardHash(t *testing.T) {
+	key := []byte("test-secret")
+
+	// HMAC-SHA256 of the digits under key, as computed by openssl dgst -sha256 -hmac
+	const want = "15e0d54329ac7909503c74553f815c417d2c9f5c0d1302213953524dd01972e3"
+	for _, number := range []string{"4111111111111111", "4111 1111 1111 1111", "4111-1111-1111-1111", "٤١١١١١١١١١١١١١١١"} {
+		if got := CardHash(number, key); got != want {
+			t.Errorf("CardHash(%q) = %s, want %s", number, got, want)
+		}
+	}
+
+	if CardHash("4111111111111111", key) == CardHash("5500000000000004", key) {
+		t.Error("different cards hash the same")
+	}
+	if CardHash("4111111111111111", key) == CardHash("4111111111111111", []byte("other-secret")) {
+		t.Error("hash does not change with the key")
+	}
+
+	// No key or no digits means no hash
+	for _, tt := range []struct {
+		number string
+		key    []byte
+	}{
+		{"4111111111111111", nil},
+		{"4111111111111111", []byte{}},
+		{"", key},
+		{"no digits", key},
+	} {
+		if got := CardHash(tt.number, tt.key); got != "" {
+			t.Errorf("CardHash(%q, %q) = %q, want none", tt.number, tt.key, got)
+		}
+	}
+}
+
+func BenchmarkCardHash(b *testing.B) {
+	key := []byte("test-secret")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		CardHash("4111 1111 1111 1111", key)
+	}
+}
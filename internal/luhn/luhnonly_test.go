@@ -0,0 +1,41 @@
+package luhn
+
+import "testing"
+
+func TestLuhnOnly(t *testing.T) {
+	// A Luhn-valid number from an unrecognized range is Unknown normally and N/A when Luhn-only
+	unrecognized := "9900000000000002"
+	if info := ValidateCard(CardValidationRequest{CardNumber: unrecognized}); info.Network != "Unknown" || info.NetworkStatus != NetworkUnknown {
+		t.Errorf("detected: network %q, status %q; want Unknown", info.Network, info.NetworkStatus)
+	}
+
+	tests := []struct {
+		name    string
+		number  string
+		valid   bool
+		outcome string
+	}{
+		{"unrecognized range", unrecognized, true, OutcomeValid},
+		{"known network", "4111111111111111", true, OutcomeValid},
+		{"odd length", "40000000000000006", true, OutcomeValid},
+		{"failed Luhn", "4111111111111112", false, OutcomeInvalidLuhn},
+	}
+	for _, tt := range tests {
+		// Expiry and CVV are not checked either
+		info := ValidateCard(CardValidationRequest{CardNumber: tt.number, ExpiryDate: "01/20", CVV: "12345", LuhnOnly: true})
+		if info.Network != NetworkNotApplicable || info.NetworkStatus != NetworkSkipped {
+			t.Errorf("%s: network %q, status %q; want %q, %q", tt.name, info.Network, info.NetworkStatus, NetworkNotApplicable, NetworkSkipped)
+		}
+		if info.Valid != tt.valid || info.Outcome != tt.outcome {
+			t.Errorf("%s: valid %v, outcome %q; want %v, %q", tt.name, info.Valid, info.Outcome, tt.valid, tt.outcome)
+		}
+		if info.PrefixNetwork != "" || info.BIN != "" || info.SecurityCodeLocation != "" || info.ExpiryValid || info.CVVValid {
+			t.Errorf("%s: %+v, want only the Luhn result", tt.name, info)
+		}
+	}
+
+	// Length bounds still apply
+	if info := ValidateCard(CardValidationRequest{CardNumber: "4111111", LuhnOnly: true}); info.Valid || info.Outcome == OutcomeValid {
+		t.Errorf("7 digits: valid %v, outcome %q; want invalid", info.Valid, info.Outcome)
+	}
+}
@@ -0,0 +1,72 @@
+// Package luhntest generates card numbers for tests.
+//
+// Numbers are random, so tests should assert on properties (network, Luhn validity)
+// rather than on specific values. They are never real cards.
+package luhntest
+
+import (
+	"math/rand"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/jamesmeyerr/credit-card-validator/internal/luhn"
+)
+
+// RandomValidCard returns a Luhn-valid number that luhn.ValidateCard classifies as the
+// given network, matched case-insensitively against luhn.Rules(). It panics if the
+// network is unknown, since that is a mistake in the calling test.
+func RandomValidCard(network string) string {
+	return fromSeed(network, rand.Int63())
+}
+
+// InvalidCard returns a 16-digit Visa number that fails the Luhn check
+func InvalidCard() string {
+	valid := RandomValidCard("Visa")
+	for len(valid) != 16 {
+		valid = RandomValidCard("Visa")
+	}
+
+	// Any other final digit breaks the checksum
+	last := int(valid[len(valid)-1] - '0')
+	return valid[:len(valid)-1] + strconv.Itoa((last+1+rand.Intn(9))%10)
+}
+
+// CardLike is a card number as a user might type it, for property tests with testing/quick.
+// Generated values are Luhn-valid numbers from a random network, sometimes grouped with
+// spaces or dashes and sometimes padded with whitespace, so cleaning them yields the number.
+type CardLike string
+
+// separators are the group separators CardLike inserts
+var separators = []string{"", " ", "-"}
+
+// Generate implements quick.Generator
+func (CardLike) Generate(r *rand.Rand, size int) reflect.Value {
+	rules := luhn.Rules()
+	number := fromSeed(rules[r.Intn(len(rules))].Network, r.Int63())
+
+	// Group into fours, as printed on most cards
+	sep := separators[r.Intn(len(separators))]
+	var b strings.Builder
+	for i, digit := range number {
+		if i > 0 && i%4 == 0 {
+			b.WriteString(sep)
+		}
+		b.WriteRune(digit)
+	}
+
+	formatted := b.String()
+	if r.Intn(4) == 0 {
+		formatted = " " + formatted + " "
+	}
+	return reflect.ValueOf(CardLike(formatted))
+}
+
+// fromSeed generates a number with luhn.GenerateForNetwork, panicking on failure
+func fromSeed(network string, seed int64) string {
+	number, err := luhn.GenerateForNetwork(network, seed)
+	if err != nil {
+		panic("luhntest: " + err.Error())
+	}
+	return number
+}
@@ -0,0 +1,75 @@
+package luhntest
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/jamesmeyerr/credit-card-validator/internal/luhn"
+)
+
+// networks returns each network in luhn.Rules() once
+func networks() []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, rule := range luhn.Rules() {
+		if !seen[rule.Network] {
+			seen[rule.Network] = true
+			names = append(names, rule.Network)
+		}
+	}
+	return names
+}
+
+func TestRandomValidCardEveryNetwork(t *testing.T) {
+	for _, network := range networks() {
+		for i := 0; i < 50; i++ {
+			number := RandomValidCard(network)
+			info := luhn.ValidateCard(luhn.CardValidationRequest{CardNumber: number})
+			if info.Network != network || !info.Valid || !info.ChecksumValid {
+				t.Fatalf("RandomValidCard(%q) = %s: network %q, valid %v, checksum valid %v",
+					network, number, info.Network, info.Valid, info.ChecksumValid)
+			}
+		}
+	}
+}
+
+func TestRandomValidCardIgnoresCase(t *testing.T) {
+	number := RandomValidCard("american express")
+	if got := luhn.ValidateCard(luhn.CardValidationRequest{CardNumber: number}).Network; got != "American Express" {
+		t.Errorf("RandomValidCard(\"american express\") = %s, classified as %q", number, got)
+	}
+}
+
+func TestRandomValidCardUnknownNetworkPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("RandomValidCard did not panic for an unknown network")
+		}
+	}()
+	RandomValidCard("Not A Network")
+}
+
+func TestInvalidCard(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		number := InvalidCard()
+		info := luhn.ValidateCard(luhn.CardValidationRequest{CardNumber: number})
+		if len(number) != 16 || !strings.HasPrefix(number, "4") {
+			t.Fatalf("InvalidCard() = %s, want a 16-digit Visa number", number)
+		}
+		if info.Valid || info.ChecksumValid {
+			t.Fatalf("InvalidCard() = %s passes the Luhn check", number)
+		}
+	}
+}
+
+func TestCardLikeCleansToValidNumber(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 200; i++ {
+		card := CardLike("").Generate(r, 0).Interface().(CardLike)
+		info := luhn.ValidateCard(luhn.CardValidationRequest{CardNumber: string(card)})
+		if !info.Valid || info.Network == "Unknown" {
+			t.Fatalf("CardLike %q: network %q, valid %v", card, info.Network, info.Valid)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package luhn
+
+import "strings"
+
+// Mask hides a card number for display or logging, revealing the first prefix and last
+// suffix characters and replacing each character between them with an asterisk.
+// If the reveal counts would cover the whole number it is masked completely, so a short
+// or truncated number is never shown in full. Mask never panics; negative counts count as 0.
+func Mask(number string, prefix, suffix int) string {
+	chars := []rune(number)
+	if prefix < 0 {
+		prefix = 0
+	}
+	if suffix < 0 {
+		suffix = 0
+	}
+
+	if prefix+suffix >= len(chars) {
+		return strings.Repeat("*", len(chars))
+	}
+
+	return string(chars[:prefix]) +
+		strings.Repeat("*", len(chars)-prefix-suffix) +
+		string(chars[len(chars)-suffix:])
+}
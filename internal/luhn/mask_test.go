@@ -0,0 +1,54 @@
+package luhn
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMask(t *testing.T) {
+	tests := []struct {
+		number string
+		prefix int
+		suffix int
+		want   string
+	}{
+		{"4111111111111111", 6, 4, "411111******1111"},
+		{"4111111111111111", 0, 4, "************1111"},
+		{"4111111111111111", 4, 0, "4111************"},
+		{"4111111111111111", 0, 0, "****************"},
+		{"378282246310005", 6, 4, "378282*****0005"},
+		{"4111111111111", 6, 4, "411111***1111"},
+		// Reveal counts that would show the whole number mask all of it
+		{"41111111111", 6, 5, "***********"},
+		{"4111", 6, 4, "****"},
+		{"41", 1, 1, "**"},
+		{"", 6, 4, ""},
+		// Negative counts count as 0
+		{"4111111111111111", -1, -3, "****************"},
+		// Characters, not bytes, are counted
+		{"４１１１１１１１", 2, 2, "４１****１１"},
+	}
+
+	for _, tt := range tests {
+		if got := Mask(tt.number, tt.prefix, tt.suffix); got != tt.want {
+			t.Errorf("Mask(%q, %d, %d) = %q, want %q", tt.number, tt.prefix, tt.suffix, got, tt.want)
+		}
+	}
+}
+
+func TestMaskNeverRevealsWholeNumber(t *testing.T) {
+	for length := 0; length <= 25; length++ {
+		number := strings.Repeat("4", length)
+		for prefix := -1; prefix <= 10; prefix++ {
+			for suffix := -1; suffix <= 10; suffix++ {
+				masked := Mask(number, prefix, suffix)
+				if len([]rune(masked)) != length {
+					t.Fatalf("Mask(%d digits, %d, %d) changed the length to %d", length, prefix, suffix, len(masked))
+				}
+				if length > 0 && !strings.Contains(masked, "*") {
+					t.Fatalf("Mask(%d digits, %d, %d) = %q reveals every digit", length, prefix, suffix, masked)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package luhn
+
+import (
+	"sort"
+	"strings"
+)
+
+// NetworkRule maps a range of leading digits to a card network
+type NetworkRule struct {
+	Network    string `json:"network"`
+	PrefixLow  string `json:"prefix_low"`  // Inclusive lower bound of the prefix range
+	PrefixHigh string `json:"prefix_high"` // Inclusive upper bound, same number of digits as PrefixLow
+	Lengths    []int  `json:"lengths"`     // Valid total card lengths
+}
+
+// NetworkRules lists the built-in network rules in match order. They are used unless
+// SetNetworkRules installs a replacement; call Rules for the set currently in effect.
+// Rules with more specific prefixes must come before broader overlapping ones.
+var NetworkRules = []NetworkRule{
+	// Visa: Starts with 4, length 13, 16, or 19
+	{Network: "Visa", PrefixLow: "4", PrefixHigh: "4", Lengths: []int{13, 16, 19}},
+
+	// Mir: Starts with 2200-2204, length 16-19 (matched before the Mastercard 2-series)
+	{Network: "Mir", PrefixLow: "2200", PrefixHigh: "2204", Lengths: lengthRange(16, 19)},
+
+	// Mastercard: Starts with 51-55 or 2221-2720, length 16
+	{Network: "Mastercard", PrefixLow: "51", PrefixHigh: "55", Lengths: []int{16}},
+	{Network: "Mastercard", PrefixLow: "2221", PrefixHigh: "2720", Lengths: []int{16}},
+
+	// American Express: Starts with 34 or 37, length 15
+	{Network: "American Express", PrefixLow: "34", PrefixHigh: "34", Lengths: []int{15}},
+	{Network: "American Express", PrefixLow: "37", PrefixHigh: "37", Lengths: []int{15}},
+
+	// RuPay: Starts with 6521 or 6522, length 16 (must precede Discover's 65)
+	{Network: "RuPay", PrefixLow: "6521", PrefixHigh: "6522", Lengths: []int{16}},
+
+	// Discover: Starts with 6011, 622126-622925, 644-649, 65, length 16-19
+	{Network: "Discover", PrefixLow: "6011", PrefixHigh: "6011", Lengths: lengthRange(16, 19)},
+	{Network: "Discover", PrefixLow: "622126", PrefixHigh: "622925", Lengths: lengthRange(16, 19)},
+	{Network: "Discover", PrefixLow: "644", PrefixHigh: "649", Lengths: lengthRange(16, 19)},
+	{Network: "Discover", PrefixLow: "65", PrefixHigh: "65", Lengths: lengthRange(16, 19)},
+
+	// Troy: Starts with 9792, length 16. Troy's co-branded 65 BINs sit inside Discover's 65
+	// range and no prefix separates them, so 65 stays Discover; deployments with issuer BIN
+	// data can map them to Troy with a NETWORK_RULES file.
+	{Network: "Troy", PrefixLow: "9792", PrefixHigh: "9792", Lengths: []int{16}},
+
+	// JCB: Starts with 3528-3589, length 16-19
+	{Network: "JCB", PrefixLow: "3528", PrefixHigh: "3589", Lengths: lengthRange(16, 19)},
+
+	// UnionPay: Starts with 62 or 81, length 16-19. The specific Discover 62 range above wins;
+	// no other network issues 81 BINs.
+	{Network: "UnionPay", PrefixLow: "62", PrefixHigh: "62", Lengths: lengthRange(16, 19)},
+	{Network: "UnionPay", PrefixLow: "81", PrefixHigh: "81", Lengths: lengthRange(16, 19)},
+
+	// Diners Club: Starts with 300-305, 36, 38-39, length 14-19
+	{Network: "Diners Club", PrefixLow: "300", PrefixHigh: "305", Lengths: lengthRange(14, 19)},
+	{Network: "Diners Club", PrefixLow: "36", PrefixHigh: "36", Lengths: lengthRange(14, 19)},
+	{Network: "Diners Club", PrefixLow: "38", PrefixHigh: "39", Lengths: lengthRange(14, 19)},
+
+	// RuPay: Starts with 60, length 16
+	{Network: "RuPay", PrefixLow: "60", PrefixHigh: "60", Lengths: []int{16}},
+
+	// Maestro: Length 12-19. The well-known prefixes 5018, 5020, 5038, 5893, 6304, 6759,
+	// 6761-6763, and the UK ranges 676770 and 676774 are listed before the broader 50, 56-58,
+	// 6390, and 67 ranges so the most specific prefix is reported. None of these overlap the
+	// Mastercard 51-55 or Discover ranges above, so ordering relative to them doesn't matter.
+	{Network: "Maestro", PrefixLow: "676770", PrefixHigh: "676770", Lengths: lengthRange(12, 19)},
+	{Network: "Maestro", PrefixLow: "676774", PrefixHigh: "676774", Lengths: lengthRange(12, 19)},
+	{Network: "Maestro", PrefixLow: "5018", PrefixHigh: "5018", Lengths: lengthRange(12, 19)},
+	{Network: "Maestro", PrefixLow: "5020", PrefixHigh: "5020", Lengths: lengthRange(12, 19)},
+	{Network: "Maestro", PrefixLow: "5038", PrefixHigh: "5038", Lengths: lengthRange(12, 19)},
+	{Network: "Maestro", PrefixLow: "5893", PrefixHigh: "5893", Lengths: lengthRange(12, 19)},
+	{Network: "Maestro", PrefixLow: "6304", PrefixHigh: "6304", Lengths: lengthRange(12, 19)},
+	{Network: "Maestro", PrefixLow: "6759", PrefixHigh: "6759", Lengths: lengthRange(12, 19)},
+	{Network: "Maestro", PrefixLow: "6761", PrefixHigh: "6763", Lengths: lengthRange(12, 19)},
+	{Network: "Maestro", PrefixLow: "6390", PrefixHigh: "6390", Lengths: lengthRange(12, 19)},
+	{Network: "Maestro", PrefixLow: "50", PrefixHigh: "50", Lengths: lengthRange(12, 19)},
+	{Network: "Maestro", PrefixLow: "56", PrefixHigh: "58", Lengths: lengthRange(12, 19)},
+	{Network: "Maestro", PrefixLow: "67", PrefixHigh: "67", Lengths: lengthRange(12, 19)},
+}
+
+// lengthRange returns every length from min to max inclusive
+func lengthRange(min, max int) []int {
+	lengths := make([]int, 0, max-min+1)
+	for length := min; length <= max; length++ {
+		lengths = append(lengths, length)
+	}
+	return lengths
+}
+
+// matchesPrefix checks if the card number starts with a prefix in the rule's range
+func (r NetworkRule) matchesPrefix(cardNumber string) bool {
+	if len(cardNumber) < len(r.PrefixLow) {
+		return false
+	}
+	// Prefixes are equal-length digit strings, so lexical order matches numeric order
+	prefix := cardNumber[:len(r.PrefixLow)]
+	return prefix >= r.PrefixLow && prefix <= r.PrefixHigh
+}
+
+// matchesLength checks if the card length is valid for the rule
+func (r NetworkRule) matchesLength(length int) bool {
+	for _, valid := range r.Lengths {
+		if length == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// NetworkLengths returns the sorted valid card lengths for a network across all its rules
+func NetworkLengths(network string) []int {
+	seen := make(map[int]bool)
+	var lengths []int
+	for _, rule := range Rules() {
+		if !strings.EqualFold(rule.Network, network) {
+			continue
+		}
+		for _, length := range rule.Lengths {
+			if !seen[length] {
+				seen[length] = true
+				lengths = append(lengths, length)
+			}
+		}
+	}
+	sort.Ints(lengths)
+	return lengths
+}
+
+// NetworkFromBIN identifies the network from the leading digits (typically the
+// 6-digit BIN) alone, ignoring card length. It returns "Unknown" if no prefix matches.
+func NetworkFromBIN(bin string) string {
+	cleaned := Clean(bin)
+	for _, rule := range Rules() {
+		if rule.matchesPrefix(cleaned) {
+			return rule.Network
+		}
+	}
+	return "Unknown"
+}
+
+// String describes the rule, e.g. "Discover 622126-622925"
+func (r NetworkRule) String() string {
+	if r.PrefixLow == r.PrefixHigh {
+		return r.Network + " " + r.PrefixLow
+	}
+	return r.Network + " " + r.PrefixLow + "-" + r.PrefixHigh
+}
+
+// MatchRule returns the rule that classified the card number, if any
+func MatchRule(cardNumber string) (NetworkRule, bool) {
+	cleaned := Clean(cardNumber)
+	for _, rule := range Rules() {
+		if rule.matchesPrefix(cleaned) && rule.matchesLength(len(cleaned)) {
+			return rule, true
+		}
+	}
+	return NetworkRule{}, false
+}
+
+// brandSlugs maps network names to stable lowercase identifiers suitable for asset filenames
+var brandSlugs = map[string]string{
+	"Visa":             "visa",
+	"Mastercard":       "mastercard",
+	"American Express": "amex",
+	"Discover":         "discover",
+	"Diners Club":      "diners",
+	"JCB":              "jcb",
+	"UnionPay":         "unionpay",
+	"Maestro":          "maestro",
+	"Mir":              "mir",
+	"RuPay":            "rupay",
+	"Troy":             "troy",
+	"Unknown":          "unknown",
+}
+
+// BrandSlug returns the asset slug for a network name, e.g. "amex" for "American Express".
+// Networks without a slug return "unknown".
+func BrandSlug(network string) string {
+	if slug, ok := brandSlugs[network]; ok {
+		return slug
+	}
+	return "unknown"
+}
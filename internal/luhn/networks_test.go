@@ -0,0 +1,146 @@
+package luhn
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNetworkFromBIN(t *testing.T) {
+	tests := []struct {
+		bin  string
+		want string
+	}{
+		{"411111", "Visa"},
+		{"555555", "Mastercard"},
+		{"222100", "Mastercard"},
+		{"272099", "Mastercard"},
+		{"378282", "American Express"},
+		{"601100", "Discover"},
+		{"622126", "Discover"},
+		{"353011", "JCB"},
+		{"620000", "UnionPay"},
+		{"305693", "Diners Club"},
+		{"220000", "Mir"},
+		{"979200", "Troy"},
+		{"676770", "Maestro"},
+		{"6011 00", "Discover"},
+		{"990000", "Unknown"},
+		{"", "Unknown"},
+	}
+
+	for _, tt := range tests {
+		if got := NetworkFromBIN(tt.bin); got != tt.want {
+			t.Errorf("NetworkFromBIN(%q) = %q, want %q", tt.bin, got, tt.want)
+		}
+	}
+}
+
+func TestTroyAndMir(t *testing.T) {
+	tests := []struct {
+		number string
+		want   string
+		valid  bool
+	}{
+		{luhnNumber("9792", 16), "Troy", true},
+		{luhnNumber("979212", 16), "Troy", true},
+		{luhnNumber("2200", 16), "Mir", true},
+		{luhnNumber("2204", 19), "Mir", true},
+		{luhnNumber("2202", 17), "Mir", true},
+		{luhnNumber("9792", 19), "Unknown", false}, // Troy is 16 digits only
+		{luhnNumber("6500", 16), "Discover", true}, // Troy's co-branded 65 BINs are left to Discover
+		{luhnNumber("2205", 16), "Unknown", true},  // neither Mir nor Mastercard 2221-2720, but Luhn-valid
+	}
+
+	for _, tt := range tests {
+		info := ValidateCard(CardValidationRequest{CardNumber: tt.number})
+		if info.Network != tt.want || info.Valid != tt.valid {
+			t.Errorf("%s: network %q, valid %v; want %q, %v", tt.number, info.Network, info.Valid, tt.want, tt.valid)
+		}
+	}
+}
+
+func TestMaestroLengths(t *testing.T) {
+	prefixes := []string{"5018", "5020", "5038", "5893", "6304", "6759", "6761", "6763", "676770", "676774", "6390", "50", "56", "58", "67"}
+
+	for _, prefix := range prefixes {
+		for _, length := range []int{11, 12, 19, 20} {
+			want := length == 12 || length == 19
+			number := luhnNumber(prefix, length)
+			info := ValidateCard(CardValidationRequest{CardNumber: number})
+
+			if got := info.Network == "Maestro" && info.Valid; got != want {
+				t.Errorf("%d-digit %s %s: network %q, valid %v; want valid Maestro %v",
+					length, prefix, number, info.Network, info.Valid, want)
+			}
+		}
+	}
+}
+
+func TestMaestroReportsMostSpecificPrefix(t *testing.T) {
+	tests := []struct {
+		prefix string
+		want   string
+	}{
+		{"676770", "676770"},
+		{"6759", "6759"},
+		{"6780", "67"},
+		{"5018", "5018"},
+		{"5019", "50"},
+	}
+
+	for _, tt := range tests {
+		rule, ok := MatchRule(luhnNumber(tt.prefix, 16))
+		if !ok || rule.Network != "Maestro" || rule.PrefixLow != tt.want {
+			t.Errorf("%s: matched %v, want the Maestro %s rule", tt.prefix, rule, tt.want)
+		}
+	}
+
+	// Maestro's 5x ranges leave Mastercard's 51-55 alone
+	for _, prefix := range []string{"51", "55"} {
+		if got := NetworkFromBIN(prefix + "0000"); got != "Mastercard" {
+			t.Errorf("NetworkFromBIN(%s0000) = %q, want Mastercard", prefix, got)
+		}
+	}
+}
+
+func TestBrandSlugForEveryNetwork(t *testing.T) {
+	seen := make(map[string]string)
+	for _, rule := range NetworkRules {
+		slug := BrandSlug(rule.Network)
+		if slug == "unknown" {
+			t.Errorf("%s has no brand slug", rule.Network)
+			continue
+		}
+		if slug != strings.ToLower(slug) || strings.ContainsAny(slug, " /.") {
+			t.Errorf("%s slug %q is not a lowercase filename", rule.Network, slug)
+		}
+		if other, ok := seen[slug]; ok && other != rule.Network {
+			t.Errorf("%s and %s share slug %q", rule.Network, other, slug)
+		}
+		seen[slug] = rule.Network
+	}
+
+	for _, network := range []string{"Unknown", NetworkNotApplicable, "", "Made Up"} {
+		if got := BrandSlug(network); got != "unknown" {
+			t.Errorf("BrandSlug(%q) = %q, want unknown", network, got)
+		}
+	}
+}
+
+func TestCardInfoBrandSlug(t *testing.T) {
+	tests := []struct {
+		number string
+		want   string
+	}{
+		{"378282246310005", "amex"},
+		{"4111111111111111", "visa"},
+		{"5555555555554444", "mastercard"},
+		{"30569309025904", "diners"},
+		{luhnNumber("99", 16), "unknown"},
+	}
+	for _, tt := range tests {
+		if got := ValidateCard(CardValidationRequest{CardNumber: tt.number}).BrandSlug; got != tt.want {
+			t.Errorf("%s: brand slug %q, want %q", tt.number, got, tt.want)
+		}
+	}
+}
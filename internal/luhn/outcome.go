@@ -0,0 +1,45 @@
+package luhn
+
+// Outcome values summarize a validation for metrics and dashboards.
+// When several apply, the first in this priority order wins:
+//
+//  1. blocked                 - the card is on a blocklist (reserved; no blocklist is configured yet)
+//  2. pan_length_out_of_range - the number is shorter than any real card or longer than ISO/IEC 7812 allows
+//  3. invalid_luhn            - the number failed the Luhn check or has an invalid length
+//  4. unknown_network         - the number is valid but matches no known network
+//  5. expired                 - an expiry date was provided and is expired or malformed
+//  6. missing_cvv             - no CVV was provided but the network requires one; see ValidationConfig
+//  7. invalid_cvv             - a CVV was provided with the wrong length for the network
+//  8. valid                   - every provided check passed
+const (
+	OutcomeBlocked             = "blocked"
+	OutcomePANLengthOutOfRange = "pan_length_out_of_range"
+	OutcomeInvalidLuhn         = "invalid_luhn"
+	OutcomeUnknownNetwork      = "unknown_network"
+	OutcomeExpired             = "expired"
+	OutcomeMissingCVV          = "missing_cvv"
+	OutcomeInvalidCVV          = "invalid_cvv"
+	OutcomeValid               = "valid"
+)
+
+// determineOutcome picks the highest-priority outcome for a validation result
+func determineOutcome(request CardValidationRequest, result CardInfo) string {
+	switch {
+	case !InLengthRange(result.CardLength):
+		return OutcomePANLengthOutOfRange
+	case !result.Valid:
+		return OutcomeInvalidLuhn
+	case result.Network == "Unknown":
+		return OutcomeUnknownNetwork
+	case request.LuhnOnly:
+		// Expiry and CVV are not checked for Luhn-only requests
+		return OutcomeValid
+	case request.ExpiryDate != "" && !result.ExpiryValid:
+		return OutcomeExpired
+	case result.CVVMissing:
+		return OutcomeMissingCVV
+	case result.CVVProvided && !result.CVVValid:
+		return OutcomeInvalidCVV
+	}
+	return OutcomeValid
+}
@@ -0,0 +1,49 @@
+package luhn
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOutcome(t *testing.T) {
+	future := time.Now().AddDate(2, 0, 0).Format("01/06")
+
+	tests := []struct {
+		name    string
+		request CardValidationRequest
+		want    string
+	}{
+		{"valid", CardValidationRequest{CardNumber: "4111111111111111"}, OutcomeValid},
+		{"valid with expiry and CVV", CardValidationRequest{CardNumber: "4111111111111111", ExpiryDate: future, CVV: "123"}, OutcomeValid},
+		{"too short", CardValidationRequest{CardNumber: "4111111"}, OutcomePANLengthOutOfRange},
+		{"too long", CardValidationRequest{CardNumber: luhnNumber("4", 20)}, OutcomePANLengthOutOfRange},
+		{"failed Luhn", CardValidationRequest{CardNumber: "4111111111111112"}, OutcomeInvalidLuhn},
+		{"unknown network", CardValidationRequest{CardNumber: luhnNumber("99", 16)}, OutcomeUnknownNetwork},
+		{"expired", CardValidationRequest{CardNumber: "4111111111111111", ExpiryDate: "01/20"}, OutcomeExpired},
+		{"malformed expiry", CardValidationRequest{CardNumber: "4111111111111111", ExpiryDate: "13/30"}, OutcomeExpired},
+		{"invalid CVV", CardValidationRequest{CardNumber: "4111111111111111", CVV: "1234"}, OutcomeInvalidCVV},
+		// Priority: an expired card with a bad CVV reports expired, and a failed Luhn check hides both
+		{"expired and invalid CVV", CardValidationRequest{CardNumber: "4111111111111111", ExpiryDate: "01/20", CVV: "1234"}, OutcomeExpired},
+		{"failed Luhn and expired", CardValidationRequest{CardNumber: "4111111111111112", ExpiryDate: "01/20"}, OutcomeInvalidLuhn},
+		{"Luhn only ignores expiry", CardValidationRequest{CardNumber: "4111111111111111", ExpiryDate: "01/20", LuhnOnly: true}, OutcomeValid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ValidateCard(tt.request).Outcome; got != tt.want {
+				t.Errorf("outcome = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOutcomeMissingCVV(t *testing.T) {
+	config := ValidationConfig{CVVRequiredNetworks: []string{"visa"}}
+
+	if got := ValidateCard(CardValidationRequest{CardNumber: "4111111111111111", Config: config}).Outcome; got != OutcomeMissingCVV {
+		t.Errorf("Visa without CVV: outcome = %q, want %q", got, OutcomeMissingCVV)
+	}
+	if got := ValidateCard(CardValidationRequest{CardNumber: "5555555555554444", Config: config}).Outcome; got != OutcomeValid {
+		t.Errorf("Mastercard without CVV: outcome = %q, want %q", got, OutcomeValid)
+	}
+}
@@ -0,0 +1,91 @@
+package luhn_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"testing/quick"
+	"unicode/utf8"
+
+	"github.com/jamesmeyerr/credit-card-validator/internal/luhn"
+	"github.com/jamesmeyerr/credit-card-validator/internal/luhn/luhntest"
+)
+
+// quickConfig runs enough cases to cover every network many times over
+var quickConfig = &quick.Config{MaxCount: 2000}
+
+func TestPropertyCleanIsIdempotent(t *testing.T) {
+	property := func(s string) bool {
+		once := luhn.Clean(s)
+		return luhn.Clean(once) == once
+	}
+	if err := quick.Check(property, quickConfig); err != nil {
+		t.Error(err)
+	}
+
+	cardLike := func(card luhntest.CardLike) bool {
+		once := luhn.Clean(string(card))
+		return luhn.Clean(once) == once && !strings.ContainsAny(once, " -")
+	}
+	if err := quick.Check(cardLike, quickConfig); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPropertyGeneratedNumbersAreValid(t *testing.T) {
+	property := func(card luhntest.CardLike) bool {
+		info := luhn.ValidateCard(luhn.CardValidationRequest{CardNumber: string(card)})
+		return info.ChecksumValid && info.LengthValid && info.Valid && info.Network != "Unknown"
+	}
+	if err := quick.Check(property, quickConfig); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPropertyMaskKeepsLength(t *testing.T) {
+	property := func(s string, prefix, suffix int8) bool {
+		masked := luhn.Mask(s, int(prefix), int(suffix))
+		return utf8.RuneCountInString(masked) == utf8.RuneCountInString(s)
+	}
+	if err := quick.Check(property, quickConfig); err != nil {
+		t.Error(err)
+	}
+
+	// A card number keeps its first six and last four digits and hides the rest
+	cardLike := func(card luhntest.CardLike) bool {
+		digits := luhn.Clean(string(card))
+		masked := luhn.Mask(digits, 6, 4)
+		hidden := masked[6 : len(masked)-4]
+		return len(masked) == len(digits) &&
+			masked[:6] == digits[:6] && masked[len(masked)-4:] == digits[len(digits)-4:] &&
+			hidden == strings.Repeat("*", len(hidden))
+	}
+	if err := quick.Check(cardLike, quickConfig); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPropertyDetectionIsStable(t *testing.T) {
+	// Formatting, repetition, and the cleaned fast path all give the same result
+	property := func(card luhntest.CardLike) bool {
+		formatted := luhn.ValidateCard(luhn.CardValidationRequest{CardNumber: string(card)})
+		again := luhn.ValidateCard(luhn.CardValidationRequest{CardNumber: string(card)})
+		cleaned := luhn.ValidateCleaned(luhn.CardValidationRequest{CardNumber: luhn.Clean(string(card))})
+		return reflect.DeepEqual(formatted, again) && reflect.DeepEqual(formatted, cleaned)
+	}
+	if err := quick.Check(property, quickConfig); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPropertyBreakingCheckDigitFails(t *testing.T) {
+	property := func(card luhntest.CardLike, delta uint8) bool {
+		digits := luhn.Clean(string(card))
+		last := digits[len(digits)-1] - '0'
+		changed := digits[:len(digits)-1] + string('0'+(last+1+delta%9)%10)
+		return !luhn.ValidateCard(luhn.CardValidationRequest{CardNumber: changed}).ChecksumValid
+	}
+	if err := quick.Check(property, quickConfig); err != nil {
+		t.Error(err)
+	}
+}
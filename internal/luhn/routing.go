@@ -0,0 +1,189 @@
+package luhn
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// RoutingRange maps an inclusive range of card prefixes to a processor and, optionally,
+// the issuer's country
+type RoutingRange struct {
+	Low       uint64
+	High      uint64
+	Processor string
+	Country   Country // zero value when the table has no country for the range
+}
+
+// RoutingTable finds the processor responsible for a card from its leading digits.
+// All ranges share the same prefix width of 6 or 8 digits. When ranges overlap, the narrowest wins.
+type RoutingTable struct {
+	width   int            // number of leading digits compared
+	ranges  []RoutingRange // sorted by Low
+	maxHigh []uint64       // maxHigh[i] is the largest High in ranges[0..i]
+}
+
+// routingTable is the table used by RouteCard and ValidateCard
+var routingTable atomic.Pointer[RoutingTable]
+
+// LoadRoutingTable reads a routing table from CSV rows of low,high,processor[,country].
+// Prefixes must all be 6 or all be 8 digits, the two BIN lengths.
+// The optional country may be an ISO 3166-1 alpha-2, alpha-3, or numeric code.
+func LoadRoutingTable(r io.Reader) (*RoutingTable, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+
+	table := &RoutingTable{}
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(record) != 3 && len(record) != 4 {
+			return nil, fmt.Errorf("line %d: expected 3 or 4 fields, got %d", line, len(record))
+		}
+
+		lowStr, highStr, processor := strings.TrimSpace(record[0]), strings.TrimSpace(record[1]), strings.TrimSpace(record[2])
+
+		// Allow a header row
+		if line == 1 && strings.EqualFold(lowStr, "low") {
+			continue
+		}
+
+		if len(lowStr) != len(highStr) || lowStr == "" {
+			return nil, fmt.Errorf("line %d: low and high must have the same number of digits", line)
+		}
+		if table.width == 0 {
+			// The width is also the reported BIN length, so it must be a length a BIN can have
+			if len(lowStr) != StandardBINLength && len(lowStr) != extendedBINLength {
+				return nil, fmt.Errorf("line %d: prefixes must have %d or %d digits, got %d",
+					line, StandardBINLength, extendedBINLength, len(lowStr))
+			}
+			table.width = len(lowStr)
+		} else if len(lowStr) != table.width {
+			return nil, fmt.Errorf("line %d: expected %d-digit prefixes, got %d", line, table.width, len(lowStr))
+		}
+
+		low, err := strconv.ParseUint(lowStr, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid low %q", line, lowStr)
+		}
+		high, err := strconv.ParseUint(highStr, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid high %q", line, highStr)
+		}
+		if low > high {
+			return nil, fmt.Errorf("line %d: low is greater than high", line)
+		}
+		if processor == "" {
+			return nil, fmt.Errorf("line %d: processor is required", line)
+		}
+
+		var country Country
+		if len(record) == 4 && strings.TrimSpace(record[3]) != "" {
+			var ok bool
+			if country, ok = LookupCountry(record[3]); !ok {
+				return nil, fmt.Errorf("line %d: invalid country %q", line, strings.TrimSpace(record[3]))
+			}
+		}
+
+		table.ranges = append(table.ranges, RoutingRange{Low: low, High: high, Processor: processor, Country: country})
+	}
+
+	if len(table.ranges) == 0 {
+		return nil, errors.New("routing table is empty")
+	}
+
+	sort.SliceStable(table.ranges, func(i, j int) bool {
+		return table.ranges[i].Low < table.ranges[j].Low
+	})
+
+	table.maxHigh = make([]uint64, len(table.ranges))
+	for i, rng := range table.ranges {
+		table.maxHigh[i] = rng.High
+		if i > 0 && table.maxHigh[i-1] > rng.High {
+			table.maxHigh[i] = table.maxHigh[i-1]
+		}
+	}
+
+	return table, nil
+}
+
+// LoadRoutingTableFile reads a routing table from a CSV file
+func LoadRoutingTableFile(path string) (*RoutingTable, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return LoadRoutingTable(file)
+}
+
+// SetRoutingTable installs the table used by RouteCard and ValidateCard; nil disables routing
+func SetRoutingTable(table *RoutingTable) {
+	routingTable.Store(table)
+}
+
+// Route finds the processor for a card number
+func (t *RoutingTable) Route(number string) (string, bool) {
+	rng, ok := t.lookup(number)
+	if !ok {
+		return "", false
+	}
+	return rng.Processor, true
+}
+
+// lookup finds the narrowest range containing the card number's prefix
+func (t *RoutingTable) lookup(number string) (RoutingRange, bool) {
+	cleaned := Clean(number)
+	if len(cleaned) < t.width {
+		return RoutingRange{}, false
+	}
+	prefix, err := strconv.ParseUint(cleaned[:t.width], 10, 64)
+	if err != nil {
+		return RoutingRange{}, false
+	}
+
+	// Binary search for the last range starting at or before the prefix
+	idx := sort.Search(len(t.ranges), func(i int) bool {
+		return t.ranges[i].Low > prefix
+	}) - 1
+
+	// Walk back through ranges that could still contain the prefix, keeping the narrowest
+	best := -1
+	for i := idx; i >= 0 && t.maxHigh[i] >= prefix; i-- {
+		rng := t.ranges[i]
+		if rng.High < prefix {
+			continue
+		}
+		if best == -1 || rng.High-rng.Low < t.ranges[best].High-t.ranges[best].Low {
+			best = i
+		}
+	}
+
+	if best == -1 {
+		return RoutingRange{}, false
+	}
+	return t.ranges[best], true
+}
+
+// RouteCard finds the processor for a card number using the installed routing table
+func RouteCard(number string) (processor string, ok bool) {
+	table := routingTable.Load()
+	if table == nil {
+		return "", false
+	}
+	return table.Route(number)
+}
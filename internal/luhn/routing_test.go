@@ -0,0 +1,158 @@
+package luhn
+
+import (
+	"strings"
+	"testing"
+)
+
+// setRoutingTable installs a table for one test
+func setRoutingTable(t *testing.T, csv string) {
+	t.Helper()
+	table, err := LoadRoutingTable(strings.NewReader(csv))
+	if err != nil {
+		t.Fatalf("LoadRoutingTable: %v", err)
+	}
+	SetRoutingTable(table)
+	t.Cleanup(func() { SetRoutingTable(nil) })
+}
+
+func TestLoadRoutingTableWidth(t *testing.T) {
+	tests := []struct {
+		name    string
+		csv     string
+		wantErr bool
+	}{
+		{"6 digits", "411111,411199,acme\n", false},
+		{"8 digits", "41111100,41119999,acme\n", false},
+		{"header row", "low,high,processor\n411111,411199,acme\n", false},
+		{"4 digits", "4111,4119,acme\n", true},
+		{"7 digits", "4111110,4111199,acme\n", true},
+		{"9 digits", "411111000,411119999,acme\n", true},
+		{"mixed widths", "411111,411199,acme\n41111100,41119999,other\n", true},
+		{"low and high differ", "411111,4111999,acme\n", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadRoutingTable(strings.NewReader(tt.csv))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRouteNarrowestRangeWins(t *testing.T) {
+	table, err := LoadRoutingTable(strings.NewReader("400000,499999,wide\n411100,411199,narrow\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		number string
+		want   string
+		ok     bool
+	}{
+		{"4111111111111111", "narrow", true},
+		{"4222222222222", "wide", true},
+		{"5500000000000004", "", false},
+		{"41", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := table.Route(tt.number)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("Route(%s) = %q, %v; want %q, %v", tt.number, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestValidateCardBINLengthFollowsRoutingTable(t *testing.T) {
+	setRoutingTable(t, "41111111,41111111,acme\n")
+
+	info := ValidateCard(CardValidationRequest{CardNumber: "4111111111111111"})
+	if info.BINLength != 8 || info.BIN != "41111111" {
+		t.Errorf("BIN = %q (%d), want 41111111 (8)", info.BIN, info.BINLength)
+	}
+
+	// 8-digit BINs are never shown for cards shorter than 16 digits
+	info = ValidateCard(CardValidationRequest{CardNumber: "4111111111111"})
+	if info.BINLength != StandardBINLength {
+		t.Errorf("13-digit card BIN length = %d, want %d", info.BINLength, StandardBINLength)
+	}
+}
+
+func TestRouteOverlappingRanges(t *testing.T) {
+	table, err := LoadRoutingTable(strings.NewReader(
+		"400000,499999,wide\n" +
+			"411000,411499,left\n" +
+			"411400,411999,right\n" +
+			"420000,420099,other\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		prefix string
+		want   string
+	}{
+		{"411000", "left"},
+		{"411399", "left"},
+		{"411400", "left"}, // in both; left is narrower
+		{"411499", "left"},
+		{"411500", "right"},
+		{"411999", "right"},
+		{"412000", "wide"},
+		{"420050", "other"},
+		// Past a later, narrower range that has already ended, the wide range still applies
+		{"430000", "wide"},
+		{"499999", "wide"},
+	}
+	for _, tt := range tests {
+		number := tt.prefix + "0000000000"
+		if got, ok := table.Route(number); !ok || got != tt.want {
+			t.Errorf("Route(%s) = %q, %v; want %q", number, got, ok, tt.want)
+		}
+	}
+}
+
+func TestRouteAdjacentRanges(t *testing.T) {
+	table, err := LoadRoutingTable(strings.NewReader(
+		"411200,411299,second\n" +
+			"411100,411199,first\n" +
+			"411300,411300,single\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		prefix string
+		want   string
+		ok     bool
+	}{
+		{"411099", "", false},
+		{"411100", "first", true},
+		{"411199", "first", true},
+		{"411200", "second", true},
+		{"411299", "second", true},
+		{"411300", "single", true},
+		{"411301", "", false},
+	}
+	for _, tt := range tests {
+		number := tt.prefix + "0000000000"
+		got, ok := table.Route(number)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("Route(%s) = %q, %v; want %q, %v", number, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestRouteCard(t *testing.T) {
+	if _, ok := RouteCard("4111111111111111"); ok {
+		t.Fatal("RouteCard matched with no table loaded")
+	}
+
+	setRoutingTable(t, "411100,411199,acme\n")
+	if got, ok := RouteCard("4111 1111 1111 1111"); !ok || got != "acme" {
+		t.Errorf("RouteCard = %q, %v; want acme", got, ok)
+	}
+}
@@ -0,0 +1,91 @@
+package luhn
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync/atomic"
+)
+
+// activeRules is the rule set used for matching. Nil means the built-in NetworkRules.
+// Replacing the pointer swaps the whole set at once, so a validation running concurrently
+// with a reload sees either the old rules or the new ones, never a mix.
+var activeRules atomic.Pointer[[]NetworkRule]
+
+// Rules returns the network rules currently used for matching, in match order.
+// The slice must not be modified.
+func Rules() []NetworkRule {
+	if rules := activeRules.Load(); rules != nil {
+		return *rules
+	}
+	return NetworkRules
+}
+
+// SetNetworkRules replaces the rules used for matching; nil restores the built-in NetworkRules
+func SetNetworkRules(rules []NetworkRule) {
+	if rules == nil {
+		activeRules.Store(nil)
+		return
+	}
+	activeRules.Store(&rules)
+}
+
+// LoadNetworkRules reads rules from a JSON array of objects with network, prefix_low,
+// prefix_high, and lengths fields, in match order. Every rule is checked before any is returned.
+func LoadNetworkRules(r io.Reader) ([]NetworkRule, error) {
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+
+	var rules []NetworkRule
+	if err := decoder.Decode(&rules); err != nil {
+		return nil, err
+	}
+	if len(rules) == 0 {
+		return nil, errors.New("network rules are empty")
+	}
+
+	for i, rule := range rules {
+		if err := rule.validate(); err != nil {
+			return nil, fmt.Errorf("rule %d: %w", i, err)
+		}
+	}
+	return rules, nil
+}
+
+// LoadNetworkRulesFile reads network rules from a JSON file
+func LoadNetworkRulesFile(path string) ([]NetworkRule, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return LoadNetworkRules(file)
+}
+
+// validate checks that a rule can be matched: equal-width digit prefixes in order and card lengths
+func (r NetworkRule) validate() error {
+	if r.Network == "" {
+		return errors.New("network is required")
+	}
+	if r.PrefixLow == "" || len(r.PrefixLow) != len(r.PrefixHigh) {
+		return errors.New("prefix_low and prefix_high must have the same number of digits")
+	}
+	if !isDigits(r.PrefixLow) || !isDigits(r.PrefixHigh) {
+		return errors.New("prefixes must contain only digits")
+	}
+	if r.PrefixLow > r.PrefixHigh {
+		return errors.New("prefix_low is greater than prefix_high")
+	}
+	if len(r.Lengths) == 0 {
+		return errors.New("at least one length is required")
+	}
+	for _, length := range r.Lengths {
+		if length < len(r.PrefixLow) || length > ISOMaxPANLength {
+			return fmt.Errorf("length %d is out of range", length)
+		}
+	}
+	return nil
+}
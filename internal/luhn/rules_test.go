@@ -0,0 +1,106 @@
+package luhn
+
+import (
+	"strings"
+	"sync"
+	"testing"
+)
+
+// setNetworkRules installs rules for one test and restores the built-in set afterwards
+func setNetworkRules(t *testing.T, rules []NetworkRule) {
+	t.Helper()
+	SetNetworkRules(rules)
+	t.Cleanup(func() { SetNetworkRules(nil) })
+}
+
+func TestLoadNetworkRules(t *testing.T) {
+	rules, err := LoadNetworkRules(strings.NewReader(`[
+		{"network":"Gift Card","prefix_low":"6039","prefix_high":"6039","lengths":[16,19]},
+		{"network":"Visa","prefix_low":"4","prefix_high":"4","lengths":[16]}
+	]`))
+	if err != nil {
+		t.Fatalf("LoadNetworkRules: %v", err)
+	}
+	if len(rules) != 2 || rules[0].Network != "Gift Card" || rules[1].PrefixLow != "4" {
+		t.Errorf("rules = %+v", rules)
+	}
+}
+
+func TestLoadNetworkRulesRejectsInvalidRules(t *testing.T) {
+	tests := map[string]string{
+		"not JSON":          `network,prefix_low`,
+		"empty":             `[]`,
+		"unknown field":     `[{"network":"X","prefix_low":"4","prefix_high":"4","lengths":[16],"country":"US"}]`,
+		"missing network":   `[{"prefix_low":"4","prefix_high":"4","lengths":[16]}]`,
+		"uneven prefixes":   `[{"network":"X","prefix_low":"4","prefix_high":"49","lengths":[16]}]`,
+		"non-digit prefix":  `[{"network":"X","prefix_low":"4a","prefix_high":"4b","lengths":[16]}]`,
+		"reversed range":    `[{"network":"X","prefix_low":"49","prefix_high":"40","lengths":[16]}]`,
+		"no lengths":        `[{"network":"X","prefix_low":"4","prefix_high":"4","lengths":[]}]`,
+		"length too long":   `[{"network":"X","prefix_low":"4","prefix_high":"4","lengths":[20]}]`,
+		"length too short":  `[{"network":"X","prefix_low":"4000","prefix_high":"4999","lengths":[3]}]`,
+		"one bad rule of 2": `[{"network":"X","prefix_low":"4","prefix_high":"4","lengths":[16]},{"network":""}]`,
+	}
+
+	for name, body := range tests {
+		if rules, err := LoadNetworkRules(strings.NewReader(body)); err == nil {
+			t.Errorf("%s: LoadNetworkRules = %+v, want an error", name, rules)
+		}
+	}
+}
+
+func TestSetNetworkRulesTakesEffect(t *testing.T) {
+	number := luhnNumber("6039", 16)
+	if network := identifyCardNetwork(number); network == "Gift Card" {
+		t.Fatalf("built-in rules already classify %s as Gift Card", number)
+	}
+
+	setNetworkRules(t, append([]NetworkRule{
+		{Network: "Gift Card", PrefixLow: "6039", PrefixHigh: "6039", Lengths: []int{16}},
+	}, NetworkRules...))
+	if info := ValidateCard(CardValidationRequest{CardNumber: number}); info.Network != "Gift Card" || !info.Valid {
+		t.Errorf("after reload: network %q, valid %v; want a valid Gift Card", info.Network, info.Valid)
+	}
+
+	SetNetworkRules(nil)
+	if network := identifyCardNetwork(number); network == "Gift Card" {
+		t.Errorf("after restoring the built-in rules: network = %q", network)
+	}
+	if len(Rules()) != len(NetworkRules) {
+		t.Errorf("Rules() has %d rules, want the %d built-in rules", len(Rules()), len(NetworkRules))
+	}
+}
+
+func TestSetNetworkRulesConcurrentWithValidation(t *testing.T) {
+	// Each set classifies the number consistently, so a validation seeing a mix would be caught
+	number := luhnNumber("4", 16)
+	sets := [][]NetworkRule{
+		{{Network: "Old", PrefixLow: "4", PrefixHigh: "4", Lengths: []int{16}}},
+		{{Network: "New", PrefixLow: "4", PrefixHigh: "4", Lengths: []int{16}}},
+	}
+	setNetworkRules(t, sets[0])
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+				SetNetworkRules(sets[i%2])
+			}
+		}
+	}()
+
+	for i := 0; i < 1000; i++ {
+		info := ValidateCard(CardValidationRequest{CardNumber: number})
+		if (info.Network != "Old" && info.Network != "New") || !info.Valid {
+			t.Errorf("network %q, valid %v; want Old or New and valid", info.Network, info.Valid)
+			break
+		}
+	}
+	close(stop)
+	wg.Wait()
+}
@@ -0,0 +1,45 @@
+package luhn
+
+// Score weights. The positive weights add up to 100.
+const (
+	scoreLuhn    = 50  // the check digit passes, whatever the length
+	scoreNetwork = 20  // the prefix identifies a known network
+	scoreLength  = 20  // the length is valid for the matched network
+	scoreExpiry  = 10  // an expiry date was provided and has not passed
+	scoreExpired = -20 // an expiry date was provided but has passed or is malformed
+)
+
+// Score combines validation results into a single 0-100 confidence value.
+//
+// The weighting is:
+//
+//	 50  check digit passed (CardInfo.ChecksumValid)
+//	 20  known network recognized
+//	 20  length valid for the network
+//	 10  expiry date provided and not expired
+//	-20  expiry date provided but expired or malformed
+//
+// Each check counts once: a number with the right check digit but the wrong length scores
+// above one with the right length but a bad check digit. A valid card without an expiry date
+// scores 90, and an expired but otherwise valid card scores 70. The score is never negative.
+func Score(info CardInfo) int {
+	score := 0
+	if info.ChecksumValid {
+		score += scoreLuhn
+	}
+	if info.Network != "" && info.Network != "Unknown" && info.Network != NetworkNotApplicable {
+		score += scoreNetwork
+	}
+	if info.LengthValid {
+		score += scoreLength
+	}
+	if info.ExpiryValid {
+		score += scoreExpiry
+	} else if info.ExpiryProvided {
+		score += scoreExpired
+	}
+	if score < 0 {
+		return 0
+	}
+	return score
+}
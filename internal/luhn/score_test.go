@@ -0,0 +1,33 @@
+package luhn
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScore(t *testing.T) {
+	future := time.Now().AddDate(2, 0, 0).Format("01/06")
+
+	tests := []struct {
+		name    string
+		request CardValidationRequest
+		want    int
+	}{
+		{"valid with future expiry", CardValidationRequest{CardNumber: "4111111111111111", ExpiryDate: future}, 100},
+		{"valid without expiry", CardValidationRequest{CardNumber: "4111111111111111"}, 90},
+		{"expired but otherwise valid", CardValidationRequest{CardNumber: "4111111111111111", ExpiryDate: "01/20"}, 70},
+		{"check digit passes at the wrong length", CardValidationRequest{CardNumber: luhnNumber("4", 17)}, 50},
+		{"check digit fails at the right length", CardValidationRequest{CardNumber: "4111111111111112"}, 40},
+		// Unknown networks have no length rule, so any ISO length counts as valid
+		{"unknown network", CardValidationRequest{CardNumber: luhnNumber("99", 16)}, 70},
+		{"invalid and expired", CardValidationRequest{CardNumber: "9999999999999991", ExpiryDate: "01/20"}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Score(ValidateCard(tt.request)); got != tt.want {
+				t.Errorf("Score = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
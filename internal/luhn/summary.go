@@ -0,0 +1,42 @@
+package luhn
+
+// Summary aggregates the results of validating several cards
+type Summary struct {
+	Total     int            `json:"total"`
+	Valid     int            `json:"valid"`      // passed the check digit and length rules
+	Invalid   int            `json:"invalid"`    // Total - Valid
+	Expired   int            `json:"expired"`    // well-formed expiry date that has passed or is implausibly far out
+	ByNetwork map[string]int `json:"by_network"` // count per detected network, including "Unknown"
+}
+
+// ValidateAll validates each request in order and summarizes the results.
+// Use ValidateBatch for large or cancellable workloads and Summarize its results.
+func ValidateAll(requests []CardValidationRequest) ([]CardInfo, Summary) {
+	results := make([]CardInfo, len(requests))
+	for i, request := range requests {
+		results[i] = ValidateCleaned(request)
+	}
+	return results, Summarize(results)
+}
+
+// Summarize computes aggregate counts for a set of validation results
+func Summarize(results []CardInfo) Summary {
+	summary := Summary{
+		Total:     len(results),
+		ByNetwork: make(map[string]int),
+	}
+	for _, info := range results {
+		if info.Valid {
+			summary.Valid++
+		}
+		if info.ExpiryFormatOK && !info.ExpiryValid {
+			summary.Expired++
+		}
+		// Numbers too short to classify have no network
+		if info.Network != "" {
+			summary.ByNetwork[info.Network]++
+		}
+	}
+	summary.Invalid = summary.Total - summary.Valid
+	return summary
+}
@@ -0,0 +1,72 @@
+package luhn
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestValidateAllSummary(t *testing.T) {
+	clock := FixedClock(time.Date(2025, time.June, 15, 12, 0, 0, 0, time.UTC))
+	requests := []CardValidationRequest{
+		{CardNumber: "4111111111111111"},                                       // valid Visa
+		{CardNumber: "4111 1111 1111 1111", ExpiryDate: "12/26", Clock: clock}, // valid Visa, formatted
+		{CardNumber: "4111111111111112"},                                       // Visa, bad check digit
+		{CardNumber: "5500000000000004", ExpiryDate: "01/20", Clock: clock},    // valid Mastercard, expired
+		{CardNumber: "378282246310005", ExpiryDate: "12/99", Clock: clock},     // valid Amex, implausibly far out
+		{CardNumber: "6011111111111117", ExpiryDate: "13/25", Clock: clock},    // valid Discover, malformed expiry
+		{CardNumber: luhnNumber("9", 16)},                                      // Luhn-valid, unknown network
+		{CardNumber: "4111"},                                                   // too short to classify
+	}
+
+	results, summary := ValidateAll(requests)
+
+	if len(results) != len(requests) {
+		t.Fatalf("%d results for %d requests", len(results), len(requests))
+	}
+	for i, request := range requests {
+		if want := ValidateCard(request); !reflect.DeepEqual(results[i], want) {
+			t.Errorf("result %d = %+v, want ValidateCard's %+v", i, results[i], want)
+		}
+	}
+
+	want := Summary{
+		Total:   8,
+		Valid:   6,
+		Invalid: 2,
+		Expired: 2, // a malformed expiry is not counted as expired
+		ByNetwork: map[string]int{
+			"Visa":             3,
+			"Mastercard":       1,
+			"American Express": 1,
+			"Discover":         1,
+			"Unknown":          1,
+		},
+	}
+	if !reflect.DeepEqual(summary, want) {
+		t.Errorf("summary = %+v, want %+v", summary, want)
+	}
+}
+
+func TestValidateAllEmpty(t *testing.T) {
+	results, summary := ValidateAll(nil)
+	if len(results) != 0 || summary.Total != 0 || summary.Valid != 0 || summary.Invalid != 0 || summary.Expired != 0 {
+		t.Errorf("ValidateAll(nil) = %v, %+v", results, summary)
+	}
+	// An empty map, not nil, so the JSON summary always has a by_network object
+	if summary.ByNetwork == nil {
+		t.Error("ByNetwork is nil")
+	}
+}
+
+func TestSummarizeMatchesValidateAll(t *testing.T) {
+	requests := []CardValidationRequest{
+		{CardNumber: "4111111111111111"},
+		{CardNumber: "5500000000000005"},
+		{CardNumber: "not a card"},
+	}
+	results, summary := ValidateAll(requests)
+	if again := Summarize(results); !reflect.DeepEqual(again, summary) {
+		t.Errorf("Summarize = %+v, ValidateAll summary = %+v", again, summary)
+	}
+}
@@ -1,10 +1,12 @@
 package luhn
 
 import (
+	"context"
 	"regexp"
 	"strings"
 	"time"
 	"strconv"
+	"unicode/utf8"
 )
 
 // CardInfo contains validation results and card network information
@@ -15,6 +17,28 @@ type CardInfo struct {
 	ExpiryValid     bool   `json:"expiry_valid,omitempty"`
 	ExpiryFormatOK  bool   `json:"expiry_format_ok,omitempty"`
 	CVVValid        bool   `json:"cvv_valid,omitempty"`
+	CVVProvided     bool   `json:"cvv_provided,omitempty"`
+	CVVMissing      bool   `json:"cvv_missing,omitempty"`    // No CVV was provided but the network requires one
+	LengthValid     bool   `json:"length_valid"`
+	ChecksumValid   bool   `json:"checksum_valid"`           // The check digit passes, whatever the length
+	ExpiryProvided  bool   `json:"expiry_provided,omitempty"`
+	PrefixNetwork   string `json:"prefix_network,omitempty"` // Network whose prefix matched when the length did not
+	Processor       string `json:"processor,omitempty"`      // Set when a routing table is loaded and a range matches
+	Outcome         string `json:"outcome"`                  // One of the Outcome* constants
+	BIN             string `json:"bin,omitempty"`            // Leading digits used for identification; see extractBIN
+	BINLength       int    `json:"bin_length,omitempty"`     // 6, or 8 when an 8-digit routing table matched
+	BrandSlug       string `json:"brand_slug,omitempty"`     // Lowercase asset identifier for Network; see BrandSlug
+	ExpiryISO       string `json:"expiry_iso,omitempty"`     // Last valid day as YYYY-MM-DD, set when ExpiryValid
+	NewExpiryValid    bool `json:"new_expiry_valid,omitempty"`
+	NewExpiryFormatOK bool `json:"new_expiry_format_ok,omitempty"`
+	RenewalValid      bool `json:"renewal_valid,omitempty"` // The new expiry is valid and later than the old one
+	NetworkStatus   string `json:"network_status"`           // One of the Network* status constants
+	SecurityCodeLocation string `json:"security_code_location,omitempty"` // "front" or "back", set for identified networks
+
+	// Issuer country codes, set when the routing table maps the card's range to a country
+	IssuerCountryAlpha2  string `json:"issuer_country_alpha2,omitempty"`
+	IssuerCountryAlpha3  string `json:"issuer_country_alpha3,omitempty"`
+	IssuerCountryNumeric string `json:"issuer_country_numeric,omitempty"`
 }
 
 // CardValidationRequest contains all information for validating a card
@@ -22,13 +46,151 @@ type CardValidationRequest struct {
 	CardNumber string `json:"card_number"`
 	ExpiryDate string `json:"expiry_date,omitempty"` // Format: MM/YY
 	CVV        string `json:"cvv,omitempty"`         // 3 or 4 digits
+
+	// NewExpiryDate is the renewed card's expiry (MM/YY) in card-update flows
+	NewExpiryDate string `json:"new_expiry_date,omitempty"`
+
+	// Algorithm selects the check digit verification; the zero value is CheckLuhn
+	Algorithm CheckAlgorithm `json:"-"`
+
+	// CheckDigit moves the check digit or changes the modulus when Algorithm is CheckLuhn;
+	// the zero value is standard Luhn
+	CheckDigit CheckDigit `json:"-"`
+
+	// CustomCheck verifies the digits-only number when Algorithm is CheckCustom
+	CustomCheck func(digits string) bool `json:"-"`
+
+	// Clock supplies the current time for expiry checks; nil uses the system clock
+	Clock Clock `json:"-"`
+
+	// LuhnOnly skips network detection and every other check, for numbers from networks this
+	// service does not know. The result has Network NetworkNotApplicable and only the check digit result.
+	LuhnOnly bool `json:"-"`
+
+	// Config is the deployment's validation policy; the zero value is DefaultValidationConfig
+	Config ValidationConfig `json:"-"`
+}
+
+// ValidationConfig holds the validation policy a deployment applies to every card. Callers
+// pass it with each request, so every endpoint validates against the same policy.
+type ValidationConfig struct {
+	// GracePeriodDays extends expiry validity this many days past the end of the expiry
+	// month, for processors that accept recently expired cards. 0 disables it.
+	GracePeriodDays int
+
+	// CVVRequiredNetworks lists networks, matched case-insensitively, whose cards must come
+	// with a CVV. A missing CVV for one of them sets CardInfo.CVVMissing. Empty requires none.
+	CVVRequiredNetworks []string
+}
+
+// DefaultValidationConfig returns a default configuration
+func DefaultValidationConfig() ValidationConfig {
+	return ValidationConfig{
+		GracePeriodDays: 0,
+	}
+}
+
+// Clock tells the time. Tests pin it with FixedClock to check expiry boundaries deterministically.
+type Clock interface {
+	Now() time.Time
+}
+
+// systemClock is the real clock used when a request does not set one
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+// fixedClock always returns the same time
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time { return time.Time(c) }
+
+// FixedClock returns a Clock that always reports t
+func FixedClock(t time.Time) Clock {
+	return fixedClock(t)
+}
+
+// clock returns the request's clock, defaulting to the system clock
+func (request CardValidationRequest) clock() Clock {
+	if request.Clock == nil {
+		return systemClock{}
+	}
+	return request.Clock
+}
+
+// NetworkStatus values distinguish why Network is or is not set
+const (
+	NetworkIdentified    = "identified"    // Network names the matching network
+	NetworkUnknown       = "unknown"       // a complete number that matches no known network
+	NetworkIndeterminate = "indeterminate" // too few digits to decide, such as a partially typed number
+	NetworkSkipped       = "skipped"       // detection was not attempted because the request was Luhn-only
+)
+
+// NetworkNotApplicable is the Network of a Luhn-only result, distinct from "Unknown" so callers
+// can tell that detection was skipped rather than failed
+const NetworkNotApplicable = "N/A"
+
+// ISO/IEC 7812 bounds PANs to 8-19 digits; deployments may adjust them
+var (
+	ISOMinPANLength = 8
+	ISOMaxPANLength = 19
+)
+
+// MinCardLength is the shortest card number ValidateCard accepts. No real
+// payment card is shorter; the generic Luhn check still handles shorter identifiers.
+var MinCardLength = 12
+
+// MinPANLength is the shortest card number ValidateCard accepts: the larger of
+// MinCardLength and ISOMinPANLength
+func MinPANLength() int {
+	if MinCardLength > ISOMinPANLength {
+		return MinCardLength
+	}
+	return ISOMinPANLength
+}
+
+// InLengthRange reports whether a card number length passes ValidateCard's length gate,
+// MinPANLength to ISOMaxPANLength digits. Numbers outside it are rejected before network
+// detection with the pan_length_out_of_range outcome.
+func InLengthRange(length int) bool {
+	return length >= MinPANLength() && length <= ISOMaxPANLength
 }
 
 // ValidateCard checks if a credit card number is valid and identifies the network
 func ValidateCard(request CardValidationRequest) CardInfo {
-	// Remove any spaces or dashes
-	cleanedNumber := cleanCardNumber(request.CardNumber)
+	// Remove any spaces or dashes, computing the Luhn sum in the same pass
+	cleanedNumber, sum := cleanAndSum(request.CardNumber)
+	return validateDigits(cleanedNumber, sum, request)
+}
+
+// ValidateCleaned is ValidateCard for callers that have already reduced the card number to
+// ASCII digits, such as requests that passed the sanitizer. It skips building a cleaned copy.
+// The assumption is checked with a single scan; other input falls back to ValidateCard,
+// so the result is always the same as ValidateCard's.
+func ValidateCleaned(request CardValidationRequest) CardInfo {
+	if !isDigits(request.CardNumber) {
+		return ValidateCard(request)
+	}
+	return validateDigits(request.CardNumber, luhnSum(request.CardNumber), request)
+}
+
+// DetectAndValidate is ValidateCard for high-throughput rejection of bad input. When the number
+// fails its check digit it returns immediately, without network detection, routing, or expiry
+// and CVV checks, so only Valid, CardLength, and Outcome are set. Numbers that pass get the
+// same result as ValidateCard.
+func DetectAndValidate(request CardValidationRequest) CardInfo {
+	cleanedNumber, sum := cleanAndSum(request.CardNumber)
+	if InLengthRange(len(cleanedNumber)) && !passesCheck(cleanedNumber, sum, request) {
+		return CardInfo{
+			CardLength: len(cleanedNumber),
+			Outcome:    OutcomeInvalidLuhn,
+		}
+	}
+	return validateDigits(cleanedNumber, sum, request)
+}
 
+// validateDigits runs every check against a digits-only card number whose Luhn sum is already known
+func validateDigits(cleanedNumber string, sum int, request CardValidationRequest) CardInfo {
 	// Create response object
 	result := CardInfo{
 		Valid:           false,
@@ -36,35 +198,102 @@ func ValidateCard(request CardValidationRequest) CardInfo {
 		ExpiryValid:     false,
 		ExpiryFormatOK:  false,
 		CVVValid:        false,
+		LengthValid:     false,
 	}
 
-	// Skip validation if length is too short
-	if len(cleanedNumber) < 2 {
+	// Reject numbers shorter than any real card or longer than ISO allows, which get their own outcome
+	if !InLengthRange(len(cleanedNumber)) {
+		result.NetworkStatus = NetworkIndeterminate
+		if len(cleanedNumber) > ISOMaxPANLength {
+			result.NetworkStatus = NetworkUnknown
+		}
+		result.Outcome = determineOutcome(request, result)
 		return result
 	}
 
-	// Check if the number passes the Luhn algorithm
-	result.Valid = isLuhnValid(cleanedNumber)
-	
-	// Identify the card network
-	result.Network = identifyCardNetwork(cleanedNumber)
+	// A Luhn-only request reports the check digit result and nothing else
+	if request.LuhnOnly {
+		result.Network = NetworkNotApplicable
+		result.NetworkStatus = NetworkSkipped
+		result.LengthValid = true
+		result.ChecksumValid = passesCheck(cleanedNumber, sum, request)
+		result.Valid = result.ChecksumValid
+		result.Outcome = determineOutcome(request, result)
+		return result
+	}
+
+	// Identify the card network and check the length against its rules
+	result.Network, result.PrefixNetwork = matchNetwork(cleanedNumber)
+	result.LengthValid = result.PrefixNetwork == ""
+	result.BrandSlug = BrandSlug(result.Network)
+	result.NetworkStatus = NetworkIdentified
+	if result.Network == "Unknown" {
+		result.NetworkStatus = NetworkUnknown
+	} else {
+		result.SecurityCodeLocation = SecurityCodeLocation(result.Network)
+	}
+
+	// Check if the number passes the check digit algorithm (Luhn by default) and has a valid length for its network
+	result.ChecksumValid = passesCheck(cleanedNumber, sum, request)
+	result.Valid = result.ChecksumValid && result.LengthValid
+
+	// Route the card when a routing table is loaded; a match identifies the card by the table's BIN width
+	// and supplies the issuer country when the table has one
+	binLength := StandardBINLength
+	if table := routingTable.Load(); table != nil {
+		if rng, ok := table.lookup(cleanedNumber); ok {
+			result.Processor = rng.Processor
+			result.IssuerCountryAlpha2 = rng.Country.Alpha2
+			result.IssuerCountryAlpha3 = rng.Country.Alpha3
+			result.IssuerCountryNumeric = rng.Country.Numeric
+			binLength = table.width
+		}
+	}
+	result.BIN, result.BINLength = extractBIN(cleanedNumber, binLength)
 
 	// Validate expiry date if provided
 	if request.ExpiryDate != "" {
-		expiryFormatOK, expiryValid := validateExpiryDate(request.ExpiryDate)
+		result.ExpiryProvided = true
+		expiryFormatOK, expiryValid := validateExpiryDate(request.ExpiryDate, request.clock().Now(), request.Config.GracePeriodDays)
 		result.ExpiryFormatOK = expiryFormatOK
 		result.ExpiryValid = expiryValid
+		if expiryValid {
+			result.ExpiryISO = expiryISODate(request.ExpiryDate)
+		}
+	}
+
+	// Validate the renewed expiry date if provided, and whether it extends the old one
+	if request.NewExpiryDate != "" {
+		result.NewExpiryFormatOK, result.NewExpiryValid = validateExpiryDate(request.NewExpiryDate, request.clock().Now(), request.Config.GracePeriodDays)
+		result.RenewalValid = result.NewExpiryValid && expiryAfter(request.NewExpiryDate, request.ExpiryDate)
 	}
 
 	// Validate CVV if provided
 	if request.CVV != "" {
+		result.CVVProvided = true
 		result.CVVValid = validateCVV(request.CVV, result.Network)
+	} else {
+		result.CVVMissing = request.Config.CVVRequired(result.Network)
 	}
 
+	result.Outcome = determineOutcome(request, result)
+
 	return result
 }
 
-// validateCVV checks if the CVV/security code is valid for the card type
+// ValidateCardContext is ValidateCard for request-scoped callers. It returns the context's
+// error without validating if the context is already cancelled or past its deadline, so
+// work for a client that has gone away is skipped. Already-cleaned numbers take the
+// ValidateCleaned fast path.
+func ValidateCardContext(ctx context.Context, request CardValidationRequest) (CardInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return CardInfo{}, err
+	}
+	return ValidateCleaned(request), nil
+}
+
+// validateCVV checks if the CVV/security code has exactly the length required by the card's network.
+// The sanitizer only enforces the loose 3-4 digit format because it runs before the network is known.
 func validateCVV(cvv string, network string) bool {
 	// Check if CVV contains only digits
 	for _, r := range cvv {
@@ -73,49 +302,62 @@ func validateCVV(cvv string, network string) bool {
 		}
 	}
 
-	// American Express requires a 4-digit CVV
+	return len(cvv) == SecurityCodeLength(network)
+}
+
+// SecurityCodeLength returns the exact CVV length for a network.
+// American Express uses a 4-digit code; most other cards use 3 digits.
+func SecurityCodeLength(network string) int {
 	if network == "American Express" {
-		return len(cvv) == 4
+		return 4
 	}
-	
-	// Most other cards use a 3-digit CVV
-	return len(cvv) == 3
+	return 3
 }
 
-// validateExpiryDate checks if expiry date is valid (MM/YY format) and not expired
-func validateExpiryDate(expiryDate string) (bool, bool) {
-	// Check format using regex (MM/YY)
-	matched, _ := regexp.MatchString(`^(0[1-9]|1[0-2])/([0-9]{2})$`, expiryDate)
-	if !matched {
-		return false, false // Format is invalid
+// CVVRequired reports whether CVVRequiredNetworks requires a CVV for the network
+func (c ValidationConfig) CVVRequired(network string) bool {
+	for _, required := range c.CVVRequiredNetworks {
+		if strings.EqualFold(strings.TrimSpace(required), network) {
+			return true
+		}
 	}
+	return false
+}
 
-	// Split into month and year
-	parts := strings.Split(expiryDate, "/")
-	if len(parts) != 2 {
-		return true, false
+// SecurityCodeLocation returns where the security code is printed for a network, as a UX hint.
+// American Express prints its 4-digit CID on the front; other networks print the CVV on the back.
+func SecurityCodeLocation(network string) string {
+	if network == "American Express" {
+		return "front"
 	}
+	return "back"
+}
 
-	month, err1 := strconv.Atoi(parts[0])
-	year, err2 := strconv.Atoi(parts[1])
-	if err1 != nil || err2 != nil {
-		return true, false
-	}
+// expiryPattern matches MM/YY. It is compiled once; Go's RE2-based regexp runs in linear time.
+var expiryPattern = regexp.MustCompile(`^(0[1-9]|1[0-2])/([0-9]{2})$`)
 
-	// Convert YY to YYYY
-	fullYear := 2000 + year
+// validateExpiryDate checks if expiry date is valid (MM/YY format) and not expired as of now,
+// allowing graceDays past the end of the expiry month
+func validateExpiryDate(expiryDate string, now time.Time, graceDays int) (bool, bool) {
+	fullYear, month, ok := parseExpiry(expiryDate)
+	if !ok {
+		return false, false // Format is invalid
+	}
 
 	// Get current date
-	now := time.Now()
 	currentYear := now.Year()
 	currentMonth := int(now.Month())
 
-	// Check if card is expired
-	if fullYear < currentYear {
-		return true, false // Expired
-	}
-	if fullYear == currentYear && month < currentMonth {
-		return true, false // Expired
+	// Check if card is expired. A card is usable through the last day of its expiry
+	// month, plus the grace period when a processor allows it.
+	if fullYear < currentYear || (fullYear == currentYear && month < currentMonth) {
+		if graceDays <= 0 {
+			return true, false // Expired
+		}
+		endOfValidity := time.Date(fullYear, time.Month(month)+1, 1, 0, 0, 0, 0, now.Location())
+		if !now.Before(endOfValidity.AddDate(0, 0, graceDays)) {
+			return true, false // Expired, even with the grace period
+		}
 	}
 
 	// Check if expiry date is too far in the future (more than 20 years)
@@ -126,37 +368,95 @@ func validateExpiryDate(expiryDate string) (bool, bool) {
 	return true, true // Valid expiry date
 }
 
-// cleanCardNumber removes any non-digit characters
-func cleanCardNumber(cardNumber string) string {
-	var cleaned strings.Builder
-	for _, r := range cardNumber {
-		if r >= '0' && r <= '9' {
-			cleaned.WriteRune(r)
+// parseExpiry is the shared MM/YY parser, returning the four-digit year and the month
+func parseExpiry(expiryDate string) (year int, month int, ok bool) {
+	// Check format using regex (MM/YY)
+	matches := expiryPattern.FindStringSubmatch(expiryDate)
+	if matches == nil {
+		return 0, 0, false
+	}
+
+	month, _ = strconv.Atoi(matches[1])
+	year, _ = strconv.Atoi(matches[2])
+
+	// Convert YY to YYYY
+	return 2000 + year, month, true
+}
+
+// expiryAfter reports whether MM/YY expiry a is a later month than b. Both must parse.
+func expiryAfter(a, b string) bool {
+	yearA, monthA, okA := parseExpiry(a)
+	yearB, monthB, okB := parseExpiry(b)
+	if !okA || !okB {
+		return false
+	}
+	return yearA > yearB || (yearA == yearB && monthA > monthB)
+}
+
+// expiryISODate returns the last day of an MM/YY expiry month as an ISO 8601 date, e.g. 09/27 is
+// 2027-09-30. Day 0 of the following month normalizes to that last day, so month lengths and
+// leap years come from the time package.
+func expiryISODate(expiryDate string) string {
+	year, month, ok := parseExpiry(expiryDate)
+	if !ok {
+		return ""
+	}
+	return time.Date(year, time.Month(month)+1, 0, 0, 0, 0, 0, time.UTC).Format("2006-01-02")
+}
+
+// isDigits reports whether s consists only of ASCII digits
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
 		}
 	}
-	return cleaned.String()
+	return true
 }
 
 // isLuhnValid implements the Luhn algorithm to validate card numbers
 func isLuhnValid(cardNumber string) bool {
-	var digits []int
-	for _, r := range cardNumber {
-		digits = append(digits, int(r-'0'))
-	}
-
 	// Check if we have a valid number of digits
-	if len(digits) < 2 {
+	if len(cardNumber) < 2 {
 		return false
 	}
 
-	// Starting from the rightmost digit and moving left
-	// double the value of every second digit
+	// If the total modulo 10 is 0, then the number is valid
+	return luhnSum(cardNumber)%10 == 0
+}
+
+// Checksum returns the Luhn sum modulo 10 for a card number; 0 means the number passes.
+// Separators are skipped as the sum is computed, so no cleaned copy is built.
+func Checksum(cardNumber string) int {
+	return luhnSum(cardNumber) % 10
+}
+
+// luhnSum computes the Luhn weighted digit sum in a single pass without allocating.
+// Anything that is not a decimal digit is skipped, as Clean would remove it.
+func luhnSum(cardNumber string) int {
 	sum := 0
-	parity := len(digits) % 2
-	
-	for i, digit := range digits {
-		// Double every second digit, starting from the right
-		if i%2 == parity {
+	double := false
+
+	// Starting from the rightmost digit and moving left, double every second digit
+	for i := len(cardNumber); i > 0; {
+		var digit int
+		if c := cardNumber[i-1]; c < utf8.RuneSelf {
+			i--
+			if c < '0' || c > '9' {
+				continue
+			}
+			digit = int(c - '0')
+		} else {
+			r, size := utf8.DecodeLastRuneInString(cardNumber[:i])
+			i -= size
+			d, ok := digitValue(r)
+			if !ok {
+				continue
+			}
+			digit = d
+		}
+
+		if double {
 			digit *= 2
 			// If doubling results in a number greater than 9, subtract 9
 			if digit > 9 {
@@ -164,54 +464,37 @@ func isLuhnValid(cardNumber string) bool {
 			}
 		}
 		sum += digit
+		double = !double
 	}
 
-	// If the total modulo 10 is 0, then the number is valid
-	return sum%10 == 0
+	return sum
 }
 
 // identifyCardNetwork determines the payment network based on card prefix and length
 func identifyCardNetwork(cardNumber string) string {
-	// Common patterns for major card networks
-	patterns := map[string]string{
-		// Visa: Starts with 4, length 13, 16, or 19
-		`^4\d{12}(?:\d{3})?(?:\d{3})?$`: "Visa",
-
-		// Mastercard: Starts with 51-55 or 2221-2720, length 16
-		`^5[1-5]\d{14}$`:     "Mastercard",
-		`^2(?:2(?:2[1-9]|[3-9]\d)|[3-6]\d{2}|7(?:[01]\d|20))\d{12}$`: "Mastercard",
-
-		// American Express: Starts with 34 or 37, length 15
-		`^3[47]\d{13}$`: "American Express",
-
-		// Discover: Starts with 6011, 622126-622925, 644-649, 65, length 16-19
-		`^6(?:011|5\d{2})\d{12,15}$`: "Discover",
-		`^6(?:44|45|46|47|48|49)\d{13,16}$`: "Discover",
-		`^6(?:22(?:12[6-9]|1[3-9]\d|[2-9]\d{2})|2[3-9]\d{2}|[3-9]\d{3})\d{10,13}$`: "Discover",
-
-		// JCB: Starts with 3528-3589, length 16-19
-		`^35(?:2[89]|[3-8]\d)\d{12,15}$`: "JCB",
-
-		// UnionPay: Starts with 62, length 16-19
-		`^62\d{14,17}$`: "UnionPay",
-
-		// Diners Club: Starts with 300-305, 36, 38-39, length 14-19
-		`^3(?:0[0-5]|[68]\d)\d{11,16}$`: "Diners Club",
-
-		// RuPay: Starts with 60, 6521, 6522, length 16
-		`^60\d{14}$`: "RuPay",
-		`^652[12]\d{13}$`: "RuPay",
-		
-		// Maestro: Starts with 5018, 5020, 5038, 5893, 6304, 6759, 6761, 6762, 6763, length 16-19
-		`^(?:5(?:018|0[23]8|[68]93)|6(?:304|759|7(?:6[1-3])))\d{10,13}$`: "Maestro",
-	}
+	network, _ := matchNetwork(cardNumber)
+	return network
+}
 
-	for pattern, network := range patterns {
-		match, _ := regexp.MatchString(pattern, cardNumber)
-		if match {
-			return network
+// matchNetwork walks the active rules in order and returns the first network whose prefix
+// and length both match. If a prefix matched but its length did not, the name of the
+// first such network is returned as prefixNetwork.
+//
+// Matching uses plain prefix comparisons and length checks rather than regular expressions.
+// Each rule compares at most its prefix width (6 digits), so the worst case is
+// O(rules × 6) regardless of input length: a pathological digit string costs no more than a real card.
+func matchNetwork(cardNumber string) (network string, prefixNetwork string) {
+	for _, rule := range Rules() {
+		if !rule.matchesPrefix(cardNumber) {
+			continue
+		}
+		if rule.matchesLength(len(cardNumber)) {
+			return rule.Network, ""
+		}
+		if prefixNetwork == "" {
+			prefixNetwork = rule.Network
 		}
 	}
 
-	return "Unknown"
-}
\ No newline at end of file
+	return "Unknown", prefixNetwork
+}
@@ -0,0 +1,723 @@
+package luhn
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// luhnNumber pads prefix with zeros to length and appends the Luhn check digit
+func luhnNumber(prefix string, length int) string {
+	body := prefix + strings.Repeat("0", length-len(prefix)-1)
+	digit, _ := ResolveCheckDigit(body + "X")
+	return body + strconv.Itoa(digit)
+}
+
+func TestNetworkRuleBoundaries(t *testing.T) {
+	for _, rule := range NetworkRules {
+		shortest, longest := rule.Lengths[0], rule.Lengths[len(rule.Lengths)-1]
+		for _, prefix := range []string{rule.PrefixLow, rule.PrefixHigh} {
+			for _, length := range []int{shortest, longest} {
+				number := luhnNumber(prefix, length)
+				info := ValidateCard(CardValidationRequest{CardNumber: number})
+				if info.Network != rule.Network || !info.Valid || !info.LengthValid {
+					t.Errorf("%v: %s (%d digits) = network %q, valid %v, length valid %v",
+						rule, number, length, info.Network, info.Valid, info.LengthValid)
+				}
+			}
+
+			// One digit beyond the rule's lengths never matches the rule. A broader rule may
+			// still claim the number, as Discover 65 does for a 17-digit RuPay 6521.
+			for _, length := range []int{shortest - 1, longest + 1} {
+				if length < MinCardLength || length > ISOMaxPANLength {
+					continue
+				}
+				number := luhnNumber(prefix, length)
+				if matched, ok := MatchRule(number); ok && matched.String() == rule.String() {
+					t.Errorf("%v: %s (%d digits) matched the rule", rule, number, length)
+				}
+			}
+		}
+	}
+}
+
+func TestAmexLengths(t *testing.T) {
+	tests := []struct {
+		prefix string
+		length int
+		want   bool
+	}{
+		{"34", 14, false},
+		{"34", 15, true},
+		{"34", 16, false},
+		{"37", 14, false},
+		{"37", 15, true},
+		{"37", 16, false},
+	}
+
+	for _, tt := range tests {
+		number := luhnNumber(tt.prefix, tt.length)
+		info := ValidateCard(CardValidationRequest{CardNumber: number})
+
+		if tt.want {
+			if info.Network != "American Express" || !info.Valid {
+				t.Errorf("%s: network %q, valid %v; want valid American Express", number, info.Network, info.Valid)
+			}
+			continue
+		}
+		if info.Network == "American Express" || info.Valid {
+			t.Errorf("%s: network %q, valid %v; want neither American Express nor valid", number, info.Network, info.Valid)
+		}
+		if info.PrefixNetwork != "American Express" || info.LengthValid || !info.ChecksumValid {
+			t.Errorf("%s: prefix network %q, length valid %v, checksum valid %v; want an American Express length mismatch",
+				number, info.PrefixNetwork, info.LengthValid, info.ChecksumValid)
+		}
+	}
+}
+
+func TestDiscoverUnionPayOverlap(t *testing.T) {
+	tests := []struct {
+		prefix string
+		want   string
+	}{
+		{"620000", "UnionPay"},
+		{"622125", "UnionPay"},
+		{"622126", "Discover"},
+		{"622500", "Discover"},
+		{"622925", "Discover"},
+		{"622926", "UnionPay"},
+		{"629999", "UnionPay"},
+	}
+
+	for _, tt := range tests {
+		for _, length := range []int{16, 19} {
+			number := luhnNumber(tt.prefix, length)
+			info := ValidateCard(CardValidationRequest{CardNumber: number})
+			if info.Network != tt.want || !info.Valid {
+				t.Errorf("%s (%d digits): network %q, valid %v; want valid %s", number, length, info.Network, info.Valid, tt.want)
+			}
+			if got := NetworkFromBIN(tt.prefix); got != tt.want {
+				t.Errorf("NetworkFromBIN(%s) = %q, want %q", tt.prefix, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestUnionPayLengths(t *testing.T) {
+	for _, prefix := range []string{"62", "81"} {
+		for length := 15; length <= 20; length++ {
+			want := length >= 16 && length <= 19
+			number := luhnNumber(prefix, length)
+			info := ValidateCard(CardValidationRequest{CardNumber: number})
+
+			if got := info.Network == "UnionPay" && info.Valid; got != want {
+				t.Errorf("%d-digit %s %s: network %q, valid %v; want valid UnionPay %v",
+					length, prefix, number, info.Network, info.Valid, want)
+			}
+		}
+	}
+}
+
+func TestUnionPayLeavesNeighbouringPrefixes(t *testing.T) {
+	tests := []struct {
+		prefix string
+		want   string
+	}{
+		{"6011", "Discover"},
+		{"644", "Discover"},
+		{"65", "Discover"},
+		{"6521", "RuPay"},
+		{"60", "RuPay"},
+		{"80", "Unknown"},
+		{"82", "Unknown"},
+	}
+
+	for _, tt := range tests {
+		number := luhnNumber(tt.prefix, 16)
+		if got := ValidateCard(CardValidationRequest{CardNumber: number}).Network; got != tt.want {
+			t.Errorf("%s: network %q, want %q", number, got, tt.want)
+		}
+	}
+}
+
+func TestVisaLengths(t *testing.T) {
+	for length := 12; length <= 20; length++ {
+		want := length == 13 || length == 16 || length == 19
+		number := luhnNumber("4", length)
+		info := ValidateCard(CardValidationRequest{CardNumber: number})
+
+		if got := info.Network == "Visa" && info.Valid; got != want {
+			t.Errorf("%d-digit Visa %s: network %q, valid %v; want valid Visa %v", length, number, info.Network, info.Valid, want)
+		}
+		if !want && InLengthRange(length) && info.PrefixNetwork != "Visa" {
+			t.Errorf("%d-digit Visa %s: prefix network %q, want Visa", length, number, info.PrefixNetwork)
+		}
+	}
+}
+
+func TestLuhnParity(t *testing.T) {
+	tests := []struct {
+		number  string
+		network string
+	}{
+		{"378282246310005", "American Express"}, // 15 digits, odd
+		{"371449635398431", "American Express"},
+		{"4111111111111111", "Visa"}, // 16 digits, even
+		{"4012888888881881", "Visa"},
+		{"4222222222222", "Visa"}, // 13 digits, odd
+		{luhnNumber("4539578763621486", 19), "Visa"},
+	}
+
+	for _, tt := range tests {
+		if !isLuhnValid(tt.number) {
+			t.Errorf("isLuhnValid(%s) = false", tt.number)
+		}
+		info := ValidateCard(CardValidationRequest{CardNumber: tt.number})
+		if !info.Valid || info.Network != tt.network {
+			t.Errorf("%s: valid %v, network %q; want valid %s", tt.number, info.Valid, info.Network, tt.network)
+		}
+
+		// Luhn catches every single-digit error, at odd and even positions alike
+		for i := range tt.number {
+			digit := tt.number[i] - '0'
+			changed := tt.number[:i] + strconv.Itoa(int(digit+1)%10) + tt.number[i+1:]
+			if isLuhnValid(changed) {
+				t.Errorf("isLuhnValid(%s) = true after changing position %d of %s", changed, i, tt.number)
+			}
+		}
+	}
+}
+
+func TestMatchNetworkAgreesWithNetworkFromBIN(t *testing.T) {
+	for _, rule := range NetworkRules {
+		for _, prefix := range []string{rule.PrefixLow, rule.PrefixHigh} {
+			for _, length := range rule.Lengths {
+				number := luhnNumber(prefix, length)
+				network, _ := matchNetwork(number)
+				if fromBIN := NetworkFromBIN(number[:StandardBINLength]); network != fromBIN {
+					t.Errorf("%v: %s (%d digits) matchNetwork = %q, NetworkFromBIN = %q", rule, number, length, network, fromBIN)
+				}
+			}
+		}
+	}
+
+	// The parity cases above, by network and length
+	corpus := []struct {
+		prefix string
+		length int
+	}{
+		{"34", 15}, {"37", 15},
+		{"4", 13}, {"4", 16}, {"4", 19},
+	}
+	for _, c := range corpus {
+		number := luhnNumber(c.prefix, c.length)
+		network, _ := matchNetwork(number)
+		if fromBIN := NetworkFromBIN(number[:StandardBINLength]); network != fromBIN {
+			t.Errorf("%s: matchNetwork = %q, NetworkFromBIN = %q", number, network, fromBIN)
+		}
+	}
+}
+
+func TestMinCardLength(t *testing.T) {
+	// 12-digit Maestro is the shortest real card
+	if info := ValidateCard(CardValidationRequest{CardNumber: luhnNumber("5018", 12)}); !info.Valid {
+		t.Errorf("12 digits: valid %v, want true", info.Valid)
+	}
+	info := ValidateCard(CardValidationRequest{CardNumber: luhnNumber("5018", 11)})
+	if info.Valid || info.NetworkStatus != NetworkIndeterminate {
+		t.Errorf("11 digits: valid %v, network status %q; want invalid and indeterminate", info.Valid, info.NetworkStatus)
+	}
+
+	previous := MinCardLength
+	MinCardLength = 14
+	defer func() { MinCardLength = previous }()
+
+	if info := ValidateCard(CardValidationRequest{CardNumber: luhnNumber("5018", 13)}); info.Valid {
+		t.Error("13 digits passed with MinCardLength 14")
+	}
+	if info := ValidateCard(CardValidationRequest{CardNumber: luhnNumber("5018", 14)}); !info.Valid {
+		t.Error("14 digits failed with MinCardLength 14")
+	}
+}
+
+// setISOBounds sets the ISO/IEC 7812 PAN length bounds for one test
+func setISOBounds(t *testing.T, min, max int) {
+	t.Helper()
+	previousMin, previousMax := ISOMinPANLength, ISOMaxPANLength
+	ISOMinPANLength, ISOMaxPANLength = min, max
+	t.Cleanup(func() { ISOMinPANLength, ISOMaxPANLength = previousMin, previousMax })
+}
+
+func TestLengthGateBoundaries(t *testing.T) {
+	// The gate runs from MinCardLength, above the ISO minimum, to the ISO maximum
+	tests := []struct {
+		length     int
+		inRange    bool
+		wantStatus string
+	}{
+		{7, false, NetworkIndeterminate},
+		{8, false, NetworkIndeterminate},
+		{11, false, NetworkIndeterminate},
+		{13, true, NetworkIdentified},
+		{19, true, NetworkIdentified},
+		{20, false, NetworkUnknown},
+	}
+
+	if got := MinPANLength(); got != MinCardLength {
+		t.Errorf("MinPANLength() = %d, want MinCardLength %d", got, MinCardLength)
+	}
+	for _, tt := range tests {
+		if got := InLengthRange(tt.length); got != tt.inRange {
+			t.Errorf("InLengthRange(%d) = %v, want %v", tt.length, got, tt.inRange)
+		}
+
+		info := ValidateCard(CardValidationRequest{CardNumber: luhnNumber("4", tt.length)})
+		if outOfRange := info.Outcome == OutcomePANLengthOutOfRange; outOfRange == tt.inRange {
+			t.Errorf("%d digits: outcome %q, want in range %v", tt.length, info.Outcome, tt.inRange)
+		}
+		if info.CardLength != tt.length || info.NetworkStatus != tt.wantStatus {
+			t.Errorf("%d digits: length %d, network status %q; want %q", tt.length, info.CardLength, info.NetworkStatus, tt.wantStatus)
+		}
+		if !tt.inRange && (info.Valid || info.Network != "") {
+			t.Errorf("%d digits: valid %v, network %q; want rejected before network detection", tt.length, info.Valid, info.Network)
+		}
+	}
+
+	// With the card minimum lowered to the ISO minimum, 8 digits reach network detection
+	previous := MinCardLength
+	MinCardLength = ISOMinPANLength
+	defer func() { MinCardLength = previous }()
+	if info := ValidateCard(CardValidationRequest{CardNumber: luhnNumber("4", 8)}); info.PrefixNetwork != "Visa" || info.Outcome == OutcomePANLengthOutOfRange {
+		t.Errorf("8 digits with MinCardLength 8: prefix network %q, outcome %q", info.PrefixNetwork, info.Outcome)
+	}
+
+	// Below the ISO minimum, the ISO minimum still applies
+	MinCardLength = 4
+	if got := MinPANLength(); got != ISOMinPANLength {
+		t.Errorf("MinPANLength() with MinCardLength 4 = %d, want %d", got, ISOMinPANLength)
+	}
+	if info := ValidateCard(CardValidationRequest{CardNumber: luhnNumber("4", 7)}); info.Outcome != OutcomePANLengthOutOfRange {
+		t.Errorf("7 digits with MinCardLength 4: outcome %q", info.Outcome)
+	}
+}
+
+func TestISOLengthBoundsConfigurable(t *testing.T) {
+	setISOBounds(t, 13, 16)
+	for length, inRange := range map[int]bool{12: false, 13: true, 16: true, 19: false} {
+		info := ValidateCard(CardValidationRequest{CardNumber: luhnNumber("4", length)})
+		if outOfRange := info.Outcome == OutcomePANLengthOutOfRange; outOfRange == inRange {
+			t.Errorf("bounds 13-16, %d digits: outcome %q, want in range %v", length, info.Outcome, inRange)
+		}
+		// DetectAndValidate applies the same bounds
+		if got := DetectAndValidate(CardValidationRequest{CardNumber: luhnNumber("4", length)}).Outcome; got != info.Outcome {
+			t.Errorf("bounds 13-16, %d digits: DetectAndValidate outcome %q, ValidateCard %q", length, got, info.Outcome)
+		}
+	}
+}
+
+func TestCVVLengthFollowsNetwork(t *testing.T) {
+	tests := []struct {
+		number string
+		cvv    string
+		want   bool
+	}{
+		{"4111111111111111", "123", true},
+		{"4111111111111111", "1234", false}, // 4-digit CVV on a Visa
+		{"5555555555554444", "1234", false},
+		{"378282246310005", "1234", true},
+		{"378282246310005", "123", false},
+		{"4111111111111111", "12a", false},
+	}
+
+	for _, tt := range tests {
+		info := ValidateCard(CardValidationRequest{CardNumber: tt.number, CVV: tt.cvv})
+		if !info.CVVProvided || info.CVVValid != tt.want {
+			t.Errorf("%s with CVV %q: provided %v, valid %v; want valid %v", info.Network, tt.cvv, info.CVVProvided, info.CVVValid, tt.want)
+		}
+		// A wrong CVV length does not make the card itself invalid
+		if !info.Valid {
+			t.Errorf("%s with CVV %q: card reported invalid", info.Network, tt.cvv)
+		}
+	}
+}
+
+// randomDigits returns a reproducible string of n random digits
+func randomDigits(n int) string {
+	rng := rand.New(rand.NewSource(int64(n)))
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte('0' + rng.Intn(10))
+	}
+	return string(b)
+}
+
+func TestCVVRequiredNetworks(t *testing.T) {
+	amex := CardValidationRequest{CardNumber: "378282246310005", Config: DefaultValidationConfig()}
+
+	// No requirement by default
+	if info := ValidateCard(amex); info.CVVMissing || info.Outcome != OutcomeValid {
+		t.Errorf("Amex without CVV and no policy: CVV missing %v, outcome %q; want valid", info.CVVMissing, info.Outcome)
+	}
+
+	// Names match case-insensitively, ignoring surrounding space
+	config := ValidationConfig{CVVRequiredNetworks: []string{" american express "}}
+	amex.Config = config
+	visa := CardValidationRequest{CardNumber: "4111111111111111", Config: config}
+	if info := ValidateCard(amex); !info.CVVMissing || info.Outcome != OutcomeMissingCVV || !info.Valid {
+		t.Errorf("Amex without CVV: CVV missing %v, outcome %q, valid %v; want missing_cvv on a valid number",
+			info.CVVMissing, info.Outcome, info.Valid)
+	}
+
+	amex.CVV = "1234"
+	if info := ValidateCard(amex); info.CVVMissing || info.Outcome != OutcomeValid {
+		t.Errorf("Amex with CVV: CVV missing %v, outcome %q; want valid", info.CVVMissing, info.Outcome)
+	}
+	if info := ValidateCard(visa); info.CVVMissing || info.Outcome != OutcomeValid {
+		t.Errorf("Visa without CVV: CVV missing %v, outcome %q; want valid", info.CVVMissing, info.Outcome)
+	}
+}
+
+func TestSecurityCodeLocation(t *testing.T) {
+	tests := []struct {
+		number   string
+		location string
+	}{
+		{"378282246310005", "front"}, // American Express CID
+		{"4111111111111111", "back"}, // Visa
+		{"5500000000000004", "back"}, // Mastercard
+		{"6011111111111117", "back"}, // Discover
+		{luhnNumber("9", 16), ""},    // no network, no hint
+		{"4111", ""},                 // too short to classify
+	}
+
+	for _, tt := range tests {
+		if got := ValidateCard(CardValidationRequest{CardNumber: tt.number}).SecurityCodeLocation; got != tt.location {
+			t.Errorf("%s: security code location %q, want %q", tt.number, got, tt.location)
+		}
+	}
+}
+
+func BenchmarkIdentifyCardNetwork(b *testing.B) {
+	for _, n := range []int{16, 1000, 100000} {
+		for _, prefix := range []string{"4", "6011", "2720", "9"} {
+			number := prefix + randomDigits(n-len(prefix))
+			b.Run(fmt.Sprintf("%s/%d", prefix, n), func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					identifyCardNetwork(number)
+				}
+			})
+		}
+	}
+}
+
+func FuzzIdentifyCardNetwork(f *testing.F) {
+	for _, seed := range []string{"4111111111111111", "6011000000000004", "2221000000000009", randomDigits(5000), ""} {
+		f.Add(seed)
+	}
+
+	maxLength := 0
+	for _, rule := range Rules() {
+		if longest := rule.Lengths[len(rule.Lengths)-1]; longest > maxLength {
+			maxLength = longest
+		}
+	}
+
+	f.Fuzz(func(t *testing.T, number string) {
+		network := identifyCardNetwork(number)
+		// No rule allows more digits than maxLength, however the prefix reads
+		if len(number) > maxLength && network != "Unknown" {
+			t.Errorf("%d-character input matched %q", len(number), network)
+		}
+	})
+}
+
+func TestExpiryMonthBoundaries(t *testing.T) {
+	tests := []struct {
+		name   string
+		grace  int
+		expiry string
+		now    time.Time
+		want   bool
+	}{
+		{"first day of expiry month", 0, "09/27", time.Date(2027, 9, 1, 0, 0, 0, 0, time.UTC), true},
+		{"last moment of expiry month", 0, "09/27", time.Date(2027, 9, 30, 23, 59, 59, 0, time.UTC), true},
+		{"first day after expiry month", 0, "09/27", time.Date(2027, 10, 1, 0, 0, 0, 0, time.UTC), false},
+		{"within grace", 5, "09/27", time.Date(2027, 10, 5, 23, 59, 59, 0, time.UTC), true},
+		{"grace ended", 5, "09/27", time.Date(2027, 10, 6, 0, 0, 0, 0, time.UTC), false},
+		{"grace across the year end", 3, "12/27", time.Date(2028, 1, 3, 12, 0, 0, 0, time.UTC), true},
+		{"grace ended across the year end", 3, "12/27", time.Date(2028, 1, 4, 0, 0, 0, 0, time.UTC), false},
+		{"grace does not revive older cards", 5, "08/27", time.Date(2027, 10, 2, 0, 0, 0, 0, time.UTC), false},
+		{"negative grace is none", -5, "09/27", time.Date(2027, 10, 1, 0, 0, 0, 0, time.UTC), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := ValidationConfig{GracePeriodDays: tt.grace}
+			info := ValidateCard(CardValidationRequest{CardNumber: "4111111111111111", ExpiryDate: tt.expiry, Clock: FixedClock(tt.now), Config: config})
+			if info.ExpiryValid != tt.want {
+				t.Errorf("expiry %s at %s with %d days grace: valid %v, want %v", tt.expiry, tt.now.Format(time.RFC3339), tt.grace, info.ExpiryValid, tt.want)
+			}
+		})
+	}
+}
+
+func TestFixedClock(t *testing.T) {
+	pinned := time.Date(2031, time.March, 15, 9, 30, 0, 0, time.UTC)
+	if got := FixedClock(pinned).Now(); !got.Equal(pinned) {
+		t.Errorf("FixedClock.Now() = %v, want %v", got, pinned)
+	}
+
+	// Without a clock the system clock is used
+	before := time.Now()
+	now := CardValidationRequest{}.clock().Now()
+	if now.Before(before) || now.After(time.Now()) {
+		t.Errorf("default clock = %v, want the current time", now)
+	}
+}
+
+func TestExpiryWithPinnedClock(t *testing.T) {
+	tests := []struct {
+		name   string
+		now    time.Time
+		expiry string
+		valid  bool
+	}{
+		{"last day of the year", time.Date(2029, 12, 31, 23, 59, 59, 0, time.UTC), "12/29", true},
+		{"first day of the next year", time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC), "12/29", false},
+		{"next month", time.Date(2029, 12, 31, 0, 0, 0, 0, time.UTC), "01/30", true},
+		{"last month", time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC), "11/29", false},
+		{"20 years out", time.Date(2030, 6, 1, 0, 0, 0, 0, time.UTC), "12/50", true},
+		{"more than 20 years out", time.Date(2030, 6, 1, 0, 0, 0, 0, time.UTC), "01/51", false},
+	}
+
+	for _, tt := range tests {
+		info := ValidateCard(CardValidationRequest{CardNumber: "4111111111111111", ExpiryDate: tt.expiry, Clock: FixedClock(tt.now)})
+		if info.ExpiryValid != tt.valid || !info.ExpiryFormatOK {
+			t.Errorf("%s: expiry %s at %s: valid %v, format ok %v; want valid %v",
+				tt.name, tt.expiry, tt.now.Format(time.RFC3339), info.ExpiryValid, info.ExpiryFormatOK, tt.valid)
+		}
+
+		// The renewal expiry uses the same clock
+		info = ValidateCard(CardValidationRequest{CardNumber: "4111111111111111", ExpiryDate: "01/20", NewExpiryDate: tt.expiry, Clock: FixedClock(tt.now)})
+		if info.NewExpiryValid != tt.valid {
+			t.Errorf("%s: new expiry %s valid %v, want %v", tt.name, tt.expiry, info.NewExpiryValid, tt.valid)
+		}
+	}
+}
+
+func TestExpiryISO(t *testing.T) {
+	clock := FixedClock(time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC))
+	tests := map[string]string{
+		"02/24": "2024-02-29", // leap year
+		"02/25": "2025-02-28",
+		"02/28": "2028-02-29",
+		"04/26": "2026-04-30",
+		"09/27": "2027-09-30",
+		"12/27": "2027-12-31",
+		"01/28": "2028-01-31",
+	}
+
+	for expiry, want := range tests {
+		info := ValidateCard(CardValidationRequest{CardNumber: "4111111111111111", ExpiryDate: expiry, Clock: clock})
+		if info.ExpiryISO != want {
+			t.Errorf("expiry %s: ExpiryISO = %q, want %q", expiry, info.ExpiryISO, want)
+		}
+	}
+
+	// 2000 is a leap year although it is a century
+	if got := expiryISODate("02/00"); got != "2000-02-29" {
+		t.Errorf("expiryISODate(02/00) = %q, want 2000-02-29", got)
+	}
+
+	// Only valid expiries get a date
+	for _, expiry := range []string{"12/23", "13/25", "2/25"} {
+		info := ValidateCard(CardValidationRequest{CardNumber: "4111111111111111", ExpiryDate: expiry, Clock: clock})
+		if info.ExpiryISO != "" {
+			t.Errorf("expiry %s: ExpiryISO = %q, want none", expiry, info.ExpiryISO)
+		}
+	}
+}
+
+func TestRenewal(t *testing.T) {
+	clock := FixedClock(time.Date(2025, time.June, 15, 0, 0, 0, 0, time.UTC))
+	tests := []struct {
+		name                          string
+		old, new                      string
+		formatOK, newValid, renewalOK bool
+	}{
+		{"renewal", "09/25", "09/28", true, true, true},
+		{"one month later", "09/25", "10/25", true, true, true},
+		{"downgrade", "09/28", "09/25", true, true, false},
+		{"same month", "09/25", "09/25", true, true, false},
+		{"earlier month of a later year", "12/25", "01/26", true, true, true},
+		{"expired new expiry", "01/24", "05/25", true, false, false},
+		{"malformed new expiry", "09/25", "13/28", false, false, false},
+		{"expired old expiry", "01/24", "09/28", true, true, true},
+		{"malformed old expiry", "9/25", "09/28", true, true, false},
+	}
+
+	for _, tt := range tests {
+		info := ValidateCard(CardValidationRequest{CardNumber: "4111111111111111", ExpiryDate: tt.old, NewExpiryDate: tt.new, Clock: clock})
+		if info.NewExpiryFormatOK != tt.formatOK || info.NewExpiryValid != tt.newValid || info.RenewalValid != tt.renewalOK {
+			t.Errorf("%s: %s to %s: format ok %v, new valid %v, renewal %v; want %v, %v, %v", tt.name, tt.old, tt.new,
+				info.NewExpiryFormatOK, info.NewExpiryValid, info.RenewalValid, tt.formatOK, tt.newValid, tt.renewalOK)
+		}
+	}
+
+	// The old expiry is still reported on its own, so an expired card can renew
+	info := ValidateCard(CardValidationRequest{CardNumber: "4111111111111111", ExpiryDate: "01/24", NewExpiryDate: "09/28", Clock: clock})
+	if info.ExpiryValid {
+		t.Error("expired old expiry reported valid")
+	}
+
+	// Without a new expiry none of the renewal fields are set
+	info = ValidateCard(CardValidationRequest{CardNumber: "4111111111111111", ExpiryDate: "09/25", Clock: clock})
+	if info.NewExpiryFormatOK || info.NewExpiryValid || info.RenewalValid {
+		t.Errorf("no new expiry: format ok %v, new valid %v, renewal %v", info.NewExpiryFormatOK, info.NewExpiryValid, info.RenewalValid)
+	}
+}
+
+func TestExpiryAfter(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"10/25", "09/25", true},
+		{"01/26", "12/25", true},
+		{"09/25", "09/25", false},
+		{"09/25", "10/25", false},
+		{"12/25", "01/26", false},
+		{"bad", "09/25", false},
+		{"09/25", "", false},
+	}
+	for _, tt := range tests {
+		if got := expiryAfter(tt.a, tt.b); got != tt.want {
+			t.Errorf("expiryAfter(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestNetworkStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		number  string
+		status  string
+		network string
+	}{
+		{"4-digit prefix", "4111", NetworkIndeterminate, ""},
+		{"partially typed", "4111 1111 11", NetworkIndeterminate, ""},
+		{"complete, no match", luhnNumber("9", 16), NetworkUnknown, "Unknown"},
+		{"longer than any PAN", strings.Repeat("4", 20), NetworkUnknown, ""},
+		{"complete Visa", "4111111111111111", NetworkIdentified, "Visa"},
+	}
+
+	for _, tt := range tests {
+		info := ValidateCard(CardValidationRequest{CardNumber: tt.number})
+		if info.NetworkStatus != tt.status || info.Network != tt.network {
+			t.Errorf("%s: network status %q, network %q; want %q, %q", tt.name, info.NetworkStatus, info.Network, tt.status, tt.network)
+		}
+	}
+
+	info := ValidateCard(CardValidationRequest{CardNumber: "4111111111111111", LuhnOnly: true})
+	if info.NetworkStatus != NetworkSkipped || info.Network != NetworkNotApplicable {
+		t.Errorf("Luhn-only: network status %q, network %q; want skipped and N/A", info.NetworkStatus, info.Network)
+	}
+}
+
+func TestValidateCardWithoutDigits(t *testing.T) {
+	for _, number := range []string{"", "----", "    ", " -\t- "} {
+		info := ValidateCard(CardValidationRequest{CardNumber: number})
+		if info.Valid || info.CardLength != 0 || info.ChecksumValid {
+			t.Errorf("%q: valid %v, length %d, checksum valid %v; want an invalid empty number", number, info.Valid, info.CardLength, info.ChecksumValid)
+		}
+	}
+}
+
+func TestValidateCleanedMatchesValidateCard(t *testing.T) {
+	future := time.Now().AddDate(2, 0, 0).Format("01/06")
+	for _, number := range []string{
+		"4111111111111111",
+		"4111111111111112",
+		"378282246310005",
+		"4111 1111 1111 1111", // not clean: falls back to ValidateCard
+		"4111-1111-1111-1111",
+		"４１１１１１１１１１１１１１１１",
+		"",
+		"41",
+	} {
+		request := CardValidationRequest{CardNumber: number, ExpiryDate: future, CVV: "123"}
+		if got, want := ValidateCleaned(request), ValidateCard(request); !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: ValidateCleaned = %+v\nValidateCard = %+v", number, got, want)
+		}
+	}
+}
+
+func TestDetectAndValidate(t *testing.T) {
+	// Numbers that pass the check digit, or that are too short or long to check, match ValidateCard
+	for _, number := range []string{"4111111111111111", "4111 1111 1111 1111", "378282246310005", "4111", "", "41111111111111111111"} {
+		request := CardValidationRequest{CardNumber: number, ExpiryDate: "09/27", CVV: "123"}
+		if got, want := DetectAndValidate(request), ValidateCard(request); !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: DetectAndValidate = %+v, want %+v", number, got, want)
+		}
+	}
+
+	// A check digit failure returns before network detection
+	info := DetectAndValidate(CardValidationRequest{CardNumber: "4111 1111 1111 1112", ExpiryDate: "09/27", CVV: "123"})
+	want := CardInfo{CardLength: 16, Outcome: OutcomeInvalidLuhn}
+	if !reflect.DeepEqual(info, want) {
+		t.Errorf("invalid number: DetectAndValidate = %+v, want %+v", info, want)
+	}
+
+	// The request's algorithm decides what counts as a failure
+	info = DetectAndValidate(CardValidationRequest{CardNumber: "4111111111111112", Algorithm: CheckNone})
+	if info.Network != "Visa" || !info.ChecksumValid {
+		t.Errorf("CheckNone: network %q, checksum valid %v; want a detected Visa", info.Network, info.ChecksumValid)
+	}
+}
+
+func BenchmarkValidateCard(b *testing.B) {
+	request := CardValidationRequest{CardNumber: "4111111111111111"}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ValidateCard(request)
+	}
+}
+
+func BenchmarkValidateCleaned(b *testing.B) {
+	request := CardValidationRequest{CardNumber: "4111111111111111"}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ValidateCleaned(request)
+	}
+}
+
+// BenchmarkValidateCardFormatted measures ValidateCard on input that needs cleaning
+func BenchmarkValidateCardFormatted(b *testing.B) {
+	request := CardValidationRequest{CardNumber: "4111 1111 1111 1111"}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ValidateCard(request)
+	}
+}
+
+// BenchmarkValidateCardInvalid is the baseline for BenchmarkDetectAndValidateInvalid
+func BenchmarkValidateCardInvalid(b *testing.B) {
+	request := CardValidationRequest{CardNumber: "4111 1111 1111 1112"}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ValidateCard(request)
+	}
+}
+
+func BenchmarkDetectAndValidateInvalid(b *testing.B) {
+	request := CardValidationRequest{CardNumber: "4111 1111 1111 1112"}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		DetectAndValidate(request)
+	}
+}
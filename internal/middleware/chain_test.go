@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestChainForwardsSanitizedBody posts a real body through every layer that reads and
+// restores it, in the order main.go applies them, and checks the handler sees the sanitized body
+func TestChainForwardsSanitizedBody(t *testing.T) {
+	limiter := newTestRateLimiter(t, RateLimiterConfig{Rate: 100, BucketSize: 100, CleanupInterval: time.Minute})
+	dedupe := NewDeduplicator(DefaultDedupeConfig())
+	sanitizer := NewInputSanitizer(DefaultSanitizationConfig())
+
+	var seen map[string]interface{}
+	calls := 0
+	var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Fatalf("read body: %v", err)
+		}
+		seen = nil
+		if err := json.Unmarshal(data, &seen); err != nil {
+			t.Fatalf("handler body is not JSON: %v (%q)", err, data)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"valid":true}`))
+	})
+	handler = sanitizer.SanitizeMiddleware(handler)
+	handler = RequireJSONMiddleware(handler)
+	handler = limiter.RateLimitMiddleware(handler)
+	handler = dedupe.DedupeMiddleware(handler)
+	handler = LoggingMiddleware(handler)
+
+	post := func(body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/validate", strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	w := post(`{"card_number":"4111 1111-1111 1111","expiry_date":"12/30","cvv":"123"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body)
+	}
+	if seen["card_number"] != "4111111111111111" || seen["expiry_date"] != "12/30" || seen["cvv"] != "123" {
+		t.Errorf("handler saw %v, want the sanitized body", seen)
+	}
+
+	// A different body is not mistaken for the first, so each layer saw the whole new body
+	w = post(`{"card_number":"5555 5555 5555 4444"}`)
+	if w.Code != http.StatusOK || calls != 2 {
+		t.Fatalf("status = %d after %d handler calls", w.Code, calls)
+	}
+	if seen["card_number"] != "5555555555554444" {
+		t.Errorf("handler saw %v, want the second card", seen)
+	}
+}
@@ -0,0 +1,154 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"google.golang.org/grpc/peer"
+)
+
+// HopStrategy selects which X-Forwarded-For entry is treated as the client
+type HopStrategy int
+
+const (
+	// HopLeftmost uses the first X-Forwarded-For entry. It is only safe when every
+	// proxy in front of the service overwrites the header, since clients can set it.
+	HopLeftmost HopStrategy = iota
+
+	// HopRightmostTrusted walks X-Forwarded-For from the right, skipping trusted
+	// proxies, and uses the first address that is not one of them
+	HopRightmostTrusted
+)
+
+// ParseHopStrategy converts "leftmost" or "rightmost" into a HopStrategy
+func ParseHopStrategy(s string) (HopStrategy, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "leftmost":
+		return HopLeftmost, nil
+	case "rightmost", "rightmost-trusted":
+		return HopRightmostTrusted, nil
+	}
+	return HopLeftmost, fmt.Errorf("unknown client IP strategy %q", s)
+}
+
+// ClientIPConfig defines how the client address is derived from proxy headers
+type ClientIPConfig struct {
+	Strategy       HopStrategy
+	TrustedProxies []string // IPs or CIDRs of proxies allowed to append X-Forwarded-For hops
+}
+
+// DefaultClientIPConfig returns a default configuration
+func DefaultClientIPConfig() ClientIPConfig {
+	return ClientIPConfig{
+		Strategy: HopLeftmost,
+	}
+}
+
+// clientIPResolver holds the parsed client IP configuration
+type clientIPResolver struct {
+	strategy HopStrategy
+	trusted  []*net.IPNet
+}
+
+// clientIP is the resolver used by getClientIP. It is set once at startup by ConfigureClientIP.
+var clientIP = clientIPResolver{strategy: HopLeftmost}
+
+// ConfigureClientIP sets how getClientIP resolves the client address, returning an error if
+// any trusted proxy CIDR is malformed. It must be called before the server starts handling requests.
+func ConfigureClientIP(config ClientIPConfig) error {
+	trusted, err := parseCIDRs(config.TrustedProxies)
+	if err != nil {
+		return err
+	}
+	clientIP = clientIPResolver{strategy: config.Strategy, trusted: trusted}
+	return nil
+}
+
+// getClientIP extracts the client's IP address from the request
+func getClientIP(r *http.Request) string {
+	return clientIP.resolve(r)
+}
+
+// resolve applies the configured hop strategy to the request
+func (c clientIPResolver) resolve(r *http.Request) string {
+	remote := remoteIP(r.RemoteAddr)
+
+	if c.strategy == HopRightmostTrusted {
+		// Headers are only meaningful when they were added by one of our proxies
+		if !c.isTrusted(remote) {
+			return remote
+		}
+
+		hops := forwardedHops(r.Header.Get("X-Forwarded-For"))
+		for i := len(hops) - 1; i >= 0; i-- {
+			if !c.isTrusted(hops[i]) {
+				return hops[i]
+			}
+		}
+
+		// Every hop is a trusted proxy: the leftmost one is the closest thing to a client
+		if len(hops) > 0 {
+			return hops[0]
+		}
+		if ip := strings.TrimSpace(r.Header.Get("X-Real-IP")); ip != "" {
+			return ip
+		}
+		return remote
+	}
+
+	// Try different headers that might contain the real client IP
+	for _, header := range []string{"X-Forwarded-For", "X-Real-IP"} {
+		if hops := forwardedHops(r.Header.Get(header)); len(hops) > 0 {
+			// X-Forwarded-For can contain multiple IPs; use the first one
+			return hops[0]
+		}
+	}
+
+	// Fall back to remote address
+	return remote
+}
+
+// isTrusted checks if the IP falls within any trusted proxy network
+func (c clientIPResolver) isTrusted(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, network := range c.trusted {
+		if network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// forwardedHops splits a comma-separated forwarding header into trimmed, non-empty entries
+func forwardedHops(header string) []string {
+	var hops []string
+	for _, hop := range strings.Split(header, ",") {
+		if hop = strings.TrimSpace(hop); hop != "" {
+			hops = append(hops, hop)
+		}
+	}
+	return hops
+}
+
+// remoteIP strips the port from a RemoteAddr, handling bracketed IPv6 addresses
+func remoteIP(remoteAddr string) string {
+	if host, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		return host
+	}
+	return remoteAddr
+}
+
+// peerIP returns the address of a gRPC call's peer. Proxy metadata is not consulted, so
+// gRPC clients are identified by their connection alone.
+func peerIP(ctx context.Context) string {
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		return remoteIP(p.Addr.String())
+	}
+	return ""
+}
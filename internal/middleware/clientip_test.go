@@ -0,0 +1,114 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// setClientIP configures client IP resolution for one test
+func setClientIP(t *testing.T, config ClientIPConfig) {
+	t.Helper()
+	previous := clientIP
+	if err := ConfigureClientIP(config); err != nil {
+		t.Fatalf("ConfigureClientIP: %v", err)
+	}
+	t.Cleanup(func() { clientIP = previous })
+}
+
+// forwardedRequest builds a request from remoteAddr carrying the given X-Forwarded-For header
+func forwardedRequest(remoteAddr, forwardedFor string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = remoteAddr
+	if forwardedFor != "" {
+		r.Header.Set("X-Forwarded-For", forwardedFor)
+	}
+	return r
+}
+
+func TestClientIPMultiHop(t *testing.T) {
+	// The client forged 203.0.113.9; the edge proxy appended the real client, 198.51.100.4,
+	// and the internal proxy 10.0.0.2 appended the edge proxy 10.0.0.1
+	const header = "203.0.113.9, 198.51.100.4, 10.0.0.1"
+	const remote = "10.0.0.2:4711"
+
+	tests := []struct {
+		name   string
+		config ClientIPConfig
+		want   string
+	}{
+		{"leftmost", ClientIPConfig{Strategy: HopLeftmost}, "203.0.113.9"},
+		{"leftmost ignores trusted proxies", ClientIPConfig{Strategy: HopLeftmost, TrustedProxies: []string{"10.0.0.0/8"}}, "203.0.113.9"},
+		{"rightmost trusted", ClientIPConfig{Strategy: HopRightmostTrusted, TrustedProxies: []string{"10.0.0.0/8"}}, "198.51.100.4"},
+		{"rightmost with single proxy IPs", ClientIPConfig{Strategy: HopRightmostTrusted, TrustedProxies: []string{"10.0.0.1", "10.0.0.2"}}, "198.51.100.4"},
+		{"rightmost trusting only the direct peer", ClientIPConfig{Strategy: HopRightmostTrusted, TrustedProxies: []string{"10.0.0.2"}}, "10.0.0.1"},
+		{"rightmost with an untrusted peer", ClientIPConfig{Strategy: HopRightmostTrusted, TrustedProxies: []string{"192.0.2.0/24"}}, "10.0.0.2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setClientIP(t, tt.config)
+			if got := getClientIP(forwardedRequest(remote, header)); got != tt.want {
+				t.Errorf("client IP = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientIPRightmostAllTrusted(t *testing.T) {
+	setClientIP(t, ClientIPConfig{Strategy: HopRightmostTrusted, TrustedProxies: []string{"10.0.0.0/8"}})
+
+	if got := getClientIP(forwardedRequest("10.0.0.2:4711", "10.0.0.5, 10.0.0.1")); got != "10.0.0.5" {
+		t.Errorf("client IP = %q, want the leftmost hop", got)
+	}
+
+	r := forwardedRequest("10.0.0.2:4711", "")
+	r.Header.Set("X-Real-IP", "198.51.100.4")
+	if got := getClientIP(r); got != "198.51.100.4" {
+		t.Errorf("client IP = %q, want X-Real-IP", got)
+	}
+
+	if got := getClientIP(forwardedRequest("10.0.0.2:4711", "")); got != "10.0.0.2" {
+		t.Errorf("client IP = %q, want the remote address", got)
+	}
+}
+
+func TestClientIPRemoteAddr(t *testing.T) {
+	setClientIP(t, DefaultClientIPConfig())
+
+	if got := getClientIP(forwardedRequest("[2001:db8::1]:4711", "")); got != "2001:db8::1" {
+		t.Errorf("client IP = %q, want 2001:db8::1", got)
+	}
+	if got := getClientIP(forwardedRequest("198.51.100.4:4711", " , 203.0.113.9")); got != "203.0.113.9" {
+		t.Errorf("client IP = %q, want the first non-empty hop", got)
+	}
+}
+
+func TestParseHopStrategy(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    HopStrategy
+		wantErr bool
+	}{
+		{"", HopLeftmost, false},
+		{"leftmost", HopLeftmost, false},
+		{"Rightmost", HopRightmostTrusted, false},
+		{"rightmost-trusted", HopRightmostTrusted, false},
+		{"middle", HopLeftmost, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseHopStrategy(tt.input)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("ParseHopStrategy(%q) = %v, %v; want %v, error %v", tt.input, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestConfigureClientIPRejectsBadCIDR(t *testing.T) {
+	previous := clientIP
+	t.Cleanup(func() { clientIP = previous })
+
+	if err := ConfigureClientIP(ClientIPConfig{Strategy: HopRightmostTrusted, TrustedProxies: []string{"10.0.0.0/33"}}); err == nil {
+		t.Error("expected an error for a malformed CIDR")
+	}
+}
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"net/http"
+)
+
+// RequireJSONMiddleware rejects requests with a body whose Content-Type is not application/json.
+// Parameters such as charset are ignored; bodyless requests such as plain GETs pass through.
+func RequireJSONMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hasBody(r) && !isJSONContentType(r.Header.Get("Content-Type")) {
+			logger := ApplicationLogger(r.Context())
+			logger.Warn().
+				Str("content_type", r.Header.Get("Content-Type")).
+				Msg("Rejected non-JSON request body")
+			WriteError(w, r, http.StatusUnsupportedMediaType, ErrCodeUnsupportedMediaType, "Content-Type must be application/json")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// hasBody reports whether a request carries, or is expected to carry, a body. Mutating methods
+// always count, even when empty, so a POST without a Content-Type is still rejected.
+func hasBody(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch:
+		return true
+	}
+	return r.ContentLength != 0
+}
@@ -0,0 +1,167 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// contentTypeStatus sends a request with the given Content-Type through RequireJSONMiddleware.
+// An empty contentType sends no header.
+func contentTypeStatus(method, contentType, body string) int {
+	handler := RequireJSONMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	var r *http.Request
+	if body == "" {
+		r = httptest.NewRequest(method, "/validate", nil)
+	} else {
+		r = httptest.NewRequest(method, "/validate", strings.NewReader(body))
+	}
+	if contentType != "" {
+		r.Header.Set("Content-Type", contentType)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w.Code
+}
+
+func TestRequireJSONAcceptsCharset(t *testing.T) {
+	for _, contentType := range []string{
+		"application/json",
+		"application/json; charset=utf-8",
+		"Application/JSON; charset=UTF-8",
+		"application/json;charset=utf-8",
+	} {
+		if got := contentTypeStatus(http.MethodPost, contentType, `{}`); got != http.StatusOK {
+			t.Errorf("Content-Type %q: status %d, want 200", contentType, got)
+		}
+	}
+}
+
+func TestSanitizerAcceptsCharset(t *testing.T) {
+	handler := NewInputSanitizer(DefaultSanitizationConfig()).SanitizeMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	r := httptest.NewRequest(http.MethodPost, "/validate", strings.NewReader(`{"card_number":"4111111111111111"}`))
+	r.Header.Set("Content-Type", "application/json; charset=utf-8")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Errorf("status = %d, want 200", w.Code)
+	}
+}
+
+func TestRequireJSONRejectsFormAndXML(t *testing.T) {
+	for _, method := range []string{http.MethodPost, http.MethodPut} {
+		for _, contentType := range []string{
+			"application/x-www-form-urlencoded",
+			"multipart/form-data; boundary=x",
+			"text/xml",
+			"application/xml; charset=utf-8",
+			"text/plain",
+			"application/jsonp",
+			"",
+		} {
+			if got := contentTypeStatus(method, contentType, `card_number=4111111111111111`); got != http.StatusUnsupportedMediaType {
+				t.Errorf("%s with Content-Type %q: status %d, want 415", method, contentType, got)
+			}
+		}
+	}
+}
+
+func TestRequireJSONIgnoresGET(t *testing.T) {
+	for _, contentType := range []string{"", "text/xml", "application/x-www-form-urlencoded"} {
+		if got := contentTypeStatus(http.MethodGet, contentType, ""); got != http.StatusOK {
+			t.Errorf("GET with Content-Type %q: status %d, want 200", contentType, got)
+		}
+	}
+}
+
+func TestHasBody(t *testing.T) {
+	tests := []struct {
+		method string
+		body   string
+		want   bool
+	}{
+		{http.MethodGet, "", false},
+		{http.MethodHead, "", false},
+		{http.MethodDelete, "", false},
+		{http.MethodGet, `{"card_number":"4111111111111111"}`, true},
+		{http.MethodPost, "", true},
+		{http.MethodPut, "", true},
+		{http.MethodPatch, `{}`, true},
+	}
+	for _, tt := range tests {
+		var r *http.Request
+		if tt.body == "" {
+			r = httptest.NewRequest(tt.method, "/validate", nil)
+		} else {
+			r = httptest.NewRequest(tt.method, "/validate", strings.NewReader(tt.body))
+		}
+		if got := hasBody(r); got != tt.want {
+			t.Errorf("%s with body %q: hasBody = %v, want %v", tt.method, tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestMissingContentType(t *testing.T) {
+	sanitizer := NewInputSanitizer(DefaultSanitizationConfig())
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	chains := map[string]http.Handler{
+		"RequireJSONMiddleware": RequireJSONMiddleware(ok),
+		"SanitizeMiddleware":    sanitizer.SanitizeMiddleware(ok),
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		body       string
+		wantStatus int
+	}{
+		{"bodyless GET", http.MethodGet, "/validate?card_number=4111111111111111", "", http.StatusOK},
+		{"POST with a body", http.MethodPost, "/validate", `{"card_number":"4111111111111111"}`, http.StatusUnsupportedMediaType},
+		{"empty POST", http.MethodPost, "/validate", "", http.StatusUnsupportedMediaType},
+		{"GET with a body", http.MethodGet, "/validate", `{"card_number":"4111111111111111"}`, http.StatusUnsupportedMediaType},
+	}
+
+	for chainName, handler := range chains {
+		for _, tt := range tests {
+			var r *http.Request
+			if tt.body == "" {
+				r = httptest.NewRequest(tt.method, tt.target, nil)
+			} else {
+				r = httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("%s, %s without Content-Type: status %d, want %d", chainName, tt.name, w.Code, tt.wantStatus)
+				continue
+			}
+			if tt.wantStatus == http.StatusUnsupportedMediaType {
+				var resp ErrorResponse
+				if err := json.NewDecoder(w.Body).Decode(&resp); err != nil || resp.Error.Code != ErrCodeUnsupportedMediaType {
+					t.Errorf("%s, %s: error %+v (%v), want %s", chainName, tt.name, resp.Error, err, ErrCodeUnsupportedMediaType)
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkRequireJSONMiddleware(b *testing.B) {
+	handler := RequireJSONMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	r := httptest.NewRequest(http.MethodPost, "/validate", strings.NewReader(`{}`))
+	r.Header.Set("Content-Type", "application/json; charset=utf-8")
+	w := httptest.NewRecorder()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		handler.ServeHTTP(w, r)
+	}
+}
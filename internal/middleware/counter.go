@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+// RequestCounter tracks served and in-flight requests across the process lifetime
+type RequestCounter struct {
+	served   atomic.Uint64
+	inFlight atomic.Int64
+}
+
+// NewRequestCounter creates a new request counter
+func NewRequestCounter() *RequestCounter {
+	return &RequestCounter{}
+}
+
+// CountMiddleware creates a middleware function that counts requests
+func (rc *RequestCounter) CountMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rc.inFlight.Add(1)
+		defer func() {
+			rc.inFlight.Add(-1)
+			rc.served.Add(1)
+		}()
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Served returns the number of completed requests
+func (rc *RequestCounter) Served() uint64 {
+	return rc.served.Load()
+}
+
+// InFlight returns the number of requests currently being handled
+func (rc *RequestCounter) InFlight() int64 {
+	return rc.inFlight.Load()
+}
@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestRequestCounterCountsEachRequest(t *testing.T) {
+	counter := NewRequestCounter()
+	handler := counter.CountMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for i := 1; i <= 3; i++ {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		if got := counter.Served(); got != uint64(i) {
+			t.Errorf("after %d requests, Served() = %d", i, got)
+		}
+	}
+	if got := counter.InFlight(); got != 0 {
+		t.Errorf("InFlight() = %d after all requests finished", got)
+	}
+}
+
+func TestRequestCounterInFlight(t *testing.T) {
+	counter := NewRequestCounter()
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	handler := counter.CountMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entered <- struct{}{}
+		<-release
+	}))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		}()
+	}
+	<-entered
+	<-entered
+
+	if got := counter.InFlight(); got != 2 {
+		t.Errorf("InFlight() = %d, want 2", got)
+	}
+	if got := counter.Served(); got != 0 {
+		t.Errorf("Served() = %d before any request finished", got)
+	}
+
+	close(release)
+	wg.Wait()
+	if counter.InFlight() != 0 || counter.Served() != 2 {
+		t.Errorf("InFlight() = %d, Served() = %d; want 0 and 2", counter.InFlight(), counter.Served())
+	}
+}
+
+func TestRequestCounterCountsPanickingRequests(t *testing.T) {
+	counter := NewRequestCounter()
+	handler := counter.CountMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	func() {
+		defer func() { recover() }()
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}()
+	if counter.InFlight() != 0 || counter.Served() != 1 {
+		t.Errorf("InFlight() = %d, Served() = %d; want 0 and 1", counter.InFlight(), counter.Served())
+	}
+}
@@ -0,0 +1,282 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jamesmeyerr/credit-card-validator/internal/luhn"
+)
+
+// DedupeConfig defines the duplicate request window
+type DedupeConfig struct {
+	Window     time.Duration // how long a response is replayed for identical requests
+	MaxEntries int           // responses held at once; new requests are not cached beyond this
+	Paths      []string      // paths whose POST requests are deduplicated
+
+	// VaryHeaders are request headers that shape the response, such as its language or key
+	// style. Requests that differ in any of them are never duplicates.
+	VaryHeaders []string
+}
+
+// DefaultDedupeWindow absorbs double-clicked submits without hiding deliberate retries
+const DefaultDedupeWindow = 2 * time.Second
+
+// DefaultDedupeConfig returns a default configuration
+func DefaultDedupeConfig() DedupeConfig {
+	return DedupeConfig{
+		Window:      DefaultDedupeWindow,
+		MaxEntries:  10000,
+		Paths:       []string{"/validate", "/validate/batch"},
+		VaryHeaders: []string{"Accept", "Accept-Language", "X-Case-Style"},
+	}
+}
+
+// Deduplicator replays the response to an identical request from the same client seen within
+// the window. It runs before the rate limiter, so a replayed request consumes no tokens.
+// Entries are keyed by an HMAC of the client IP, path, and cleaned body under a per-process
+// random key, so neither raw card numbers nor reversible hashes of them are held.
+type Deduplicator struct {
+	window     time.Duration
+	maxEntries int
+	paths      map[string]bool
+	vary       []string
+	key        []byte
+
+	mu      sync.Mutex
+	entries map[string]*dedupeEntry
+}
+
+// dedupeEntry is a response being produced or recently produced for one request key
+type dedupeEntry struct {
+	done    chan struct{} // closed once the response below is complete
+	expires time.Time
+	status  int
+	header  http.Header
+	body    []byte
+}
+
+// NewDeduplicator creates a new duplicate request window
+func NewDeduplicator(config DedupeConfig) *Deduplicator {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic("dedupe: cannot generate key: " + err.Error())
+	}
+
+	paths := make(map[string]bool, len(config.Paths))
+	for _, path := range config.Paths {
+		paths[path] = true
+	}
+
+	return &Deduplicator{
+		window:     config.Window,
+		maxEntries: config.MaxEntries,
+		paths:      paths,
+		vary:       config.VaryHeaders,
+		key:        key,
+		entries:    make(map[string]*dedupeEntry),
+	}
+}
+
+// DedupeMiddleware creates a middleware function that replays responses to duplicate requests.
+// A duplicate arriving while the first is still in flight waits for its response.
+func (d *Deduplicator) DedupeMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || !d.paths[r.URL.Path] || r.Body == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// Requests too large to buffer are passed through untouched
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxLoggedBodySize+1))
+		r.Body = readCloser{
+			Reader: io.MultiReader(bytes.NewReader(body), r.Body),
+			Closer: r.Body,
+		}
+		if err != nil || len(body) > maxLoggedBodySize {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key := d.requestKey(r, body)
+		entry, first := d.claim(key)
+		if entry == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if !first {
+			select {
+			case <-entry.done:
+			case <-r.Context().Done():
+				return
+			}
+			if entry.status == 0 {
+				// The original request failed to complete, so handle this one normally
+				next.ServeHTTP(w, r)
+				return
+			}
+			replay(w, entry)
+			return
+		}
+
+		rec := &dedupeRecorder{ResponseWriter: w, status: http.StatusOK}
+		finished := false
+		defer func() {
+			// A panicking handler leaves nothing worth replaying
+			if !finished {
+				rec.status = 0
+			}
+			d.complete(key, entry, rec)
+		}()
+		next.ServeHTTP(rec, r)
+		finished = true
+	})
+}
+
+// requestKey identifies a request by client, path, query, response-shaping headers, and body.
+// Card numbers are cleaned first so "4111 1111 1111 1111" and "4111111111111111" are duplicates.
+func (d *Deduplicator) requestKey(r *http.Request, body []byte) string {
+	canonical := body
+	var requestMap map[string]interface{}
+	if json.Unmarshal(body, &requestMap) == nil {
+		if cardNumber, ok := requestMap["card_number"].(string); ok {
+			requestMap["card_number"] = luhn.Clean(cardNumber)
+		}
+		// Marshalling sorts keys, so key order does not matter either
+		if encoded, err := json.Marshal(requestMap); err == nil {
+			canonical = encoded
+		}
+	}
+
+	mac := hmac.New(sha256.New, d.key)
+	parts := []string{getClientIP(r), r.URL.Path, r.URL.RawQuery}
+	for _, name := range d.vary {
+		parts = append(parts, strings.Join(r.Header.Values(name), ","))
+	}
+	for _, part := range parts {
+		mac.Write([]byte(part))
+		mac.Write([]byte{0})
+	}
+	mac.Write(canonical)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// claim returns the entry for key and whether the caller is the first to see it.
+// It returns nil if the request cannot be tracked because the window is full.
+func (d *Deduplicator) claim(key string) (*dedupeEntry, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	if entry, ok := d.entries[key]; ok {
+		if entry.expires.IsZero() || now.Before(entry.expires) {
+			return entry, false
+		}
+		delete(d.entries, key)
+	}
+
+	if len(d.entries) >= d.maxEntries {
+		d.evictExpired(now)
+		if len(d.entries) >= d.maxEntries {
+			return nil, false
+		}
+	}
+
+	entry := &dedupeEntry{done: make(chan struct{})}
+	d.entries[key] = entry
+	return entry, true
+}
+
+// complete stores the recorded response and releases any waiting duplicates.
+// Only successful responses are kept; errors are cleared so a retry is handled afresh.
+// Responses marked Cache-Control: no-store, such as those echoing the caller's card number,
+// are never kept, so no raw card data is held in memory.
+func (d *Deduplicator) complete(key string, entry *dedupeEntry, rec *dedupeRecorder) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if rec.status >= 200 && rec.status < 300 && !rec.overflow && !noStore(rec.Header()) {
+		entry.status = rec.status
+		entry.header = rec.Header().Clone()
+		entry.body = rec.body.Bytes()
+		entry.expires = time.Now().Add(d.window)
+	} else {
+		delete(d.entries, key)
+	}
+	close(entry.done)
+}
+
+// noStore reports whether the response forbids keeping a copy
+func noStore(header http.Header) bool {
+	for _, value := range header.Values("Cache-Control") {
+		for _, directive := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(directive), "no-store") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// evictExpired removes completed entries whose window has passed. The caller holds d.mu.
+func (d *Deduplicator) evictExpired(now time.Time) {
+	for key, entry := range d.entries {
+		if !entry.expires.IsZero() && !now.Before(entry.expires) {
+			delete(d.entries, key)
+		}
+	}
+}
+
+// replay writes a stored response, keeping this request's own ID
+func replay(w http.ResponseWriter, entry *dedupeEntry) {
+	for name, values := range entry.header {
+		if name == "X-Request-Id" {
+			continue
+		}
+		w.Header()[name] = append([]string(nil), values...)
+	}
+	w.Header().Set("X-Deduplicated", "true")
+	w.WriteHeader(entry.status)
+	w.Write(entry.body)
+}
+
+// dedupeRecorder passes a response through while keeping a copy for replay
+type dedupeRecorder struct {
+	http.ResponseWriter
+	status   int
+	body     bytes.Buffer
+	overflow bool // the response was too large to keep, or marked no-store
+}
+
+// WriteHeader captures the status code
+func (r *dedupeRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Write captures the response body
+func (r *dedupeRecorder) Write(b []byte) (int, error) {
+	if !r.overflow {
+		if r.body.Len()+len(b) > maxLoggedBodySize || noStore(r.Header()) {
+			r.overflow = true
+			r.body.Reset()
+		} else {
+			r.body.Write(b)
+		}
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+// Unwrap exposes the underlying writer so http.ResponseController can flush and set deadlines
+func (r *dedupeRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,259 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// dedupeTarget counts the requests that reach it and answers with a fixed JSON body
+type dedupeTarget struct {
+	calls  atomic.Int32
+	status int
+	header http.Header
+}
+
+func (h *dedupeTarget) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.calls.Add(1)
+	for name, values := range h.header {
+		w.Header()[name] = values
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Request-Id", r.Header.Get("X-Request-Id"))
+	if h.status != 0 {
+		w.WriteHeader(h.status)
+	}
+	w.Write([]byte(`{"valid":true}`))
+}
+
+// dedupeRequest sends a POST through handler from ip with optional header pairs
+func dedupeRequest(handler http.Handler, ip, path, body string, headers ...string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	r.RemoteAddr = ip + ":12345"
+	r.Header.Set("Content-Type", "application/json")
+	for i := 0; i+1 < len(headers); i += 2 {
+		r.Header.Set(headers[i], headers[i+1])
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
+
+func TestDedupeReplaysRapidDuplicate(t *testing.T) {
+	target := &dedupeTarget{}
+	handler := NewDeduplicator(DefaultDedupeConfig()).DedupeMiddleware(target)
+	body := `{"card_number":"4111111111111111","expiry_date":"09/27"}`
+
+	first := dedupeRequest(handler, "192.0.2.1", "/validate", body, "X-Request-Id", "first")
+	second := dedupeRequest(handler, "192.0.2.1", "/validate", body, "X-Request-Id", "second")
+
+	if calls := target.calls.Load(); calls != 1 {
+		t.Fatalf("handler ran %d times, want 1", calls)
+	}
+	if first.Header().Get("X-Deduplicated") != "" || second.Header().Get("X-Deduplicated") != "true" {
+		t.Errorf("X-Deduplicated = %q, %q; want only the second marked", first.Header().Get("X-Deduplicated"), second.Header().Get("X-Deduplicated"))
+	}
+	if second.Code != first.Code || second.Body.String() != first.Body.String() || second.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("replay = %d %q, want %d %q", second.Code, second.Body.String(), first.Code, first.Body.String())
+	}
+	// The replay does not carry the first request's ID
+	if id := second.Header().Get("X-Request-Id"); id == "first" {
+		t.Errorf("replay X-Request-Id = %q", id)
+	}
+}
+
+func TestDedupeKey(t *testing.T) {
+	base := `{"card_number":"4111111111111111","expiry_date":"09/27"}`
+	tests := []struct {
+		name      string
+		ip, path  string
+		body      string
+		headers   []string
+		duplicate bool
+	}{
+		{"formatted card number", "192.0.2.1", "/validate", `{"card_number":"4111 1111 1111 1111","expiry_date":"09/27"}`, nil, true},
+		{"reordered keys", "192.0.2.1", "/validate", `{"expiry_date":"09/27","card_number":"4111111111111111"}`, nil, true},
+		{"other client", "192.0.2.2", "/validate", base, nil, false},
+		{"other card", "192.0.2.1", "/validate", `{"card_number":"5500000000000004","expiry_date":"09/27"}`, nil, false},
+		{"other path", "192.0.2.1", "/validate/batch", base, nil, false},
+		{"other query", "192.0.2.1", "/validate?verbose=false", base, nil, false},
+		{"other language", "192.0.2.1", "/validate", base, []string{"Accept-Language", "fr"}, false},
+		{"other case style", "192.0.2.1", "/validate", base, []string{"X-Case-Style", "camel"}, false},
+		{"unrelated header", "192.0.2.1", "/validate", base, []string{"User-Agent", "other"}, true},
+	}
+
+	for _, tt := range tests {
+		target := &dedupeTarget{}
+		handler := NewDeduplicator(DefaultDedupeConfig()).DedupeMiddleware(target)
+		dedupeRequest(handler, "192.0.2.1", "/validate", base)
+		w := dedupeRequest(handler, tt.ip, tt.path, tt.body, tt.headers...)
+
+		if got := w.Header().Get("X-Deduplicated") == "true"; got != tt.duplicate {
+			t.Errorf("%s: deduplicated %v, want %v", tt.name, got, tt.duplicate)
+		}
+	}
+}
+
+func TestDedupeWindowExpires(t *testing.T) {
+	target := &dedupeTarget{}
+	config := DefaultDedupeConfig()
+	config.Window = 20 * time.Millisecond
+	handler := NewDeduplicator(config).DedupeMiddleware(target)
+
+	body := `{"card_number":"4111111111111111"}`
+	dedupeRequest(handler, "192.0.2.1", "/validate", body)
+	time.Sleep(40 * time.Millisecond)
+	if w := dedupeRequest(handler, "192.0.2.1", "/validate", body); w.Header().Get("X-Deduplicated") != "" || target.calls.Load() != 2 {
+		t.Errorf("after the window: deduplicated %q, %d calls; want a fresh response", w.Header().Get("X-Deduplicated"), target.calls.Load())
+	}
+}
+
+func TestDedupeSkipsUncacheableResponses(t *testing.T) {
+	tests := []struct {
+		name   string
+		target *dedupeTarget
+	}{
+		{"client error", &dedupeTarget{status: http.StatusBadRequest}},
+		{"server error", &dedupeTarget{status: http.StatusInternalServerError}},
+		{"no-store", &dedupeTarget{header: http.Header{"Cache-Control": {"private, no-store"}}}},
+	}
+
+	for _, tt := range tests {
+		dedupe := NewDeduplicator(DefaultDedupeConfig())
+		handler := dedupe.DedupeMiddleware(tt.target)
+		body := `{"card_number":"4111111111111111","include_original":true}`
+		dedupeRequest(handler, "192.0.2.1", "/validate", body)
+		w := dedupeRequest(handler, "192.0.2.1", "/validate", body)
+
+		if calls := tt.target.calls.Load(); calls != 2 || w.Header().Get("X-Deduplicated") != "" {
+			t.Errorf("%s: %d calls, deduplicated %q; want both handled", tt.name, calls, w.Header().Get("X-Deduplicated"))
+		}
+		dedupe.mu.Lock()
+		if len(dedupe.entries) != 0 {
+			t.Errorf("%s: %d entries kept", tt.name, len(dedupe.entries))
+		}
+		dedupe.mu.Unlock()
+	}
+}
+
+func TestDedupeIgnoresOtherRequests(t *testing.T) {
+	target := &dedupeTarget{}
+	handler := NewDeduplicator(DefaultDedupeConfig()).DedupeMiddleware(target)
+
+	for i := 0; i < 2; i++ {
+		dedupeRequest(handler, "192.0.2.1", "/stats", `{}`)
+		r := httptest.NewRequest(http.MethodGet, "/validate?card_number=4111111111111111", nil)
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+	}
+	if calls := target.calls.Load(); calls != 4 {
+		t.Errorf("handler ran %d times, want 4", calls)
+	}
+}
+
+func TestDedupeWaitsForInFlightOriginal(t *testing.T) {
+	release := make(chan struct{})
+	var calls atomic.Int32
+	handler := NewDeduplicator(DefaultDedupeConfig()).DedupeMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		<-release
+		w.Write([]byte(`{"valid":true}`))
+	}))
+
+	body := `{"card_number":"4111111111111111"}`
+	var wg sync.WaitGroup
+	responses := make([]*httptest.ResponseRecorder, 3)
+	for i := range responses {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			responses[i] = dedupeRequest(handler, "192.0.2.1", "/validate", body)
+		}(i)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := calls.Load(); n != 1 {
+		t.Errorf("handler ran %d times for concurrent duplicates, want 1", n)
+	}
+	replayed := 0
+	for _, w := range responses {
+		if w.Body.String() != `{"valid":true}` {
+			t.Errorf("response body = %q", w.Body.String())
+		}
+		if w.Header().Get("X-Deduplicated") == "true" {
+			replayed++
+		}
+	}
+	if replayed != 2 {
+		t.Errorf("%d responses replayed, want 2", replayed)
+	}
+}
+
+func TestDedupeSparesRateLimitTokens(t *testing.T) {
+	limiter, err := NewRateLimiter(RateLimiterConfig{Rate: 0.001, BucketSize: 1, CleanupInterval: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer limiter.Shutdown()
+	target := &dedupeTarget{}
+	handler := NewDeduplicator(DefaultDedupeConfig()).DedupeMiddleware(limiter.RateLimitMiddleware(target))
+
+	body := `{"card_number":"4111111111111111"}`
+	for i := 0; i < 3; i++ {
+		if w := dedupeRequest(handler, "192.0.2.1", "/validate", body); w.Code != http.StatusOK {
+			t.Fatalf("duplicate %d: status %d, want 200", i, w.Code)
+		}
+	}
+	// The one token went to the first request; a new request is limited
+	if w := dedupeRequest(handler, "192.0.2.1", "/validate", `{"card_number":"5500000000000004"}`); w.Code != http.StatusTooManyRequests {
+		t.Errorf("new request: status %d, want 429", w.Code)
+	}
+}
+
+func TestDedupeMaxEntries(t *testing.T) {
+	target := &dedupeTarget{}
+	config := DefaultDedupeConfig()
+	config.MaxEntries = 1
+	dedupe := NewDeduplicator(config)
+	handler := dedupe.DedupeMiddleware(target)
+
+	dedupeRequest(handler, "192.0.2.1", "/validate", `{"card_number":"4111111111111111"}`)
+	// A full window passes new requests through untracked
+	for i := 0; i < 2; i++ {
+		if w := dedupeRequest(handler, "192.0.2.1", "/validate", `{"card_number":"5500000000000004"}`); w.Header().Get("X-Deduplicated") != "" {
+			t.Errorf("request %d beyond MaxEntries was deduplicated", i)
+		}
+	}
+	if calls := target.calls.Load(); calls != 3 || len(dedupe.entries) != 1 {
+		t.Errorf("%d calls, %d entries; want 3 calls and 1 entry", calls, len(dedupe.entries))
+	}
+}
+
+func TestDedupeKeyHoldsNoCardData(t *testing.T) {
+	dedupe := NewDeduplicator(DefaultDedupeConfig())
+	r := httptest.NewRequest(http.MethodPost, "/validate", nil)
+	key := dedupe.requestKey(r, []byte(`{"card_number":"4111111111111111"}`))
+	if strings.Contains(key, "4111") || len(key) != 64 {
+		t.Errorf("key = %q, want a 64-character HMAC", key)
+	}
+
+	// Keys are per process, so they cannot be matched against another instance
+	if other := NewDeduplicator(DefaultDedupeConfig()).requestKey(r, []byte(`{"card_number":"4111111111111111"}`)); other == key {
+		t.Error("two deduplicators produced the same key")
+	}
+}
+
+func BenchmarkDedupeReplay(b *testing.B) {
+	handler := NewDeduplicator(DefaultDedupeConfig()).DedupeMiddleware(&dedupeTarget{})
+	body := `{"card_number":"4111111111111111","expiry_date":"09/27"}`
+	dedupeRequest(handler, "192.0.2.1", "/validate", body)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		dedupeRequest(handler, "192.0.2.1", "/validate", body)
+	}
+}
@@ -0,0 +1,114 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/jamesmeyerr/credit-card-validator/internal/luhn"
+)
+
+// Error codes used in the standardized error envelope
+const (
+	ErrCodeUnsupportedMediaType  = "UNSUPPORTED_MEDIA_TYPE"
+	ErrCodeRequestTooLarge       = "REQUEST_TOO_LARGE"
+	ErrCodeInvalidJSON           = "INVALID_JSON"
+	ErrCodeInvalidForm           = "INVALID_FORM"
+	ErrCodeEmptyBatch            = "EMPTY_BATCH"
+	ErrCodeBatchTooLarge         = "BATCH_TOO_LARGE"
+	ErrCodeMissingField          = "MISSING_FIELD"
+	ErrCodeDuplicateKey          = "DUPLICATE_KEY"
+	ErrCodeFieldTooLong          = "FIELD_TOO_LONG"
+	ErrCodeNoDigits              = "NO_DIGITS"
+	ErrCodeInvalidExpiry         = "INVALID_EXPIRY_FORMAT"
+	ErrCodeInvalidCVV            = "INVALID_CVV_FORMAT"
+	ErrCodeInvalidPlaceholder    = "INVALID_PLACEHOLDER"
+	ErrCodeInvalidCheckAlgorithm = "INVALID_CHECK_ALGORITHM"
+	ErrCodeInvalidQuery          = "INVALID_QUERY_PARAMETER"
+	ErrCodeCVVNotAllowed         = "CVV_NOT_ALLOWED"
+	ErrCodeURITooLong            = "URI_TOO_LONG"
+	ErrCodeForbidden             = "FORBIDDEN"
+	ErrCodeNotFound              = "NOT_FOUND"
+	ErrCodeInvalidUpload         = "INVALID_UPLOAD"
+	ErrCodeRateLimited           = "RATE_LIMITED"
+	ErrCodeStreamBusy            = "STREAM_BUSY"
+	ErrCodeInternal              = "INTERNAL_ERROR"
+)
+
+// StatusConfig chooses the status codes for well-formed requests that fail validation
+type StatusConfig struct {
+	// UnprocessableEntity makes well-formed requests whose fields fail validation, such as a
+	// malformed expiry or CVV, return 422 instead of 400, and cards with a non-valid outcome
+	// return 422 from /validate. Unparseable JSON is always 400.
+	// It is off by default so existing clients keep seeing 400.
+	UnprocessableEntity bool
+}
+
+// DefaultStatusConfig returns a default configuration
+func DefaultStatusConfig() StatusConfig {
+	return StatusConfig{
+		UnprocessableEntity: false,
+	}
+}
+
+// ValidationStatus returns the status for a well-formed request whose fields fail validation
+func (c StatusConfig) ValidationStatus() int {
+	if c.UnprocessableEntity {
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusBadRequest
+}
+
+// OutcomeStatus returns the status for a card with the given outcome. Outcomes other than
+// valid, such as a failed checksum or an expired card, are 422 when UnprocessableEntity is set;
+// otherwise every outcome is 200.
+func (c StatusConfig) OutcomeStatus(outcome string) int {
+	if c.UnprocessableEntity && outcome != luhn.OutcomeValid {
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusOK
+}
+
+// OutcomeErrorCode returns the error envelope code for a card outcome, e.g. EXPIRED for expired
+func OutcomeErrorCode(outcome string) string {
+	return strings.ToUpper(outcome)
+}
+
+// ErrorResponse is the standardized JSON error envelope
+type ErrorResponse struct {
+	Error ErrorDetail `json:"error"`
+}
+
+// ErrorDetail describes a single error
+type ErrorDetail struct {
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	RequestID string `json:"request_id,omitempty"`
+
+	// Fields names the request fields the error applies to, when there are several
+	Fields []string `json:"fields,omitempty"`
+}
+
+// WriteError writes the standardized JSON error envelope with the given status
+func WriteError(w http.ResponseWriter, r *http.Request, status int, code, message string) {
+	WriteFieldsError(w, r, status, code, message, nil)
+}
+
+// WriteFieldsError writes the standardized JSON error envelope naming the fields at fault
+func WriteFieldsError(w http.ResponseWriter, r *http.Request, status int, code, message string, fields []string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(ErrorResponse{
+		Error: ErrorDetail{
+			Code:      code,
+			Message:   message,
+			RequestID: GetRequestID(r.Context()),
+			Fields:    fields,
+		},
+	})
+}
+
+// NotFoundHandler responds to unmatched routes with a JSON 404 in the error envelope
+func NotFoundHandler(w http.ResponseWriter, r *http.Request) {
+	WriteError(w, r, http.StatusNotFound, ErrCodeNotFound, "Resource not found")
+}
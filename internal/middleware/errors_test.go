@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jamesmeyerr/credit-card-validator/internal/luhn"
+)
+
+func TestValidationStatus(t *testing.T) {
+	if got := DefaultStatusConfig().ValidationStatus(); got != http.StatusBadRequest {
+		t.Errorf("default ValidationStatus() = %d, want 400", got)
+	}
+	if got := (StatusConfig{UnprocessableEntity: true}).ValidationStatus(); got != http.StatusUnprocessableEntity {
+		t.Errorf("ValidationStatus() with UnprocessableEntity = %d, want 422", got)
+	}
+}
+
+func TestOutcomeStatus(t *testing.T) {
+	tests := []struct {
+		enabled bool
+		outcome string
+		want    int
+	}{
+		{false, luhn.OutcomeValid, http.StatusOK},
+		{false, luhn.OutcomeInvalidLuhn, http.StatusOK},
+		{true, luhn.OutcomeValid, http.StatusOK},
+		{true, luhn.OutcomeInvalidLuhn, http.StatusUnprocessableEntity},
+		{true, luhn.OutcomeExpired, http.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range tests {
+		config := StatusConfig{UnprocessableEntity: tt.enabled}
+		if got := config.OutcomeStatus(tt.outcome); got != tt.want {
+			t.Errorf("OutcomeStatus(%q) with UnprocessableEntity=%v = %d, want %d", tt.outcome, tt.enabled, got, tt.want)
+		}
+	}
+	if got := OutcomeErrorCode(luhn.OutcomePANLengthOutOfRange); got != "PAN_LENGTH_OUT_OF_RANGE" {
+		t.Errorf("OutcomeErrorCode = %q", got)
+	}
+}
+
+func TestWriteFieldsError(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/validate", nil)
+	w := httptest.NewRecorder()
+	WriteFieldsError(w, r, http.StatusUnprocessableEntity, ErrCodeMissingField, "missing", []string{"cvv", "expiry_date"})
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("status = %d, want 422", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+
+	var resp ErrorResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Error.Code != ErrCodeMissingField || len(resp.Error.Fields) != 2 {
+		t.Errorf("envelope = %+v", resp.Error)
+	}
+}
+
+func TestNotFoundHandler(t *testing.T) {
+	handler := LoggingMiddleware(http.HandlerFunc(NotFoundHandler))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/no/such/path", nil))
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+	var resp ErrorResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode error envelope: %v", err)
+	}
+	if resp.Error.Code != ErrCodeNotFound {
+		t.Errorf("error code = %q, want %q", resp.Error.Code, ErrCodeNotFound)
+	}
+	if resp.Error.RequestID == "" || resp.Error.RequestID != w.Header().Get("X-Request-Id") {
+		t.Errorf("request ID = %q, want the X-Request-Id header %q", resp.Error.RequestID, w.Header().Get("X-Request-Id"))
+	}
+}
@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+)
+
+// formBoolFields are request fields decoded as booleans rather than strings
+var formBoolFields = map[string]bool{"luhn_only": true}
+
+// FormToJSONMiddleware converts an application/x-www-form-urlencoded POST, as submitted by the
+// HTML form without JavaScript, into the equivalent JSON body so the sanitizer and handler see
+// the same representation as a JSON request. Other requests pass through unchanged.
+// Only the body is read; query parameters never become request fields.
+func FormToJSONMiddleware(maxSize int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost || !isFormContentType(r.Header.Get("Content-Type")) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			r.Body = http.MaxBytesReader(w, r.Body, maxSize)
+			if err := r.ParseForm(); err != nil {
+				var maxBytesErr *http.MaxBytesError
+				if errors.As(err, &maxBytesErr) {
+					WriteError(w, r, http.StatusRequestEntityTooLarge, ErrCodeRequestTooLarge,
+						fmt.Sprintf("request body exceeds max size %d bytes", maxSize))
+					return
+				}
+				WriteError(w, r, http.StatusBadRequest, ErrCodeInvalidForm, "Invalid form body")
+				return
+			}
+
+			requestMap := make(map[string]interface{}, len(r.PostForm))
+			for key, values := range r.PostForm {
+				// Repeated fields are rejected for the same reason as duplicate JSON keys
+				if len(values) > 1 {
+					WriteError(w, r, http.StatusBadRequest, ErrCodeDuplicateKey,
+						fmt.Sprintf("duplicate key %q in request body", key))
+					return
+				}
+				if !formBoolFields[key] {
+					requestMap[key] = values[0]
+					continue
+				}
+				// An unchecked checkbox is simply absent; an empty value counts as false
+				if values[0] == "" {
+					continue
+				}
+				enabled, err := strconv.ParseBool(values[0])
+				if err != nil {
+					WriteError(w, r, http.StatusBadRequest, ErrCodeInvalidForm,
+						fmt.Sprintf("%s must be true or false", key))
+					return
+				}
+				requestMap[key] = enabled
+			}
+
+			body, err := json.Marshal(requestMap)
+			if err != nil {
+				WriteError(w, r, http.StatusInternalServerError, ErrCodeInternal, "Error processing request")
+				return
+			}
+
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			r.ContentLength = int64(len(body))
+			r.Header.Set("Content-Type", "application/json")
+			r.PostForm, r.Form = nil, nil
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// isFormContentType checks if the media type is application/x-www-form-urlencoded
+func isFormContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/x-www-form-urlencoded"
+}
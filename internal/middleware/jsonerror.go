@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+)
+
+// jsonSnippetRadius is how many bytes of context are shown on each side of a syntax error
+const jsonSnippetRadius = 10
+
+// JSONErrorMessage describes a JSON decoding error for the client: the byte offset and nearby
+// text for syntax errors, and the field and expected type for type errors. body may be nil,
+// in which case no snippet is included. Digits in the snippet are replaced with '#' so a
+// card number is never echoed back.
+func JSONErrorMessage(err error, body []byte) string {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+
+	switch {
+	case body != nil && len(bytes.TrimSpace(body)) == 0, errors.Is(err, io.EOF):
+		return "Invalid JSON: request body is empty"
+	case errors.As(err, &syntaxErr):
+		message := fmt.Sprintf("Invalid JSON at byte offset %d: %s", syntaxErr.Offset, syntaxErr.Error())
+		if snippet := jsonSnippet(body, syntaxErr.Offset); snippet != "" {
+			message += fmt.Sprintf(" near %q", snippet)
+		}
+		return message
+	case errors.As(err, &typeErr):
+		if typeErr.Field == "" {
+			return fmt.Sprintf("Invalid JSON: request body must be %s, got %s", jsonTypeName(typeErr), typeErr.Value)
+		}
+		return fmt.Sprintf("Invalid JSON: field %q must be %s, got %s", typeErr.Field, jsonTypeName(typeErr), typeErr.Value)
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return "Invalid JSON: unexpected end of input"
+	}
+	return "Invalid JSON payload"
+}
+
+// jsonTypeName names the Go type a JSON value was decoded into in JSON terms
+func jsonTypeName(typeErr *json.UnmarshalTypeError) string {
+	switch typeErr.Type.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Map, reflect.Struct:
+		return "an object"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "a number"
+	}
+	return typeErr.Type.String()
+}
+
+// jsonSnippet returns the body around offset with digits replaced by '#'
+func jsonSnippet(body []byte, offset int64) string {
+	if len(body) == 0 {
+		return ""
+	}
+	start := offset - jsonSnippetRadius
+	if start < 0 {
+		start = 0
+	}
+	end := offset + jsonSnippetRadius
+	if end > int64(len(body)) {
+		end = int64(len(body))
+	}
+	if start >= end {
+		return ""
+	}
+
+	snippet := make([]byte, 0, end-start)
+	for _, b := range body[start:end] {
+		if b >= '0' && b <= '9' {
+			b = '#'
+		}
+		snippet = append(snippet, b)
+	}
+	return string(snippet)
+}
@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+)
+
+// decodeError decodes body into a card request and returns the error
+func decodeError(t *testing.T, body string) error {
+	t.Helper()
+	var req struct {
+		CardNumber string `json:"card_number"`
+		Verbose    bool   `json:"verbose"`
+		Cards      []int  `json:"cards"`
+	}
+	err := json.Unmarshal([]byte(body), &req)
+	if err == nil {
+		t.Fatalf("%s decoded without error", body)
+	}
+	return err
+}
+
+func TestJSONErrorMessageSyntaxError(t *testing.T) {
+	body := `{"card_number":"4111111111111111",}`
+	message := JSONErrorMessage(decodeError(t, body), []byte(body))
+
+	for _, want := range []string{"byte offset 35", `near "#######\",}"`} {
+		if !strings.Contains(message, want) {
+			t.Errorf("message = %q, want it to contain %q", message, want)
+		}
+	}
+	if strings.Contains(message, "1111") {
+		t.Errorf("message = %q echoes card digits", message)
+	}
+}
+
+func TestJSONErrorMessageTypeError(t *testing.T) {
+	tests := []struct {
+		body string
+		want string
+	}{
+		{`{"card_number":4111111111111111}`, `field "card_number" must be a string, got number`},
+		{`{"verbose":"yes"}`, `field "verbose" must be a boolean, got string`},
+		{`{"cards":{}}`, `field "cards" must be an array, got object`},
+		{`["4111111111111111"]`, `request body must be an object, got array`},
+	}
+
+	for _, tt := range tests {
+		if message := JSONErrorMessage(decodeError(t, tt.body), []byte(tt.body)); !strings.Contains(message, tt.want) {
+			t.Errorf("%s: message = %q, want it to contain %q", tt.body, message, tt.want)
+		}
+	}
+}
+
+func TestJSONErrorMessageOtherErrors(t *testing.T) {
+	tests := []struct {
+		err  error
+		body []byte
+		want string
+	}{
+		{io.EOF, nil, "Invalid JSON: request body is empty"},
+		{decodeError(t, "  "), []byte("  "), "Invalid JSON: request body is empty"},
+		{io.ErrUnexpectedEOF, nil, "Invalid JSON: unexpected end of input"},
+		{io.ErrClosedPipe, nil, "Invalid JSON payload"},
+	}
+	for _, tt := range tests {
+		if message := JSONErrorMessage(tt.err, tt.body); message != tt.want {
+			t.Errorf("JSONErrorMessage(%v) = %q, want %q", tt.err, message, tt.want)
+		}
+	}
+
+	// Without the body there is no snippet, only the offset
+	body := `{"card_number":}`
+	if message := JSONErrorMessage(decodeError(t, body), nil); strings.Contains(message, "near") || !strings.Contains(message, "byte offset") {
+		t.Errorf("message without body = %q", message)
+	}
+}
@@ -4,13 +4,17 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
 	"os"
 	"strings"
 	"time"
+	"unicode"
 
+	"github.com/jamesmeyerr/credit-card-validator/internal/luhn"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 )
@@ -44,8 +48,27 @@ type contextKey int
 const (
 	// requestIDKey is the context key for the request ID
 	requestIDKey contextKey = iota
+
+	// timingsKey is the context key for the request's stage Timings
+	timingsKey
+
+	// logStateKey is the context key for the request's logState
+	logStateKey
 )
 
+// CVVLogMask replaces every logged CVV. It has a fixed length so logs do not reveal whether
+// a CVV had 3 or 4 digits.
+var CVVLogMask = "***"
+
+// maxLoggedBodySize is the largest request body the logger buffers for masked logging
+const maxLoggedBodySize = 64 * 1024
+
+// readCloser combines a reader with the original body's closer
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
 // LoggingMiddleware adds request logging and tracing
 func LoggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -59,8 +82,16 @@ func LoggingMiddleware(next http.Handler) http.Handler {
 
 		// Store request ID in context
 		ctx := context.WithValue(r.Context(), requestIDKey, requestID)
+		ctx, state := withLogState(ctx)
 		r = r.WithContext(ctx)
 
+		// Collect per-stage timings when the breakdown is enabled
+		var timings *Timings
+		if TimingBreakdown {
+			ctx, timings = withTimings(ctx)
+			r = r.WithContext(ctx)
+		}
+
 		// Add request ID to response headers
 		w.Header().Set("X-Request-ID", requestID)
 
@@ -69,29 +100,32 @@ func LoggingMiddleware(next http.Handler) http.Handler {
 			ResponseWriter: w,
 			Status:         http.StatusOK, // Default status
 			Size:           0,
+			state:          state,
 		}
 
-		// Extract request body for logging (with privacy protection)
-		var requestBody map[string]interface{}
+		// Extract request body for logging (with privacy protection). Only a masked copy
+		// is ever logged; bodyBytes itself must never reach the logger.
+		var requestBody interface{}
 		var bodyBytes []byte
+		unloggedBody := false
 		
-		if r.Body != nil && r.Header.Get("Content-Type") == "application/json" {
-			bodyBytes, _ = io.ReadAll(r.Body)
-			r.Body.Close()
+		if r.Body != nil && isJSONContentType(r.Header.Get("Content-Type")) {
+			// Buffer at most maxLoggedBodySize+1 bytes so oversized bodies are left for the sanitizer to reject
+			var readErr error
+			bodyBytes, readErr = io.ReadAll(io.LimitReader(r.Body, maxLoggedBodySize+1))
 			
-			// Restore the body for downstream handlers
-			r.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
+			// Restore the full body for downstream handlers: the buffered bytes followed by anything unread
+			r.Body = readCloser{
+				Reader: io.MultiReader(bytes.NewReader(bodyBytes), r.Body),
+				Closer: r.Body,
+			}
 			
-			// Try to parse as JSON
-			if err := json.Unmarshal(bodyBytes, &requestBody); err == nil {
-				// Mask sensitive data
-				if cardNum, ok := requestBody["card_number"].(string); ok && len(cardNum) > 6 {
-					masked := cardNum[:6] + strings.Repeat("*", len(cardNum)-10) + cardNum[len(cardNum)-4:]
-					requestBody["card_number"] = masked
-				}
-				if cvv, ok := requestBody["cvv"].(string); ok {
-					requestBody["cvv"] = strings.Repeat("*", len(cvv))
-				}
+			// Try to parse as JSON, skipping bodies that are too large or unreadable to log
+			var decoded interface{}
+			if readErr == nil && len(bodyBytes) <= maxLoggedBodySize && json.Unmarshal(bodyBytes, &decoded) == nil {
+				requestBody = maskLoggedValue("", decoded)
+			} else {
+				unloggedBody = len(bodyBytes) > 0
 			}
 		}
 
@@ -107,12 +141,27 @@ func LoggingMiddleware(next http.Handler) http.Handler {
 
 		if requestBody != nil {
 			logger = logger.With().Interface("request_body", requestBody).Logger()
+		} else if unloggedBody {
+			// Masking needs a parsed body, so only describe one that could not be parsed
+			logger = logger.With().
+				Int("request_body_bytes", len(bodyBytes)).
+				Str("content_type", r.Header.Get("Content-Type")).
+				Logger()
 		}
 
-		logger.Info().Msg("Request started")
+		// The start line waits until the request is admitted or its response begins, so a
+		// rejection reported in a rate limit summary writes no lines of its own
+		state.startLine = func(summarized bool) {
+			if summarized {
+				logger.Debug().Msg("Request started")
+			} else {
+				logger.Info().Msg("Request started")
+			}
+		}
 
 		// Process the request
 		next.ServeHTTP(rr, r)
+		state.logStart()
 
 		// Post-request logging
 		duration := time.Since(start)
@@ -121,8 +170,17 @@ func LoggingMiddleware(next http.Handler) http.Handler {
 			Int("size", rr.Size).
 			Dur("duration_ms", duration).
 			Logger()
+		if timings != nil {
+			responseLog = responseLog.With().Dict("timings_ms", timings.dict()).Logger()
+		}
 
-		if rr.Status >= 400 {
+		if state.summarized.Load() {
+			// Reported in aggregate, such as a rate limit summary, so keep it out of the normal log
+			responseLog.Debug().Msg("Request failed")
+		} else if errors.Is(r.Context().Err(), context.Canceled) {
+			// The client disconnected before the handler finished
+			responseLog.Warn().Msg("Request cancelled by client")
+		} else if rr.Status >= 400 {
 			// Log elevated for errors
 			responseLog.Error().Msg("Request failed")
 		} else {
@@ -131,40 +189,93 @@ func LoggingMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// maskLoggedValue returns a copy of a decoded JSON value that is safe to log. Card numbers
+// and CVVs are masked wherever they appear, including inside batches, and any other value
+// with enough digits to hold a card number, such as free text, has its digits hidden.
+func maskLoggedValue(key string, value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		masked := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			masked[k] = maskLoggedValue(k, item)
+		}
+		return masked
+	case []interface{}:
+		masked := make([]interface{}, len(v))
+		for i, item := range v {
+			masked[i] = maskLoggedValue(key, item)
+		}
+		return masked
+	case string:
+		switch strings.ToLower(key) {
+		case "card_number", "card_number_original":
+			return luhn.Mask(v, 6, 4)
+		case "cvv":
+			return CVVLogMask
+		}
+		if len(luhn.Clean(v)) >= luhn.MinCardLength {
+			return hideDigits(v)
+		}
+		return v
+	case float64:
+		// A card number or CVV sent as a JSON number
+		switch strings.ToLower(key) {
+		case "card_number", "card_number_original", "cvv":
+			return "[redacted]"
+		}
+		if v >= 1e11 || v <= -1e11 {
+			return "[redacted]"
+		}
+		return v
+	}
+	return value
+}
+
+// hideDigits replaces every digit with an asterisk
+func hideDigits(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) {
+			return '*'
+		}
+		return r
+	}, s)
+}
+
 // responseRecorder is a wrapper around http.ResponseWriter to capture status code and response size
 type responseRecorder struct {
 	http.ResponseWriter
 	Status int
 	Size   int
+	state  *logState // writes the deferred start line when the response begins; nil outside LoggingMiddleware
 }
 
 // WriteHeader captures the status code
 func (r *responseRecorder) WriteHeader(status int) {
+	r.state.logStart()
 	r.Status = status
 	r.ResponseWriter.WriteHeader(status)
 }
 
 // Write captures the response size
 func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.state.logStart()
 	size, err := r.ResponseWriter.Write(b)
 	r.Size += size
 	return size, err
 }
 
-// getClientIP extracts the client's IP address from the request
-func getClientIP(r *http.Request) string {
-	// Try different headers that might contain the real client IP
-	for _, header := range []string{"X-Forwarded-For", "X-Real-IP"} {
-		ip := r.Header.Get(header)
-		if ip != "" {
-			// X-Forwarded-For can contain multiple IPs; use the first one
-			return strings.Split(ip, ",")[0]
-		}
+// Unwrap exposes the underlying writer so http.ResponseController can flush and set deadlines
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// isJSONContentType checks if the media type is application/json, ignoring parameters such as charset
+func isJSONContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
 	}
-	
-	// Fall back to remote address
-	ip, _, _ := strings.Cut(r.RemoteAddr, ":")
-	return ip
+	return mediaType == "application/json"
 }
 
 // GetRequestID extracts the request ID from the context
@@ -0,0 +1,141 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/jamesmeyerr/credit-card-validator/internal/luhn"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+func TestLoggingReportsClientCancellation(t *testing.T) {
+	logs := captureLogs(t)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	handler := LoggingMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The client disconnects while the handler is working
+		cancel()
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/stats", nil).WithContext(ctx))
+
+	if !strings.Contains(logs.String(), `"level":"warn"`) || !strings.Contains(logs.String(), "Request cancelled by client") {
+		t.Errorf("log = %s, want a cancellation warning", logs)
+	}
+}
+
+// logRequest sends body through the logging middleware and returns the captured logs
+func logRequest(t *testing.T, body string) string {
+	t.Helper()
+	logs := captureLogs(t)
+	handler := LoggingMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The handler still sees the full, unmasked body
+		if got, _ := io.ReadAll(r.Body); string(got) != body {
+			t.Errorf("handler body = %q, want %q", got, body)
+		}
+	}))
+	r := httptest.NewRequest(http.MethodPost, "/validate", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("X-Request-ID", "req-logging")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+	return logs.String()
+}
+
+func TestLoggingNeverLogsUnparseableBody(t *testing.T) {
+	for _, body := range []string{
+		`{"card_number":"4111111111111111","cvv":"737"`,
+		`{"card_number":"4111111111111111",}`,
+		`card_number=4111111111111111&cvv=737`,
+	} {
+		logs := logRequest(t, body)
+		for _, secret := range []string{"4111111111111111", "411111", "1111", "737"} {
+			if strings.Contains(logs, secret) {
+				t.Errorf("%s: log contains %q: %s", body, secret, logs)
+			}
+		}
+		// Only the size and content type describe the body
+		if !strings.Contains(logs, `"request_body_bytes":`+strconv.Itoa(len(body))) || !strings.Contains(logs, `"content_type":"application/json"`) {
+			t.Errorf("%s: log = %s, want the body length and content type", body, logs)
+		}
+	}
+}
+
+func TestLoggingMasksParsedBody(t *testing.T) {
+	logs := logRequest(t, `{"card_number":"4111111111111111","cvv":"737","cards":[{"card_number":"5500000000000004","cvv":"1234"}],"note":"card 4000 0000 0000 0002"}`)
+	for _, secret := range []string{"4111111111111111", "5500000000000004", "737", "1234", "4000 0000"} {
+		if strings.Contains(logs, secret) {
+			t.Errorf("log contains %q: %s", secret, logs)
+		}
+	}
+	for _, want := range []string{`"411111******1111"`, `"550000******0004"`, `"cvv":"***"`, `"card **** **** **** ****"`} {
+		if !strings.Contains(logs, want) {
+			t.Errorf("log = %s, want it to contain %s", logs, want)
+		}
+	}
+	if strings.Contains(logs, "request_body_bytes") {
+		t.Errorf("parsed body was logged by size: %s", logs)
+	}
+}
+
+func TestLoggingCVVFixedMask(t *testing.T) {
+	// 3- and 4-digit CVVs log the same mask, so the log does not reveal the length
+	for _, cvv := range []string{"737", "1234", "7"} {
+		logs := logRequest(t, `{"card_number":"378282246310005","cvv":"`+cvv+`"}`)
+		if !strings.Contains(logs, `"cvv":"***"`) || strings.Contains(logs, `"cvv":"`+cvv) {
+			t.Errorf("cvv %s: log = %s, want the fixed mask", cvv, logs)
+		}
+	}
+
+	previous := CVVLogMask
+	CVVLogMask = "[cvv]"
+	t.Cleanup(func() { CVVLogMask = previous })
+	for _, cvv := range []string{"737", "1234"} {
+		if logs := logRequest(t, `{"cards":[{"card_number":"4111111111111111","cvv":"`+cvv+`"}]}`); !strings.Contains(logs, `"cvv":"[cvv]"`) {
+			t.Errorf("cvv %s with custom mask: log = %s", cvv, logs)
+		}
+	}
+}
+
+func TestMaskLoggedValue(t *testing.T) {
+	tests := []struct {
+		key   string
+		value interface{}
+		want  interface{}
+	}{
+		{"card_number", "4111111111111111", "411111******1111"},
+		{"CARD_NUMBER", "4111111111111111", "411111******1111"},
+		{"card_number_original", "4111 1111 1111 1111", luhn.Mask("4111 1111 1111 1111", 6, 4)},
+		{"cvv", "1234", CVVLogMask},
+		{"cvv", float64(737), "[redacted]"},
+		{"card_number", float64(4111111111111111), "[redacted]"},
+		{"amount", float64(4111111111111), "[redacted]"},
+		{"amount", float64(42), float64(42)},
+		{"expiry_date", "09/27", "09/27"},
+		{"reference", "ref-4111111111111111", "ref-****************"},
+		{"verbose", true, true},
+	}
+	for _, tt := range tests {
+		if got := maskLoggedValue(tt.key, tt.value); got != tt.want {
+			t.Errorf("maskLoggedValue(%q, %v) = %v, want %v", tt.key, tt.value, got, tt.want)
+		}
+	}
+}
+
+func BenchmarkLoggingMiddleware(b *testing.B) {
+	previous := log.Logger
+	log.Logger = zerolog.Nop()
+	defer func() { log.Logger = previous }()
+	handler := LoggingMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	body := `{"card_number":"4111111111111111","cvv":"737","expiry_date":"09/27"}`
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		r := httptest.NewRequest(http.MethodPost, "/validate", strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+	}
+}
@@ -1,73 +1,261 @@
 package middleware
 
 import (
+    "container/list"
+    "context"
+    "fmt"
+    "hash/fnv"
+    "math"
+    "net"
     "net/http"
+    "strconv"
+    "strings"
     "sync"
     "time"
-    "encoding/json"
+
+    "github.com/rs/zerolog/log"
+    "google.golang.org/grpc"
+    "google.golang.org/grpc/codes"
+    "google.golang.org/grpc/status"
+)
+
+// RateLimiterConfig defines rate limiting behaviour
+type RateLimiterConfig struct {
+    Rate            float64       // tokens per second
+    BucketSize      int           // maximum burst
+    CleanupInterval time.Duration // how often stale buckets are removed
+    ExemptCIDRs     []string      // IPs or CIDRs that bypass rate limiting entirely
+    IncludeHeaders  bool          // add X-RateLimit-* headers to every response
+    Algorithm       RateLimitAlgorithm
+    MaxClients      int           // tracked clients before the least recently seen is evicted; 0 is unbounded
+
+    // LogSummaryInterval, when set, replaces the per-request log lines for each rejection
+    // with one summary per client every interval, keeping logs readable during abuse
+    LogSummaryInterval time.Duration
+}
+
+// DefaultMaxClients bounds rate limiter memory when a flood of unique addresses arrives between cleanups
+const DefaultMaxClients = 100000
+
+// RateLimitAlgorithm selects how requests are spread over time
+type RateLimitAlgorithm int
+
+const (
+    // AlgorithmTokenBucket allows bursts of up to BucketSize requests, refilled at Rate
+    AlgorithmTokenBucket RateLimitAlgorithm = iota
+
+    // AlgorithmLeakyBucket allows no bursts: requests must be at least 1/Rate seconds apart.
+    // It is a token bucket holding a single token, so BucketSize is ignored.
+    AlgorithmLeakyBucket
 )
 
-// RateLimiter implements a token bucket rate limiting algorithm
+// ParseRateLimitAlgorithm converts "token" or "leaky" into a RateLimitAlgorithm
+func ParseRateLimitAlgorithm(s string) (RateLimitAlgorithm, error) {
+    switch strings.ToLower(strings.TrimSpace(s)) {
+    case "", "token":
+        return AlgorithmTokenBucket, nil
+    case "leaky":
+        return AlgorithmLeakyBucket, nil
+    }
+    return AlgorithmTokenBucket, fmt.Errorf("unknown rate limit algorithm %q", s)
+}
+
+// shardCount is the number of independently locked client maps
+const shardCount = 32
+
+// RateLimiter implements token bucket rate limiting, optionally as a burst-free leaky bucket
 type RateLimiter struct {
     rate       float64     // tokens per second
     bucketSize int         // maximum tokens
-    clients    map[string]*bucket
-    mu         sync.Mutex
+    shards     [shardCount]*clientShard
+    exempt     []*net.IPNet
+    headers    bool        // whether to emit X-RateLimit-* headers
     cleanup    *time.Ticker
+    done       chan struct{}
+    stopped    sync.Once
+    summary    *rejectionSummary // nil unless rejections are logged in aggregate
+}
+
+// clientShard holds a subset of client buckets behind its own lock,
+// so requests from different IPs rarely contend on the same mutex
+type clientShard struct {
+    mu      sync.Mutex
+    clients map[string]*bucket
+    recent  *list.List // client IPs, most recently seen first
+    limit   int        // maximum clients in this shard; 0 is unbounded
 }
 
 // bucket represents a token bucket for a single client
 type bucket struct {
     tokens     float64
     lastRefill time.Time
+    elem       *list.Element // this client's entry in the shard's recent list
 }
 
-// NewRateLimiter creates a new rate limiter
-func NewRateLimiter(rate float64, bucketSize int, cleanupInterval time.Duration) *RateLimiter {
+// NewRateLimiter creates a new rate limiter, returning an error if any exempt CIDR is malformed
+func NewRateLimiter(config RateLimiterConfig) (*RateLimiter, error) {
+    exempt, err := parseCIDRs(config.ExemptCIDRs)
+    if err != nil {
+        return nil, err
+    }
+
+    bucketSize := config.BucketSize
+    if config.Algorithm == AlgorithmLeakyBucket {
+        bucketSize = 1
+    }
+
     limiter := &RateLimiter{
-        rate:       rate,
+        rate:       config.Rate,
         bucketSize: bucketSize,
-        clients:    make(map[string]*bucket),
-        cleanup:    time.NewTicker(cleanupInterval),
+        exempt:     exempt,
+        headers:    config.IncludeHeaders,
+        cleanup:    time.NewTicker(config.CleanupInterval),
+        done:       make(chan struct{}),
+    }
+
+    // The cap is split evenly across shards, rounding up so it is never below MaxClients
+    shardLimit := 0
+    if config.MaxClients > 0 {
+        shardLimit = (config.MaxClients + shardCount - 1) / shardCount
+    }
+
+    for i := range limiter.shards {
+        limiter.shards[i] = &clientShard{
+            clients: make(map[string]*bucket),
+            recent:  list.New(),
+            limit:   shardLimit,
+        }
+    }
+
+    if config.LogSummaryInterval > 0 {
+        limiter.summary = newRejectionSummary(config.LogSummaryInterval)
     }
 
     // Start cleanup routine to remove stale buckets
     go func() {
-        for range limiter.cleanup.C {
-            limiter.cleanupStale(30 * time.Minute)
+        for {
+            select {
+            case <-limiter.cleanup.C:
+                limiter.cleanupStale(30 * time.Minute)
+            case <-limiter.done:
+                return
+            }
         }
     }()
 
-    return limiter
+    return limiter, nil
+}
+
+// parseCIDRs parses a list of CIDRs, treating bare IPs as single-address networks
+func parseCIDRs(cidrs []string) ([]*net.IPNet, error) {
+    var networks []*net.IPNet
+    for _, cidr := range cidrs {
+        cidr = strings.TrimSpace(cidr)
+        if cidr == "" {
+            continue
+        }
+
+        if !strings.Contains(cidr, "/") {
+            ip := net.ParseIP(cidr)
+            if ip == nil {
+                return nil, fmt.Errorf("invalid IP address %q", cidr)
+            }
+            bits := 8 * net.IPv6len
+            if ip.To4() != nil {
+                ip = ip.To4()
+                bits = 8 * net.IPv4len
+            }
+            networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+            continue
+        }
+
+        _, network, err := net.ParseCIDR(cidr)
+        if err != nil {
+            return nil, fmt.Errorf("invalid CIDR %q: %w", cidr, err)
+        }
+        networks = append(networks, network)
+    }
+    return networks, nil
+}
+
+// isExempt checks if the IP falls within any exempt network
+func (rl *RateLimiter) isExempt(ip string) bool {
+    if len(rl.exempt) == 0 {
+        return false
+    }
+
+    parsed := net.ParseIP(strings.TrimSpace(ip))
+    if parsed == nil {
+        return false
+    }
+
+    for _, network := range rl.exempt {
+        if network.Contains(parsed) {
+            return true
+        }
+    }
+    return false
+}
+
+// shardFor returns the shard responsible for an IP
+func (rl *RateLimiter) shardFor(ip string) *clientShard {
+    h := fnv.New32a()
+    h.Write([]byte(ip))
+    return rl.shards[h.Sum32()%shardCount]
 }
 
 // cleanupStale removes buckets that haven't been used for a while
 func (rl *RateLimiter) cleanupStale(maxAge time.Duration) {
-    rl.mu.Lock()
-    defer rl.mu.Unlock()
-    
     threshold := time.Now().Add(-maxAge)
-    for ip, bucket := range rl.clients {
-        if bucket.lastRefill.Before(threshold) {
-            delete(rl.clients, ip)
+    for _, shard := range rl.shards {
+        shard.mu.Lock()
+        for ip, bucket := range shard.clients {
+            if bucket.lastRefill.Before(threshold) {
+                shard.remove(ip, bucket)
+            }
         }
+        shard.mu.Unlock()
     }
 }
 
-// Allow checks if a request should be allowed based on the client's IP
-func (rl *RateLimiter) Allow(ip string) bool {
-    rl.mu.Lock()
-    defer rl.mu.Unlock()
+// remove forgets a client; the caller must hold the shard's lock
+func (s *clientShard) remove(ip string, b *bucket) {
+    s.recent.Remove(b.elem)
+    delete(s.clients, ip)
+}
+
+// Allow checks if a request should be allowed based on the client's IP.
+// It also returns the tokens remaining in the client's bucket after this request.
+func (rl *RateLimiter) Allow(ip string) (bool, float64) {
+    // Exempt sources never consume tokens or create buckets
+    if rl.isExempt(ip) {
+        return true, float64(rl.bucketSize)
+    }
 
-    b, exists := rl.clients[ip]
+    shard := rl.shardFor(ip)
+    shard.mu.Lock()
+    defer shard.mu.Unlock()
+
+    b, exists := shard.clients[ip]
     if !exists {
+        // Make room by forgetting the least recently seen client. An evicted client
+        // starts over with a full bucket, which is the price of bounded memory.
+        if shard.limit > 0 && len(shard.clients) >= shard.limit {
+            oldest := shard.recent.Back()
+            oldestIP := oldest.Value.(string)
+            shard.remove(oldestIP, shard.clients[oldestIP])
+        }
+
         // Create a new bucket for this client
-        rl.clients[ip] = &bucket{
+        b = &bucket{
             tokens:     float64(rl.bucketSize) - 1, // Use one token for this request
             lastRefill: time.Now(),
+            elem:       shard.recent.PushFront(ip),
         }
-        return true
+        shard.clients[ip] = b
+        return true, b.tokens
     }
+    shard.recent.MoveToFront(b.elem)
 
     // Calculate token refill since last request
     now := time.Now()
@@ -81,10 +269,10 @@ func (rl *RateLimiter) Allow(ip string) bool {
     // Check if enough tokens
     if b.tokens >= 1.0 {
         b.tokens -= 1.0
-        return true
+        return true, b.tokens
     }
 
-    return false
+    return false, b.tokens
 }
 
 // Helper function for float64 minimum
@@ -95,9 +283,40 @@ func min(a, b float64) float64 {
     return b
 }
 
-// Shutdown stops the cleanup ticker
+// setHeaders adds rate limit metadata computed from the client's bucket state
+func (rl *RateLimiter) setHeaders(w http.ResponseWriter, remaining float64) {
+    // Seconds until the bucket is full again
+    reset := 0
+    if rl.rate > 0 && remaining < float64(rl.bucketSize) {
+        reset = int(math.Ceil((float64(rl.bucketSize) - remaining) / rl.rate))
+    }
+
+    w.Header().Set("X-RateLimit-Limit", strconv.Itoa(rl.bucketSize))
+    w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(int(math.Floor(remaining))))
+    w.Header().Set("X-RateLimit-Reset", strconv.Itoa(reset))
+}
+
+// retryAfter returns the whole seconds until the bucket holds a full token again
+func (rl *RateLimiter) retryAfter(remaining float64) int {
+    if rl.rate <= 0 {
+        return 1
+    }
+    seconds := int(math.Ceil((1 - remaining) / rl.rate))
+    if seconds < 1 {
+        seconds = 1
+    }
+    return seconds
+}
+
+// Shutdown stops the cleanup routine and logs any pending rejection summary
 func (rl *RateLimiter) Shutdown() {
-    rl.cleanup.Stop()
+    rl.stopped.Do(func() {
+        rl.cleanup.Stop()
+        close(rl.done)
+    })
+    if rl.summary != nil {
+        rl.summary.stop()
+    }
 }
 
 // RateLimitMiddleware creates a middleware function for rate limiting
@@ -107,21 +326,56 @@ func (rl *RateLimiter) RateLimitMiddleware(next http.Handler) http.Handler {
         ip := getClientIP(r)
         if ip == "" {
             // Log this with the application logger if needed
-            http.Error(w, "Unable to determine client IP", http.StatusInternalServerError)
+            WriteError(w, r, http.StatusInternalServerError, ErrCodeInternal, "Unable to determine client IP")
             return
         }
 
         // Check if request is allowed
-        if !rl.Allow(ip) {
-            w.Header().Set("Content-Type", "application/json")
-            w.WriteHeader(http.StatusTooManyRequests)
-            json.NewEncoder(w).Encode(map[string]string{
-                "error": "Rate limit exceeded, please try again later",
-            })
+        allowed, remaining := rl.Allow(ip)
+        if rl.headers {
+            rl.setHeaders(w, remaining)
+        }
+        if !allowed {
+            if rl.summary != nil {
+                rl.summary.record(ip)
+                markSummarized(r.Context())
+            }
+            w.Header().Set("Retry-After", strconv.Itoa(rl.retryAfter(remaining)))
+            WriteError(w, r, http.StatusTooManyRequests, ErrCodeRateLimited,
+                "Rate limit exceeded, please try again later")
             return
         }
 
         // Pass to next handler if request is allowed
+        logAdmitted(r.Context())
         next.ServeHTTP(w, r)
     })
-}
\ No newline at end of file
+}
+
+// UnaryInterceptor applies the limit to gRPC calls, keyed on the peer address. The buckets are
+// shared with RateLimitMiddleware, so a client cannot double its allowance by switching
+// transports. Rejected calls fail with ResourceExhausted.
+func (rl *RateLimiter) UnaryInterceptor() grpc.UnaryServerInterceptor {
+    return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+        ip := peerIP(ctx)
+        if ip == "" {
+            return nil, status.Error(codes.Internal, "Unable to determine client IP")
+        }
+
+        allowed, remaining := rl.Allow(ip)
+        if !allowed {
+            if rl.summary != nil {
+                rl.summary.record(ip)
+            } else {
+                log.Warn().
+                    Str("client_ip", ip).
+                    Str("method", info.FullMethod).
+                    Msg("gRPC call rate-limited")
+            }
+            return nil, status.Errorf(codes.ResourceExhausted,
+                "Rate limit exceeded, please try again in %d seconds", rl.retryAfter(remaining))
+        }
+
+        return handler(ctx, req)
+    }
+}
@@ -0,0 +1,372 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// newTestRateLimiter creates a limiter that refills too slowly to matter within a test
+func newTestRateLimiter(t *testing.T, config RateLimiterConfig) *RateLimiter {
+	t.Helper()
+	if config.Rate == 0 {
+		config.Rate = 0.001
+	}
+	if config.BucketSize == 0 {
+		config.BucketSize = 3
+	}
+	if config.CleanupInterval == 0 {
+		config.CleanupInterval = time.Minute
+	}
+	limiter, err := NewRateLimiter(config)
+	if err != nil {
+		t.Fatalf("NewRateLimiter: %v", err)
+	}
+	t.Cleanup(limiter.Shutdown)
+	return limiter
+}
+
+// limitedRequest sends a request from ip through the limiter's middleware
+func limitedRequest(limiter *RateLimiter, ip string) *httptest.ResponseRecorder {
+	handler := limiter.RateLimitMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	r := httptest.NewRequest(http.MethodPost, "/validate", nil)
+	r.RemoteAddr = ip + ":12345"
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
+
+func TestRateLimiterExemptCIDRs(t *testing.T) {
+	limiter := newTestRateLimiter(t, RateLimiterConfig{ExemptCIDRs: []string{"10.0.0.0/8", "192.0.2.7"}})
+
+	for _, ip := range []string{"10.1.2.3", "192.0.2.7"} {
+		for i := 0; i < 50; i++ {
+			if w := limitedRequest(limiter, ip); w.Code != http.StatusOK {
+				t.Fatalf("exempt %s request %d: status %d", ip, i+1, w.Code)
+			}
+		}
+	}
+
+	// A non-exempt client is still limited after its burst
+	for i := 0; i < 3; i++ {
+		if w := limitedRequest(limiter, "192.0.2.8"); w.Code != http.StatusOK {
+			t.Fatalf("request %d within the burst: status %d", i+1, w.Code)
+		}
+	}
+	if w := limitedRequest(limiter, "192.0.2.8"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("request beyond the burst: status %d, want 429", w.Code)
+	}
+}
+
+func TestRateLimiterInvalidCIDR(t *testing.T) {
+	for _, cidr := range []string{"10.0.0.0/33", "not-an-ip", "300.1.1.1"} {
+		if _, err := NewRateLimiter(RateLimiterConfig{Rate: 1, BucketSize: 1, CleanupInterval: time.Minute, ExemptCIDRs: []string{cidr}}); err == nil {
+			t.Errorf("NewRateLimiter accepted exempt CIDR %q", cidr)
+		}
+	}
+}
+
+func TestRateLimiterHeadersDecrement(t *testing.T) {
+	limiter := newTestRateLimiter(t, RateLimiterConfig{BucketSize: 3, IncludeHeaders: true})
+
+	for _, want := range []string{"2", "1", "0"} {
+		w := limitedRequest(limiter, "192.0.2.1")
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200", w.Code)
+		}
+		if got := w.Header().Get("X-RateLimit-Limit"); got != "3" {
+			t.Errorf("X-RateLimit-Limit = %q, want 3", got)
+		}
+		if got := w.Header().Get("X-RateLimit-Remaining"); got != want {
+			t.Errorf("X-RateLimit-Remaining = %q, want %s", got, want)
+		}
+		if w.Header().Get("X-RateLimit-Reset") == "" {
+			t.Error("X-RateLimit-Reset is missing")
+		}
+	}
+
+	// Rejections carry the headers too
+	w := limitedRequest(limiter, "192.0.2.1")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("X-RateLimit-Remaining") != "0" {
+		t.Errorf("rejection: status %d, remaining %q", w.Code, w.Header().Get("X-RateLimit-Remaining"))
+	}
+}
+
+func TestRateLimiterHeadersOff(t *testing.T) {
+	limiter := newTestRateLimiter(t, RateLimiterConfig{})
+	if w := limitedRequest(limiter, "192.0.2.1"); w.Header().Get("X-RateLimit-Remaining") != "" {
+		t.Error("headers were set without IncludeHeaders")
+	}
+}
+
+func TestRateLimiterRejectionEnvelope(t *testing.T) {
+	limiter := newTestRateLimiter(t, RateLimiterConfig{Rate: 0.5, BucketSize: 1})
+	handler := LoggingMiddleware(limiter.RateLimitMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})))
+
+	var w *httptest.ResponseRecorder
+	for i := 0; i < 2; i++ {
+		r := httptest.NewRequest(http.MethodPost, "/validate", nil)
+		r.RemoteAddr = "192.0.2.1:12345"
+		w = httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+	}
+
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want 429", w.Code)
+	}
+	// One token every two seconds, and the bucket is empty
+	if got := w.Header().Get("Retry-After"); got != "2" {
+		t.Errorf("Retry-After = %q, want 2", got)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+
+	var resp ErrorResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode error envelope: %v", err)
+	}
+	if resp.Error.Code != ErrCodeRateLimited || resp.Error.Message == "" {
+		t.Errorf("error = %+v, want %s with a message", resp.Error, ErrCodeRateLimited)
+	}
+	if resp.Error.RequestID == "" || resp.Error.RequestID != w.Header().Get("X-Request-Id") {
+		t.Errorf("request ID = %q, want the X-Request-Id header %q", resp.Error.RequestID, w.Header().Get("X-Request-Id"))
+	}
+}
+
+func TestParseRateLimitAlgorithm(t *testing.T) {
+	tests := map[string]RateLimitAlgorithm{
+		"":        AlgorithmTokenBucket,
+		"token":   AlgorithmTokenBucket,
+		"leaky":   AlgorithmLeakyBucket,
+		" Leaky ": AlgorithmLeakyBucket,
+	}
+	for s, want := range tests {
+		if got, err := ParseRateLimitAlgorithm(s); err != nil || got != want {
+			t.Errorf("ParseRateLimitAlgorithm(%q) = %v, %v; want %v", s, got, err, want)
+		}
+	}
+	if _, err := ParseRateLimitAlgorithm("sliding"); err == nil {
+		t.Error("ParseRateLimitAlgorithm(sliding) succeeded, want an error")
+	}
+}
+
+func TestRateLimiterBurstByAlgorithm(t *testing.T) {
+	// At 20 requests a second, both algorithms allow the same long-run rate
+	tests := []struct {
+		algorithm RateLimitAlgorithm
+		burst     int
+	}{
+		{AlgorithmTokenBucket, 5},
+		{AlgorithmLeakyBucket, 1}, // BucketSize is ignored
+	}
+
+	for _, tt := range tests {
+		limiter := newTestRateLimiter(t, RateLimiterConfig{Rate: 20, BucketSize: 5, Algorithm: tt.algorithm})
+
+		allowed := 0
+		for i := 0; i < 10; i++ {
+			if limitedRequest(limiter, "192.0.2.1").Code == http.StatusOK {
+				allowed++
+			}
+		}
+		if allowed != tt.burst {
+			t.Errorf("algorithm %d: %d of 10 back-to-back requests allowed, want %d", tt.algorithm, allowed, tt.burst)
+		}
+	}
+}
+
+func TestLeakyBucketMinimumInterval(t *testing.T) {
+	limiter := newTestRateLimiter(t, RateLimiterConfig{Rate: 20, Algorithm: AlgorithmLeakyBucket})
+
+	if w := limitedRequest(limiter, "192.0.2.1"); w.Code != http.StatusOK {
+		t.Fatalf("first request: status = %d, want 200", w.Code)
+	}
+	if w := limitedRequest(limiter, "192.0.2.1"); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("immediate second request: status = %d, want 429", w.Code)
+	}
+
+	// After the 50ms interval exactly one more request is allowed, not a burst
+	time.Sleep(70 * time.Millisecond)
+	if w := limitedRequest(limiter, "192.0.2.1"); w.Code != http.StatusOK {
+		t.Errorf("after the interval: status = %d, want 200", w.Code)
+	}
+	if w := limitedRequest(limiter, "192.0.2.1"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("second request after the interval: status = %d, want 429", w.Code)
+	}
+}
+
+// trackedClients counts the client buckets held across every shard
+func trackedClients(limiter *RateLimiter) int {
+	total := 0
+	for _, shard := range limiter.shards {
+		shard.mu.Lock()
+		total += len(shard.clients)
+		shard.mu.Unlock()
+	}
+	return total
+}
+
+// sameShardIPs returns n distinct addresses that hash to the same shard as ip
+func sameShardIPs(limiter *RateLimiter, ip string, n int) []string {
+	var ips []string
+	for i := 0; len(ips) < n; i++ {
+		candidate := fmt.Sprintf("198.51.%d.%d", i/256, i%256)
+		if candidate != ip && limiter.shardFor(candidate) == limiter.shardFor(ip) {
+			ips = append(ips, candidate)
+		}
+	}
+	return ips
+}
+
+func TestRateLimiterMaxClientsBounded(t *testing.T) {
+	limiter := newTestRateLimiter(t, RateLimiterConfig{MaxClients: 64})
+
+	// A flood of unique addresses between cleanups
+	for i := 0; i < 20000; i++ {
+		limiter.Allow(fmt.Sprintf("10.%d.%d.%d", i>>16, (i>>8)&0xff, i&0xff))
+	}
+	if n := trackedClients(limiter); n > 64 {
+		t.Errorf("%d clients tracked, want at most 64", n)
+	}
+	for _, shard := range limiter.shards {
+		if len(shard.clients) != shard.recent.Len() {
+			t.Fatalf("shard holds %d clients but %d recency entries", len(shard.clients), shard.recent.Len())
+		}
+	}
+}
+
+func TestRateLimiterMaxClientsUnbounded(t *testing.T) {
+	limiter := newTestRateLimiter(t, RateLimiterConfig{MaxClients: 0})
+	for i := 0; i < 1000; i++ {
+		limiter.Allow(fmt.Sprintf("10.0.%d.%d", i/256, i%256))
+	}
+	if n := trackedClients(limiter); n != 1000 {
+		t.Errorf("%d clients tracked, want all 1000", n)
+	}
+}
+
+func TestRateLimiterEvictsLeastRecentlySeen(t *testing.T) {
+	// Two clients per shard
+	limiter := newTestRateLimiter(t, RateLimiterConfig{BucketSize: 1, MaxClients: 2 * shardCount})
+	active := "192.0.2.1"
+	others := sameShardIPs(limiter, active, 2)
+
+	if allowed, _ := limiter.Allow(active); !allowed {
+		t.Fatal("first request refused")
+	}
+	limiter.Allow(others[0])
+
+	// Seeing the active client again makes others[0] the least recently seen
+	if allowed, _ := limiter.Allow(active); allowed {
+		t.Fatal("second request allowed with an empty bucket")
+	}
+	limiter.Allow(others[1])
+
+	// The active client kept its empty bucket; others[0] was evicted and starts over
+	if allowed, _ := limiter.Allow(active); allowed {
+		t.Error("active client was evicted instead of the least recently seen")
+	}
+	if allowed, _ := limiter.Allow(others[0]); !allowed {
+		t.Error("evicted client did not start over with a full bucket")
+	}
+}
+
+// benchmarkIPs are the clients spread across the parallel benchmarks
+var benchmarkIPs = func() []string {
+	ips := make([]string, 1024)
+	for i := range ips {
+		ips[i] = fmt.Sprintf("10.0.%d.%d", i/256, i%256)
+	}
+	return ips
+}()
+
+func BenchmarkAllowParallel(b *testing.B) {
+	limiter, err := NewRateLimiter(RateLimiterConfig{Rate: 1e9, BucketSize: 1 << 30, CleanupInterval: time.Hour})
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer limiter.Shutdown()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			limiter.Allow(benchmarkIPs[i%len(benchmarkIPs)])
+			i++
+		}
+	})
+}
+
+// BenchmarkAllowParallelSingleLock serializes Allow behind one mutex, as the limiter did
+// before its client map was sharded, for comparison with BenchmarkAllowParallel
+func BenchmarkAllowParallelSingleLock(b *testing.B) {
+	limiter, err := NewRateLimiter(RateLimiterConfig{Rate: 1e9, BucketSize: 1 << 30, CleanupInterval: time.Hour})
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer limiter.Shutdown()
+
+	var mu sync.Mutex
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			mu.Lock()
+			limiter.Allow(benchmarkIPs[i%len(benchmarkIPs)])
+			mu.Unlock()
+			i++
+		}
+	})
+}
+
+func TestRateLimiterUnaryInterceptor(t *testing.T) {
+	limiter := newTestRateLimiter(t, RateLimiterConfig{BucketSize: 2})
+	interceptor := limiter.UnaryInterceptor()
+	info := &grpc.UnaryServerInfo{FullMethod: "/validator.v1.CardValidator/Validate"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) { return "ok", nil }
+	call := func(ip string) error {
+		ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP(ip), Port: 40000}})
+		_, err := interceptor(ctx, nil, info, handler)
+		return err
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := call("192.0.2.1"); err != nil {
+			t.Fatalf("call %d: %v", i, err)
+		}
+	}
+	if err := call("192.0.2.1"); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("third call: error = %v, want ResourceExhausted", err)
+	}
+	if err := call("192.0.2.2"); err != nil {
+		t.Errorf("other client: %v", err)
+	}
+
+	// HTTP requests from the same address share the bucket
+	if w := limitedRequest(limiter, "192.0.2.1"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("HTTP after gRPC calls: status %d, want 429", w.Code)
+	}
+
+	// A call without a peer cannot be attributed to a client
+	if _, err := interceptor(context.Background(), nil, info, handler); status.Code(err) != codes.Internal {
+		t.Errorf("no peer: error = %v, want Internal", err)
+	}
+}
@@ -0,0 +1,138 @@
+package middleware
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// maxSummarizedClients bounds the clients counted individually in one summary interval.
+// Rejections from further clients are reported together as a single total.
+const maxSummarizedClients = 10000
+
+// rejectionSummary counts rate-limited requests per client and logs one summary line per
+// client each interval, instead of one line per rejected request
+type rejectionSummary struct {
+	interval time.Duration
+
+	mu      sync.Mutex
+	counts  map[string]int
+	others  int // rejections from clients beyond maxSummarizedClients
+	ticker  *time.Ticker
+	done    chan struct{}
+	stopped sync.Once
+}
+
+// newRejectionSummary starts a summary flushed every interval
+func newRejectionSummary(interval time.Duration) *rejectionSummary {
+	s := &rejectionSummary{
+		interval: interval,
+		counts:   make(map[string]int),
+		ticker:   time.NewTicker(interval),
+		done:     make(chan struct{}),
+	}
+
+	go func() {
+		for {
+			select {
+			case <-s.ticker.C:
+				s.flush()
+			case <-s.done:
+				return
+			}
+		}
+	}()
+
+	return s
+}
+
+// record counts a rejection for the client
+func (s *rejectionSummary) record(ip string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.counts[ip]; !ok && len(s.counts) >= maxSummarizedClients {
+		s.others++
+		return
+	}
+	s.counts[ip]++
+}
+
+// flush logs and resets the counts, busiest clients first
+func (s *rejectionSummary) flush() {
+	s.mu.Lock()
+	counts, others := s.counts, s.others
+	s.counts, s.others = make(map[string]int), 0
+	s.mu.Unlock()
+
+	ips := make([]string, 0, len(counts))
+	for ip := range counts {
+		ips = append(ips, ip)
+	}
+	sort.Slice(ips, func(i, j int) bool { return counts[ips[i]] > counts[ips[j]] })
+
+	for _, ip := range ips {
+		log.Warn().
+			Str("client_ip", ip).
+			Int("count", counts[ip]).
+			Dur("window", s.interval).
+			Msgf("%d requests rate-limited from IP %s in the last %s", counts[ip], ip, s.interval)
+	}
+	if others > 0 {
+		log.Warn().
+			Int("count", others).
+			Dur("window", s.interval).
+			Msgf("%d requests rate-limited from other clients in the last %s", others, s.interval)
+	}
+}
+
+// stop halts the ticker and logs whatever has been counted since the last flush
+func (s *rejectionSummary) stop() {
+	s.stopped.Do(func() {
+		s.ticker.Stop()
+		close(s.done)
+		s.flush()
+	})
+}
+
+// logState carries per-request decisions about the request's log lines from inner middleware
+type logState struct {
+	summarized atomic.Bool // the outcome is reported in an aggregate summary instead
+
+	started   sync.Once
+	startLine func(summarized bool) // writes the deferred "Request started" line
+}
+
+// logStart writes the start line once, at debug level if the request has been summarized
+func (s *logState) logStart() {
+	if s == nil || s.startLine == nil {
+		return
+	}
+	s.started.Do(func() { s.startLine(s.summarized.Load()) })
+}
+
+// withLogState attaches an empty logState to the context
+func withLogState(ctx context.Context) (context.Context, *logState) {
+	state := &logState{}
+	return context.WithValue(ctx, logStateKey, state), state
+}
+
+// markSummarized tells LoggingMiddleware that the request's outcome is covered by a summary,
+// so its start and completion lines are demoted to debug level
+func markSummarized(ctx context.Context) {
+	if state, ok := ctx.Value(logStateKey).(*logState); ok {
+		state.summarized.Store(true)
+	}
+}
+
+// logAdmitted writes the request's start line once it is past the middleware that may summarize
+// it, so later application logs follow the line as usual
+func logAdmitted(ctx context.Context) {
+	if state, ok := ctx.Value(logStateKey).(*logState); ok {
+		state.logStart()
+	}
+}
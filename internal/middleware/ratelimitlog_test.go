@@ -0,0 +1,218 @@
+package middleware
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// lockedBuffer is a log buffer that a flushing goroutine and the test can share
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func (b *lockedBuffer) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Len()
+}
+
+func (b *lockedBuffer) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.buf.Reset()
+}
+
+// captureSharedLogs captures the global logger, including lines written from other goroutines
+func captureSharedLogs(t *testing.T) *lockedBuffer {
+	t.Helper()
+	buf := &lockedBuffer{}
+	previous := log.Logger
+	log.Logger = zerolog.New(buf)
+	t.Cleanup(func() { log.Logger = previous })
+	return buf
+}
+
+// newSummaryLimiter returns a limiter with a one-token bucket that refills too slowly to matter
+func newSummaryLimiter(t *testing.T, interval time.Duration) *RateLimiter {
+	t.Helper()
+	limiter, err := NewRateLimiter(RateLimiterConfig{Rate: 0.001, BucketSize: 1, CleanupInterval: time.Minute, LogSummaryInterval: interval})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(limiter.Shutdown)
+	return limiter
+}
+
+func TestRejectionSummaryFlush(t *testing.T) {
+	logs := captureSharedLogs(t)
+	summary := newRejectionSummary(time.Hour)
+	defer summary.stop()
+
+	for i := 0; i < 3; i++ {
+		summary.record("192.0.2.1")
+	}
+	for i := 0; i < 5; i++ {
+		summary.record("192.0.2.2")
+	}
+	summary.flush()
+
+	lines := strings.Split(strings.TrimSpace(logs.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("%d summary lines, want 2: %s", len(lines), logs)
+	}
+	// Busiest client first
+	for i, want := range []string{
+		`"client_ip":"192.0.2.2","count":5`,
+		`"client_ip":"192.0.2.1","count":3`,
+	} {
+		if !strings.Contains(lines[i], want) || !strings.Contains(lines[i], `"level":"warn"`) {
+			t.Errorf("line %d = %s, want it to contain %s", i, lines[i], want)
+		}
+	}
+	if !strings.Contains(lines[0], "5 requests rate-limited from IP 192.0.2.2 in the last 1h0m0s") {
+		t.Errorf("message = %s", lines[0])
+	}
+
+	// Counts reset after each flush
+	logs.Reset()
+	summary.flush()
+	if logs.Len() != 0 {
+		t.Errorf("second flush logged %s", logs)
+	}
+}
+
+func TestRejectionSummaryBoundsClients(t *testing.T) {
+	logs := captureSharedLogs(t)
+	summary := newRejectionSummary(time.Hour)
+	defer summary.stop()
+
+	for i := 0; i < maxSummarizedClients; i++ {
+		summary.record(string(rune(i)))
+	}
+	// Known clients are still counted; new ones go into the shared total
+	summary.record(string(rune(0)))
+	summary.record("203.0.113.1")
+	summary.record("203.0.113.2")
+	if len(summary.counts) != maxSummarizedClients || summary.others != 2 {
+		t.Errorf("%d clients, %d others; want %d, 2", len(summary.counts), summary.others, maxSummarizedClients)
+	}
+
+	summary.flush()
+	if !strings.Contains(logs.String(), "2 requests rate-limited from other clients") {
+		t.Error("no summary line for clients beyond the bound")
+	}
+}
+
+func TestRateLimiterAggregatesRejectionLogs(t *testing.T) {
+	// Each rejection beyond the first request adds no lines, so the total does not grow with n
+	for _, n := range []int{5, 50} {
+		logs := captureSharedLogs(t)
+		log.Logger = log.Logger.Level(zerolog.InfoLevel)
+		limiter := newSummaryLimiter(t, time.Hour)
+		handler := LoggingMiddleware(limiter.RateLimitMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+
+		for i := 0; i < n; i++ {
+			r := httptest.NewRequest(http.MethodGet, "/stats", nil)
+			r.RemoteAddr = "192.0.2.1:12345"
+			handler.ServeHTTP(httptest.NewRecorder(), r)
+		}
+		limiter.Shutdown()
+
+		// The admitted request's start and completion lines, then one summary line
+		lines := strings.Split(strings.TrimSpace(logs.String()), "\n")
+		if len(lines) != 3 {
+			t.Fatalf("%d requests: %d lines, want 3: %s", n, len(lines), logs)
+		}
+		for i, want := range []string{"Request started", "Request completed", fmt.Sprintf("%d requests rate-limited from IP 192.0.2.1", n-1)} {
+			if !strings.Contains(lines[i], want) {
+				t.Errorf("%d requests: line %d = %s, want %q", n, i, lines[i], want)
+			}
+		}
+	}
+}
+
+func TestLoggingStartLineOrder(t *testing.T) {
+	logs := captureSharedLogs(t)
+	limiter := newSummaryLimiter(t, time.Hour)
+	handler := LoggingMiddleware(limiter.RateLimitMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger := ApplicationLogger(r.Context())
+		logger.Info().Msg("handling")
+	})))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/stats", nil))
+
+	// An admitted request's start line precedes the handler's own logs
+	started, handling := strings.Index(logs.String(), "Request started"), strings.Index(logs.String(), "handling")
+	if started < 0 || handling < started {
+		t.Errorf("log = %s, want the start line first", logs)
+	}
+	if strings.Count(logs.String(), "Request started") != 1 {
+		t.Errorf("start line not logged exactly once: %s", logs)
+	}
+}
+
+func TestRateLimiterLogsEachRejectionByDefault(t *testing.T) {
+	logs := captureSharedLogs(t)
+	limiter := newSummaryLimiter(t, 0)
+	handler := LoggingMiddleware(limiter.RateLimitMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+
+	for i := 0; i < 3; i++ {
+		r := httptest.NewRequest(http.MethodGet, "/stats", nil)
+		r.RemoteAddr = "192.0.2.1:12345"
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+	}
+	if n := strings.Count(logs.String(), `"level":"error"`); n != 2 {
+		t.Errorf("%d error lines, want one per rejection: %s", n, logs)
+	}
+	if strings.Contains(logs.String(), "rate-limited from IP") {
+		t.Errorf("summary logged without an interval: %s", logs)
+	}
+}
+
+func TestRejectionSummaryFlushesOnInterval(t *testing.T) {
+	logs := captureSharedLogs(t)
+	summary := newRejectionSummary(10 * time.Millisecond)
+	defer summary.stop()
+	summary.record("192.0.2.1")
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !strings.Contains(logs.String(), "1 requests rate-limited from IP 192.0.2.1") {
+		if time.Now().After(deadline) {
+			t.Fatalf("no summary after the interval: %s", logs)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func BenchmarkRejectionSummaryRecord(b *testing.B) {
+	previous := log.Logger
+	log.Logger = zerolog.Nop()
+	defer func() { log.Logger = previous }()
+	summary := newRejectionSummary(time.Hour)
+	defer summary.stop()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		summary.record("192.0.2.1")
+	}
+}
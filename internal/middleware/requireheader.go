@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// HeaderExemptPaths are served without the required header so health probes can reach the
+// service directly rather than through the gateway, and so the web page loads. Everything
+// else, including /stats, requires it.
+var HeaderExemptPaths = []string{"/", "/healthz"}
+
+// HeaderExemptPrefixes are path prefixes served without the required header, for the
+// web page's static assets
+var HeaderExemptPrefixes = []string{"/static/"}
+
+// RequireHeaderMiddleware rejects requests whose header name does not equal value with
+// 403 Forbidden. It is meant for deployments behind a gateway that injects a shared
+// secret, so clients cannot bypass the gateway. Values are compared in constant time,
+// and hashed first so the comparison does not reveal the secret's length either.
+//
+// Browsers cannot add the header themselves: the page's fetch calls and its native
+// <form action="/validate" method="post"> fallback only succeed when the gateway injects it.
+// Reaching the page directly loads it, but its submissions are rejected.
+func RequireHeaderMiddleware(name, value string) func(http.Handler) http.Handler {
+	want := sha256.Sum256([]byte(value))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if headerExempt(r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			got := sha256.Sum256([]byte(r.Header.Get(name)))
+			if subtle.ConstantTimeCompare(got[:], want[:]) != 1 {
+				logger := ApplicationLogger(r.Context())
+				logger.Warn().
+					Str("header", name).
+					Bool("present", r.Header.Get(name) != "").
+					Str("client_ip", getClientIP(r)).
+					Msg("Rejected request without required header")
+				WriteError(w, r, http.StatusForbidden, ErrCodeForbidden, "Forbidden")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireMetadataInterceptor is RequireHeaderMiddleware for gRPC: calls whose metadata
+// does not carry name with value fail with PermissionDenied. Metadata keys are lowercase,
+// so name is matched case-insensitively, as HTTP header names are.
+func RequireMetadataInterceptor(name, value string) grpc.UnaryServerInterceptor {
+	want := sha256.Sum256([]byte(value))
+	key := strings.ToLower(name)
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		var sent string
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get(key); len(values) > 0 {
+				sent = values[0]
+			}
+		}
+
+		got := sha256.Sum256([]byte(sent))
+		if subtle.ConstantTimeCompare(got[:], want[:]) != 1 {
+			log.Warn().
+				Str("header", name).
+				Bool("present", sent != "").
+				Str("client_ip", peerIP(ctx)).
+				Str("method", info.FullMethod).
+				Msg("Rejected gRPC call without required header")
+			return nil, status.Error(codes.PermissionDenied, "Forbidden")
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// headerExempt reports whether the path is served without the required header
+func headerExempt(path string) bool {
+	for _, exempt := range HeaderExemptPaths {
+		if path == exempt {
+			return true
+		}
+	}
+	for _, prefix := range HeaderExemptPrefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestRequireHeaderMiddleware(t *testing.T) {
+	handler := RequireHeaderMiddleware("X-Gateway-Secret", "s3cret")(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}))
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		header string
+		want   int
+	}{
+		{"correct header", http.MethodPost, "/validate", "s3cret", http.StatusNoContent},
+		{"missing header", http.MethodPost, "/validate", "", http.StatusForbidden},
+		{"wrong header", http.MethodPost, "/validate", "s3cret!", http.StatusForbidden},
+		{"native form post without header", http.MethodPost, "/validate", "", http.StatusForbidden},
+		{"web page", http.MethodGet, "/", "", http.StatusNoContent},
+		{"static asset", http.MethodGet, "/static/js/app.js", "", http.StatusNoContent},
+		{"health probe", http.MethodGet, "/healthz", "", http.StatusNoContent},
+		{"stats without header", http.MethodGet, "/stats", "", http.StatusForbidden},
+		{"stats with header", http.MethodGet, "/stats", "s3cret", http.StatusNoContent},
+		{"prefix of an exempt path", http.MethodGet, "/healthzx", "", http.StatusForbidden},
+		{"openapi", http.MethodGet, "/openapi.json", "", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.header != "" {
+				r.Header.Set("X-Gateway-Secret", tt.header)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			if w.Code != tt.want {
+				t.Errorf("%s %s: status = %d, want %d", tt.method, tt.path, w.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequireMetadataInterceptor(t *testing.T) {
+	interceptor := RequireMetadataInterceptor("X-Gateway-Secret", "s3cret")
+	info := &grpc.UnaryServerInfo{FullMethod: "/validator.v1.CardValidator/Validate"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) { return "ok", nil }
+
+	tests := []struct {
+		name string
+		md   metadata.MD
+		want codes.Code
+	}{
+		{"correct metadata", metadata.Pairs("x-gateway-secret", "s3cret"), codes.OK},
+		{"missing metadata", nil, codes.PermissionDenied},
+		{"wrong value", metadata.Pairs("x-gateway-secret", "S3CRET"), codes.PermissionDenied},
+		{"other key", metadata.Pairs("x-other", "s3cret"), codes.PermissionDenied},
+	}
+	for _, tt := range tests {
+		ctx := context.Background()
+		if tt.md != nil {
+			ctx = metadata.NewIncomingContext(ctx, tt.md)
+		}
+		resp, err := interceptor(ctx, nil, info, handler)
+		if status.Code(err) != tt.want {
+			t.Errorf("%s: error = %v, want %v", tt.name, err, tt.want)
+		}
+		if (resp != nil) != (tt.want == codes.OK) {
+			t.Errorf("%s: handler ran %v, want %v", tt.name, resp != nil, tt.want == codes.OK)
+		}
+	}
+}
@@ -3,10 +3,13 @@ package middleware
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"regexp"
 	"strings"
+
+	"github.com/jamesmeyerr/credit-card-validator/internal/luhn"
 )
 
 // SanitizationConfig defines sanitization rules
@@ -15,15 +18,39 @@ type SanitizationConfig struct {
 	MaxExpiryLength     int
 	MaxCVVLength        int
 	MaxRequestSize      int64 // in bytes
+
+	// PreserveOriginalFormat keeps the caller's original card number string
+	// in the card_number_original field so it can be echoed back for display
+	PreserveOriginalFormat bool
+
+	// LenientExpiry canonicalizes forms like 9/25 and 09/2025 into MM/YY instead of rejecting them
+	LenientExpiry bool
+
+	// Report lists the transformations applied to the request in the X-Sanitize-Report
+	// response header. Only transformation names are reported, never field values.
+	Report bool
+
+	// Status chooses between 400 and 422 for fields that fail sanitization
+	Status StatusConfig
 }
 
+// Transformation names reported in the X-Sanitize-Report header
+const (
+	TransformStrippedNonDigits = "stripped_non_digits"
+	TransformNormalizedExpiry  = "normalized_expiry"
+)
+
 // DefaultSanitizationConfig returns a default configuration
 func DefaultSanitizationConfig() SanitizationConfig {
 	return SanitizationConfig{
-		MaxCardNumberLength: 19,    // Maximum valid card number length
-		MaxExpiryLength:     5,     // Format: MM/YY
-		MaxCVVLength:        4,     // Max 4 digits for Amex
-		MaxRequestSize:      1024,  // 1KB is more than enough for our small JSON payload
+		MaxCardNumberLength: luhn.ISOMaxPANLength, // Maximum valid card number length
+		MaxExpiryLength:     5,                    // Format: MM/YY
+		MaxCVVLength:        4,                    // Max 4 digits for Amex
+		MaxRequestSize:      1024,                 // 1KB is more than enough for our small JSON payload
+		PreserveOriginalFormat: false,
+		LenientExpiry:          false,
+		Report:                 false,
+		Status:                 DefaultStatusConfig(),
 	}
 }
 
@@ -42,10 +69,22 @@ func NewInputSanitizer(config SanitizationConfig) *InputSanitizer {
 // SanitizeMiddleware creates a middleware function for input sanitization
 func (is *InputSanitizer) SanitizeMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Only process POST/GET requests with JSON content
+		// Never accept a CVV on GET, where it can end up in URLs, proxy logs, and browser history
+		if r.Method == http.MethodGet && r.URL.Query().Has("cvv") {
+			rejectCVVOnGet(w, r, "query")
+			return
+		}
+
+		// A bodyless GET has nothing to sanitize, and needs no Content-Type
+		if !hasBody(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// Only process requests with JSON content
 		contentType := r.Header.Get("Content-Type")
 		if !strings.Contains(strings.ToLower(contentType), "application/json") {
-			http.Error(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+			WriteError(w, r, http.StatusUnsupportedMediaType, ErrCodeUnsupportedMediaType, "Content-Type must be application/json")
 			return
 		}
 
@@ -55,7 +94,8 @@ func (is *InputSanitizer) SanitizeMiddleware(next http.Handler) http.Handler {
 		// Read the body
 		body, err := io.ReadAll(r.Body)
 		if err != nil {
-			http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+			WriteError(w, r, http.StatusRequestEntityTooLarge, ErrCodeRequestTooLarge,
+				fmt.Sprintf("request body exceeds max size %d bytes", is.config.MaxRequestSize))
 			return
 		}
 		
@@ -65,32 +105,69 @@ func (is *InputSanitizer) SanitizeMiddleware(next http.Handler) http.Handler {
 		// Try to parse as JSON to ensure it's valid
 		var requestMap map[string]interface{}
 		if err := json.Unmarshal(body, &requestMap); err != nil {
-			http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+			WriteError(w, r, http.StatusBadRequest, ErrCodeInvalidJSON, JSONErrorMessage(err, body))
+			return
+		}
+
+		// Reject repeated keys, which decoders resolve differently and can be used to smuggle values
+		if key, ok := findDuplicateKey(body); ok {
+			WriteError(w, r, http.StatusBadRequest, ErrCodeDuplicateKey,
+				fmt.Sprintf("duplicate key %q in request body", key))
 			return
 		}
 
+		// The same rule applies to a CVV in a GET body
+		if _, ok := requestMap["cvv"]; ok && r.Method == http.MethodGet {
+			rejectCVVOnGet(w, r, "body")
+			return
+		}
+
+		// Names of the transformations applied, for the optional report header
+		var applied []string
+
 		// Sanitize card number - only keep digits
 		if cardNumber, ok := requestMap["card_number"].(string); ok {
-			sanitized := sanitizeCardNumber(cardNumber)
-			if len(sanitized) > is.config.MaxCardNumberLength {
-				http.Error(w, "Card number exceeds maximum allowed length", http.StatusBadRequest)
+			sanitized, fieldErr := is.cleanCardNumber(cardNumber)
+			if fieldErr != nil {
+				is.writeFieldError(w, r, fieldErr)
 				return
 			}
 			requestMap["card_number"] = sanitized
+			if sanitized != cardNumber {
+				applied = append(applied, TransformStrippedNonDigits)
+			}
+
+			// Keep the original input alongside the digits-only form when requested
+			if is.config.PreserveOriginalFormat {
+				requestMap["card_number_original"] = cardNumber
+			} else {
+				delete(requestMap, "card_number_original")
+			}
 		}
 
-		// Sanitize expiry date - validate format
-		if expiryDate, ok := requestMap["expiry_date"].(string); ok {
-			if !isValidExpiryFormat(expiryDate) || len(expiryDate) > is.config.MaxExpiryLength {
-				http.Error(w, "Invalid expiry date format", http.StatusBadRequest)
+		// Sanitize expiry dates - validate format. new_expiry_date is the renewed card's expiry in update flows.
+		normalizedExpiry := false
+		for _, field := range []string{"expiry_date", "new_expiry_date"} {
+			expiryDate, ok := requestMap[field].(string)
+			if !ok {
+				continue
+			}
+			checked, fieldErr := is.checkExpiry(field, expiryDate)
+			if fieldErr != nil {
+				is.writeFieldError(w, r, fieldErr)
 				return
 			}
+			if checked != expiryDate && !normalizedExpiry {
+				applied = append(applied, TransformNormalizedExpiry)
+				normalizedExpiry = true
+			}
+			requestMap[field] = checked
 		}
 
 		// Sanitize CVV - only allow digits
 		if cvv, ok := requestMap["cvv"].(string); ok {
-			if !isValidCVV(cvv) || len(cvv) > is.config.MaxCVVLength {
-				http.Error(w, "Invalid CVV format", http.StatusBadRequest)
+			if fieldErr := is.checkCVV(cvv); fieldErr != nil {
+				is.writeFieldError(w, r, fieldErr)
 				return
 			}
 		}
@@ -98,7 +175,7 @@ func (is *InputSanitizer) SanitizeMiddleware(next http.Handler) http.Handler {
 		// Convert back to JSON
 		sanitizedBody, err := json.Marshal(requestMap)
 		if err != nil {
-			http.Error(w, "Error processing request", http.StatusInternalServerError)
+			WriteError(w, r, http.StatusInternalServerError, ErrCodeInternal, "Error processing request")
 			return
 		}
 
@@ -108,30 +185,218 @@ func (is *InputSanitizer) SanitizeMiddleware(next http.Handler) http.Handler {
 		// Update Content-Length header
 		r.ContentLength = int64(len(sanitizedBody))
 		
+		if is.config.Report && len(applied) > 0 {
+			w.Header().Set("X-Sanitize-Report", strings.Join(applied, ","))
+		}
+
 		// Pass to next handler
 		next.ServeHTTP(w, r)
 	})
 }
 
-// sanitizeCardNumber removes all non-digit characters
-func sanitizeCardNumber(input string) string {
-	var sanitized strings.Builder
-	for _, char := range input {
-		if char >= '0' && char <= '9' {
-			sanitized.WriteRune(char)
+// rejectCVVOnGet refuses a GET request carrying a CVV and records the attempt
+func rejectCVVOnGet(w http.ResponseWriter, r *http.Request, location string) {
+	logger := ApplicationLogger(r.Context())
+	logger.Warn().
+		Str("cvv_location", location).
+		Str("client_ip", getClientIP(r)).
+		Msg("Rejected CVV sent with GET request")
+	WriteError(w, r, http.StatusBadRequest, ErrCodeCVVNotAllowed,
+		"cvv must not be sent with GET requests; use POST")
+}
+
+// findDuplicateKey scans the top-level object with a streaming decoder and returns the first
+// key that repeats. Keys are compared case-insensitively because encoding/json matches struct
+// fields that way, so "card_number" and "Card_Number" would otherwise compete for one field.
+func findDuplicateKey(body []byte) (string, bool) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return "", false
+	}
+
+	seen := make(map[string]bool)
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return "", false
+		}
+		key, ok := token.(string)
+		if !ok {
+			return "", false
+		}
+		if seen[strings.ToLower(key)] {
+			return key, true
+		}
+		seen[strings.ToLower(key)] = true
+
+		// Skip the value, however deeply nested
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return "", false
 		}
 	}
-	return sanitized.String()
+	return "", false
+}
+
+// FieldError describes an input field that fails the sanitizer's length or format rules
+type FieldError struct {
+	Code    string // the ErrCode value the HTTP error envelope carries
+	Field   string
+	Message string
+}
+
+// Error returns the message
+func (e *FieldError) Error() string {
+	return e.Message
+}
+
+// writeFieldError rejects a request whose field failed sanitization
+func (is *InputSanitizer) writeFieldError(w http.ResponseWriter, r *http.Request, fieldErr *FieldError) {
+	WriteError(w, r, is.config.Status.ValidationStatus(), fieldErr.Code, fieldErr.Message)
 }
 
+// fieldTooLong describes a field that exceeds its maximum length, naming the field and both lengths
+func fieldTooLong(field string, limit, received int) *FieldError {
+	return &FieldError{
+		Code:    ErrCodeFieldTooLong,
+		Field:   field,
+		Message: fmt.Sprintf("%s exceeds max length %d, received %d", field, limit, received),
+	}
+}
+
+// cleanCardNumber reduces a card number to its digits
+func (is *InputSanitizer) cleanCardNumber(cardNumber string) (string, *FieldError) {
+	sanitized := luhn.Clean(cardNumber)
+	if sanitized == "" && cardNumber != "" {
+		// Separators or whitespace alone would otherwise look like a missing card number
+		return "", &FieldError{Code: ErrCodeNoDigits, Field: "card_number", Message: "No digits found in card number"}
+	}
+	if len(sanitized) > is.config.MaxCardNumberLength {
+		return "", fieldTooLong("card_number", is.config.MaxCardNumberLength, len(sanitized))
+	}
+	return sanitized, nil
+}
+
+// checkExpiry returns an expiry date in MM/YY form. In lenient mode, acceptable variants
+// are rewritten into that form first.
+func (is *InputSanitizer) checkExpiry(field, expiryDate string) (string, *FieldError) {
+	if is.config.LenientExpiry {
+		canonical, ok := canonicalizeExpiry(expiryDate)
+		if !ok {
+			return "", &FieldError{Code: ErrCodeInvalidExpiry, Field: field,
+				Message: field + " must be in M/YY or MM/YYYY format, with a year from 2000 to 2099"}
+		}
+		expiryDate = canonical
+	}
+	if len(expiryDate) > is.config.MaxExpiryLength {
+		return "", fieldTooLong(field, is.config.MaxExpiryLength, len(expiryDate))
+	}
+	if !isValidExpiryFormat(expiryDate) {
+		return "", &FieldError{Code: ErrCodeInvalidExpiry, Field: field, Message: field + " must be in MM/YY format"}
+	}
+	return expiryDate, nil
+}
+
+// checkCVV rejects a CVV that is too long or not 3 or 4 digits
+func (is *InputSanitizer) checkCVV(cvv string) *FieldError {
+	if len(cvv) > is.config.MaxCVVLength {
+		return fieldTooLong("cvv", is.config.MaxCVVLength, len(cvv))
+	}
+	if !isValidCVV(cvv) {
+		return &FieldError{Code: ErrCodeInvalidCVV, Field: "cvv", Message: "cvv must be 3 or 4 digits"}
+	}
+	return nil
+}
+
+// CardFields are the fields of one card that the sanitizer checks
+type CardFields struct {
+	CardNumber    string
+	ExpiryDate    string
+	NewExpiryDate string
+	CVV           string
+}
+
+// SanitizeFields applies the same length and format rules as SanitizeMiddleware to one card,
+// for transports that do not pass through it, such as gRPC. Empty fields are treated as absent.
+// The result holds the digits-only card number and MM/YY expiry dates; a failing field is
+// reported as a *FieldError.
+func (is *InputSanitizer) SanitizeFields(fields CardFields) (CardFields, error) {
+	if fields.CardNumber != "" {
+		cleaned, fieldErr := is.cleanCardNumber(fields.CardNumber)
+		if fieldErr != nil {
+			return fields, fieldErr
+		}
+		fields.CardNumber = cleaned
+	}
+	expiries := []struct {
+		field string
+		value *string
+	}{{"expiry_date", &fields.ExpiryDate}, {"new_expiry_date", &fields.NewExpiryDate}}
+	for _, expiry := range expiries {
+		if *expiry.value == "" {
+			continue
+		}
+		checked, fieldErr := is.checkExpiry(expiry.field, *expiry.value)
+		if fieldErr != nil {
+			return fields, fieldErr
+		}
+		*expiry.value = checked
+	}
+	if fields.CVV != "" {
+		if fieldErr := is.checkCVV(fields.CVV); fieldErr != nil {
+			return fields, fieldErr
+		}
+	}
+	return fields, nil
+}
+
+// Patterns are compiled once. Go's RE2-based regexp matches in time linear in the
+// input, so no input can trigger catastrophic backtracking.
+var (
+	expiryPattern = regexp.MustCompile(`^(0[1-9]|1[0-2])/\d{2}$`)
+	cvvPattern    = regexp.MustCompile(`^\d{3,4}$`)
+)
+
 // isValidExpiryFormat checks if expiry date follows MM/YY format
 func isValidExpiryFormat(input string) bool {
-	pattern := regexp.MustCompile(`^(0[1-9]|1[0-2])/\d{2}$`)
-	return pattern.MatchString(input)
+	return expiryPattern.MatchString(input)
+}
+
+// lenientExpiryPattern matches M/YY, MM/YY, M/YYYY, and MM/YYYY with optional surrounding spaces
+var lenientExpiryPattern = regexp.MustCompile(`^\s*(\d{1,2})\s*/\s*(\d{2}|\d{4})\s*$`)
+
+// canonicalizeExpiry zero-pads single-digit months and shortens four-digit years to produce MM/YY.
+// MM/YY names a year in this century, so four-digit years outside 2000-2099 are rejected rather
+// than truncated into the wrong one.
+func canonicalizeExpiry(input string) (string, bool) {
+	matches := lenientExpiryPattern.FindStringSubmatch(input)
+	if matches == nil {
+		return "", false
+	}
+
+	month, year := matches[1], matches[2]
+	if len(month) == 1 {
+		month = "0" + month
+	}
+	if month < "01" || month > "12" {
+		return "", false
+	}
+	if len(year) == 4 {
+		if year[:2] != "20" {
+			return "", false
+		}
+		year = year[2:]
+	}
+
+	return month + "/" + year, true
 }
 
-// isValidCVV checks if CVV contains only digits
+// isValidCVV checks if CVV is 3 or 4 digits. The exact length depends on the
+// card network, which is checked later by luhn.ValidateCard.
+// The length is checked first so oversized input is rejected without being scanned.
 func isValidCVV(input string) bool {
-	pattern := regexp.MustCompile(`^\d{3,4}$`)
-	return pattern.MatchString(input)
+	if len(input) < 3 || len(input) > 4 {
+		return false
+	}
+	return cvvPattern.MatchString(input)
 }
\ No newline at end of file
@@ -0,0 +1,501 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// sanitize posts body through the sanitizer and returns the response and the JSON object
+// forwarded to the next handler, which is nil if the request was rejected
+func sanitize(t *testing.T, config SanitizationConfig, method, body string) (*httptest.ResponseRecorder, map[string]interface{}) {
+	t.Helper()
+
+	var forwarded map[string]interface{}
+	handler := NewInputSanitizer(config).SanitizeMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Fatalf("read forwarded body: %v", err)
+		}
+		if len(data) > 0 {
+			if err := json.Unmarshal(data, &forwarded); err != nil {
+				t.Fatalf("forwarded body is not JSON: %v", err)
+			}
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	r := httptest.NewRequest(method, "/validate", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w, forwarded
+}
+
+// errorCode decodes the code from an error envelope
+func errorCode(t *testing.T, w *httptest.ResponseRecorder) string {
+	t.Helper()
+	var resp ErrorResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode error envelope: %v", err)
+	}
+	return resp.Error.Code
+}
+
+func TestSanitizerPreservesOriginalFormat(t *testing.T) {
+	config := DefaultSanitizationConfig()
+	config.PreserveOriginalFormat = true
+
+	w, forwarded := sanitize(t, config, http.MethodPost, `{"card_number":"4111 1111-1111 1111"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+	if forwarded["card_number"] != "4111111111111111" {
+		t.Errorf("card_number = %v, want digits only", forwarded["card_number"])
+	}
+	if forwarded["card_number_original"] != "4111 1111-1111 1111" {
+		t.Errorf("card_number_original = %v, want the input as sent", forwarded["card_number_original"])
+	}
+}
+
+func TestSanitizerDropsOriginalByDefault(t *testing.T) {
+	// A client-supplied card_number_original is never forwarded unless the option is on
+	_, forwarded := sanitize(t, DefaultSanitizationConfig(), http.MethodPost,
+		`{"card_number":"4111 1111 1111 1111","card_number_original":"5500000000000004"}`)
+	if forwarded["card_number"] != "4111111111111111" {
+		t.Errorf("card_number = %v, want digits only", forwarded["card_number"])
+	}
+	if _, ok := forwarded["card_number_original"]; ok {
+		t.Errorf("card_number_original = %v, want it removed", forwarded["card_number_original"])
+	}
+}
+
+func TestSanitizerFieldTooLong(t *testing.T) {
+	tests := []struct {
+		body string
+		want string
+	}{
+		{`{"card_number":"41111111111111111111"}`, "card_number exceeds max length 19, received 20"},
+		{`{"card_number":"4111 1111 1111 1111 1111"}`, "card_number exceeds max length 19, received 20"},
+		{`{"card_number":"4111111111111111","expiry_date":"12/2030"}`, "expiry_date exceeds max length 5, received 7"},
+		{`{"card_number":"4111111111111111","new_expiry_date":"012/30"}`, "new_expiry_date exceeds max length 5, received 6"},
+		{`{"card_number":"4111111111111111","cvv":"12345"}`, "cvv exceeds max length 4, received 5"},
+	}
+
+	for _, tt := range tests {
+		w, forwarded := sanitize(t, DefaultSanitizationConfig(), http.MethodPost, tt.body)
+		if forwarded != nil {
+			t.Errorf("%s: request was forwarded", tt.body)
+		}
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", tt.body, w.Code)
+		}
+		var resp ErrorResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.Error.Code != ErrCodeFieldTooLong || resp.Error.Message != tt.want {
+			t.Errorf("%s: error = %+v, want %q", tt.body, resp.Error, tt.want)
+		}
+	}
+}
+
+func TestSanitizerCVVFormatOnly(t *testing.T) {
+	// The exact length depends on the network, so both 3 and 4 digits pass here whatever the card
+	for _, cvv := range []string{"123", "1234"} {
+		body := `{"card_number":"4111111111111111","cvv":"` + cvv + `"}`
+		if w, forwarded := sanitize(t, DefaultSanitizationConfig(), http.MethodPost, body); w.Code != http.StatusOK || forwarded["cvv"] != cvv {
+			t.Errorf("CVV %q: status %d, forwarded %v", cvv, w.Code, forwarded["cvv"])
+		}
+	}
+
+	for _, cvv := range []string{"12", "12a"} {
+		body := `{"card_number":"4111111111111111","cvv":"` + cvv + `"}`
+		w, forwarded := sanitize(t, DefaultSanitizationConfig(), http.MethodPost, body)
+		if forwarded != nil {
+			t.Errorf("CVV %q: request was forwarded", cvv)
+		}
+		if code := errorCode(t, w); code != ErrCodeInvalidCVV {
+			t.Errorf("CVV %q: error code %q, want %q", cvv, code, ErrCodeInvalidCVV)
+		}
+	}
+}
+
+func TestCanonicalizeExpiry(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+		ok    bool
+	}{
+		{"09/25", "09/25", true},
+		{"9/25", "09/25", true},
+		{"09/2025", "09/25", true},
+		{"9/2025", "09/25", true},
+		{" 12 / 30 ", "12/30", true},
+		{"01/2000", "01/00", true},
+		{"12/2099", "12/99", true},
+		{"09/2125", "", false},
+		{"09/1999", "", false},
+		{"09/2100", "", false},
+		{"0/25", "", false},
+		{"13/25", "", false},
+		{"9/205", "", false},
+		{"0925", "", false},
+		{"09-25", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := canonicalizeExpiry(tt.input)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("canonicalizeExpiry(%q) = %q, %v; want %q, %v", tt.input, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestSanitizerLenientExpiryForwardsCanonicalForm(t *testing.T) {
+	config := DefaultSanitizationConfig()
+	config.LenientExpiry = true
+
+	for _, input := range []string{"9/25", "09/2025", "9/2025"} {
+		body := `{"card_number":"4111111111111111","expiry_date":"` + input + `","new_expiry_date":"` + input + `"}`
+		w, forwarded := sanitize(t, config, http.MethodPost, body)
+		if w.Code != http.StatusOK {
+			t.Errorf("%q: status %d, want 200", input, w.Code)
+			continue
+		}
+		if forwarded["expiry_date"] != "09/25" || forwarded["new_expiry_date"] != "09/25" {
+			t.Errorf("%q: forwarded %v and %v, want 09/25", input, forwarded["expiry_date"], forwarded["new_expiry_date"])
+		}
+	}
+}
+
+func TestSanitizerLenientExpiryRejectsOtherCenturies(t *testing.T) {
+	config := DefaultSanitizationConfig()
+	config.LenientExpiry = true
+
+	// Truncating these would turn 2125 into 2025 and 1999 into 2099
+	for _, input := range []string{"09/2125", "09/1999", "9/1999"} {
+		w, forwarded := sanitize(t, config, http.MethodPost, `{"card_number":"4111111111111111","expiry_date":"`+input+`"}`)
+		if forwarded != nil {
+			t.Errorf("%q: request was forwarded as %v", input, forwarded["expiry_date"])
+		}
+		if code := errorCode(t, w); code != ErrCodeInvalidExpiry {
+			t.Errorf("%q: error code %q, want %q", input, code, ErrCodeInvalidExpiry)
+		}
+	}
+}
+
+func TestSanitizerStrictExpiryRejectsVariants(t *testing.T) {
+	w, forwarded := sanitize(t, DefaultSanitizationConfig(), http.MethodPost, `{"card_number":"4111111111111111","expiry_date":"9/25"}`)
+	if forwarded != nil {
+		t.Error("request was forwarded")
+	}
+	if code := errorCode(t, w); code != ErrCodeInvalidExpiry {
+		t.Errorf("error code %q, want %q", code, ErrCodeInvalidExpiry)
+	}
+}
+
+// captureLogs sends the global logger's output to a buffer for one test
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	previous := log.Logger
+	log.Logger = zerolog.New(&buf)
+	t.Cleanup(func() { log.Logger = previous })
+	return &buf
+}
+
+func TestSanitizerRejectsCVVOnGET(t *testing.T) {
+	tests := []struct {
+		name     string
+		target   string
+		body     string
+		location string
+	}{
+		{"query", "/validate?card_number=4111111111111111&cvv=123", "", "query"},
+		{"empty query value", "/validate?cvv=", "", "query"},
+		{"body", "/validate", `{"card_number":"4111111111111111","cvv":"123"}`, "body"},
+		{"non-string body value", "/validate", `{"card_number":"4111111111111111","cvv":123}`, "body"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs := captureLogs(t)
+			forwarded := false
+			handler := NewInputSanitizer(DefaultSanitizationConfig()).SanitizeMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				forwarded = true
+			}))
+
+			var r *http.Request
+			if tt.body == "" {
+				r = httptest.NewRequest(http.MethodGet, tt.target, nil)
+			} else {
+				r = httptest.NewRequest(http.MethodGet, tt.target, strings.NewReader(tt.body))
+				r.Header.Set("Content-Type", "application/json")
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if forwarded {
+				t.Error("request was forwarded")
+			}
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want 400", w.Code)
+			}
+			if code := errorCode(t, w); code != ErrCodeCVVNotAllowed {
+				t.Errorf("error code %q, want %q", code, ErrCodeCVVNotAllowed)
+			}
+
+			var entry map[string]interface{}
+			if err := json.Unmarshal(logs.Bytes(), &entry); err != nil {
+				t.Fatalf("log entry: %v (%q)", err, logs)
+			}
+			if entry["level"] != "warn" || entry["cvv_location"] != tt.location {
+				t.Errorf("log entry = %v, want a warning with cvv_location %s", entry, tt.location)
+			}
+			if strings.Contains(logs.String(), "123") {
+				t.Errorf("log contains the CVV: %s", logs)
+			}
+		})
+	}
+}
+
+func TestSanitizerAllowsGETWithoutCVV(t *testing.T) {
+	w, _ := sanitize(t, DefaultSanitizationConfig(), http.MethodGet, `{"card_number":"4111111111111111"}`)
+	if w.Code != http.StatusOK {
+		t.Errorf("GET body without CVV: status %d, want 200", w.Code)
+	}
+
+	handler := NewInputSanitizer(DefaultSanitizationConfig()).SanitizeMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/validate?card_number=4111111111111111", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("GET query without CVV: status %d, want 200", w.Code)
+	}
+}
+
+func TestSanitizerRejectsInputWithoutDigits(t *testing.T) {
+	for _, cardNumber := range []string{"----", "    ", " - - ", "\t\n"} {
+		body, _ := json.Marshal(map[string]string{"card_number": cardNumber})
+		w, forwarded := sanitize(t, DefaultSanitizationConfig(), http.MethodPost, string(body))
+		if forwarded != nil {
+			t.Errorf("%q: request was forwarded", cardNumber)
+		}
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%q: status %d, want 400", cardNumber, w.Code)
+		}
+		if code := errorCode(t, w); code != ErrCodeNoDigits {
+			t.Errorf("%q: error code %q, want %q", cardNumber, code, ErrCodeNoDigits)
+		}
+	}
+}
+
+func TestSanitizerRejectsDuplicateKeys(t *testing.T) {
+	tests := []struct {
+		body string
+		key  string
+	}{
+		{`{"card_number":"4111111111111111","card_number":"5555555555554444"}`, "card_number"},
+		{`{"card_number":"4111111111111111","cvv":"123","cvv":"456"}`, "cvv"},
+		// encoding/json matches keys case-insensitively, so these collide too
+		{`{"card_number":"4111111111111111","Card_Number":"5555555555554444"}`, "Card_Number"},
+	}
+
+	for _, tt := range tests {
+		w, forwarded := sanitize(t, DefaultSanitizationConfig(), http.MethodPost, tt.body)
+		if forwarded != nil {
+			t.Errorf("%s: request was forwarded", tt.body)
+		}
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", tt.body, w.Code)
+		}
+		var resp ErrorResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.Error.Code != ErrCodeDuplicateKey || !strings.Contains(resp.Error.Message, `"`+tt.key+`"`) {
+			t.Errorf("%s: error = %+v, want %s naming %q", tt.body, resp.Error, ErrCodeDuplicateKey, tt.key)
+		}
+	}
+}
+
+func TestFindDuplicateKeyIgnoresNestedKeys(t *testing.T) {
+	for _, body := range []string{
+		`{"card_number":"4111111111111111","meta":{"card_number":"x","card_number":"y"}}`,
+		`{"a":[{"b":1},{"b":2}],"c":"card_number"}`,
+		`[1,2,3]`,
+		`{}`,
+	} {
+		if key, ok := findDuplicateKey([]byte(body)); ok {
+			t.Errorf("%s: reported duplicate key %q", body, key)
+		}
+	}
+}
+
+func TestIsValidCVV(t *testing.T) {
+	tests := map[string]bool{
+		"123":   true,
+		"1234":  true,
+		"12":    false,
+		"12345": false,
+		"12a":   false,
+		"":      false,
+		"１２３":   false, // fullwidth digits are not ASCII digits
+	}
+	for cvv, want := range tests {
+		if got := isValidCVV(cvv); got != want {
+			t.Errorf("isValidCVV(%q) = %v, want %v", cvv, got, want)
+		}
+	}
+}
+
+func TestSanitizerRejectsLongCVV(t *testing.T) {
+	config := DefaultSanitizationConfig()
+	config.MaxRequestSize = 1 << 20
+
+	cvv := strings.Repeat("1", 100000)
+	w, forwarded := sanitize(t, config, http.MethodPost, `{"card_number":"4111111111111111","cvv":"`+cvv+`"}`)
+	if forwarded != nil {
+		t.Fatal("a 100000-digit CVV was forwarded")
+	}
+	if code := errorCode(t, w); code != ErrCodeFieldTooLong {
+		t.Errorf("code = %q, want %q", code, ErrCodeFieldTooLong)
+	}
+}
+
+// FuzzIsValidCVV checks that no input is accepted unless it is 3 or 4 ASCII digits, and that
+// very long input is rejected without being scanned
+func FuzzIsValidCVV(f *testing.F) {
+	for _, seed := range []string{"123", "1234", "12345", strings.Repeat("9", 1000), strings.Repeat("9", 100000)} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, cvv string) {
+		start := time.Now()
+		valid := isValidCVV(cvv)
+		if elapsed := time.Since(start); len(cvv) > 4 && elapsed > 10*time.Millisecond {
+			t.Errorf("isValidCVV took %v for %d bytes", elapsed, len(cvv))
+		}
+		if valid != cvvPattern.MatchString(cvv) {
+			t.Errorf("isValidCVV(%q) = %v, want the pattern result", cvv, valid)
+		}
+		if valid && len(cvv) > 4 {
+			t.Errorf("isValidCVV accepted %d bytes", len(cvv))
+		}
+	})
+}
+
+func BenchmarkIsValidCVVLong(b *testing.B) {
+	cvv := strings.Repeat("1", 1<<20)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		isValidCVV(cvv)
+	}
+}
+
+func TestSanitizerReport(t *testing.T) {
+	config := DefaultSanitizationConfig()
+	config.Report = true
+	config.LenientExpiry = true
+
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"formatted card", `{"card_number":"4111 1111-1111 1111"}`, TransformStrippedNonDigits},
+		{"normalized expiry", `{"card_number":"4111111111111111","expiry_date":"9/27"}`, TransformNormalizedExpiry},
+		{"both", `{"card_number":"4111 1111 1111 1111","expiry_date":"09/2027"}`,
+			TransformStrippedNonDigits + "," + TransformNormalizedExpiry},
+		{"both expiry fields reported once", `{"card_number":"4111111111111111","expiry_date":"9/27","new_expiry_date":"9/29"}`,
+			TransformNormalizedExpiry},
+		{"already clean", `{"card_number":"4111111111111111","expiry_date":"09/27"}`, ""},
+	}
+
+	for _, tt := range tests {
+		w, forwarded := sanitize(t, config, http.MethodPost, tt.body)
+		if forwarded == nil {
+			t.Fatalf("%s: request rejected with %d", tt.name, w.Code)
+		}
+		report := w.Header().Get("X-Sanitize-Report")
+		if report != tt.want {
+			t.Errorf("%s: X-Sanitize-Report = %q, want %q", tt.name, report, tt.want)
+		}
+		// Only transformation names, never field values
+		if strings.ContainsAny(report, "0123456789/ ") {
+			t.Errorf("%s: report %q contains request data", tt.name, report)
+		}
+	}
+}
+
+func TestSanitizerReportOff(t *testing.T) {
+	w, _ := sanitize(t, DefaultSanitizationConfig(), http.MethodPost, `{"card_number":"4111 1111 1111 1111"}`)
+	if report, ok := w.Header()["X-Sanitize-Report"]; ok {
+		t.Errorf("X-Sanitize-Report = %q with reporting off", report)
+	}
+}
+
+func TestSanitizeFields(t *testing.T) {
+	sanitizer := NewInputSanitizer(DefaultSanitizationConfig())
+
+	fields, err := sanitizer.SanitizeFields(CardFields{CardNumber: "4111 1111-1111 1111", ExpiryDate: "09/30", CVV: "123"})
+	if err != nil || fields.CardNumber != "4111111111111111" || fields.ExpiryDate != "09/30" {
+		t.Errorf("valid fields: %+v, %v", fields, err)
+	}
+	// Empty fields are absent, not malformed
+	if _, err := sanitizer.SanitizeFields(CardFields{}); err != nil {
+		t.Errorf("empty fields: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		fields CardFields
+		code   string
+		field  string
+	}{
+		{"no digits", CardFields{CardNumber: "--"}, ErrCodeNoDigits, "card_number"},
+		{"long card number", CardFields{CardNumber: strings.Repeat("4", 20)}, ErrCodeFieldTooLong, "card_number"},
+		{"bad expiry", CardFields{ExpiryDate: "13/30"}, ErrCodeInvalidExpiry, "expiry_date"},
+		{"bad new expiry", CardFields{NewExpiryDate: "1/30"}, ErrCodeInvalidExpiry, "new_expiry_date"},
+		{"long cvv", CardFields{CVV: "12345"}, ErrCodeFieldTooLong, "cvv"},
+		{"bad cvv", CardFields{CVV: "12"}, ErrCodeInvalidCVV, "cvv"},
+	}
+	for _, tt := range tests {
+		_, err := sanitizer.SanitizeFields(tt.fields)
+		fieldErr, ok := err.(*FieldError)
+		if !ok || fieldErr.Code != tt.code || fieldErr.Field != tt.field {
+			t.Errorf("%s: error = %#v, want %s on %s", tt.name, err, tt.code, tt.field)
+		}
+	}
+
+	// Lenient mode canonicalizes as the middleware does
+	config := DefaultSanitizationConfig()
+	config.LenientExpiry = true
+	fields, err = NewInputSanitizer(config).SanitizeFields(CardFields{ExpiryDate: "9/2030", NewExpiryDate: "10/31"})
+	if err != nil || fields.ExpiryDate != "09/30" || fields.NewExpiryDate != "10/31" {
+		t.Errorf("lenient: %+v, %v", fields, err)
+	}
+}
+
+func TestSanitizerStatusConfig(t *testing.T) {
+	// Field errors are 400 by default and 422 when the status config asks for it
+	body := `{"card_number":"4111111111111111","expiry_date":"13/27"}`
+	if w, _ := sanitize(t, DefaultSanitizationConfig(), http.MethodPost, body); w.Code != http.StatusBadRequest {
+		t.Errorf("default: status = %d, want 400", w.Code)
+	}
+	config := DefaultSanitizationConfig()
+	config.Status.UnprocessableEntity = true
+	w, _ := sanitize(t, config, http.MethodPost, body)
+	if w.Code != http.StatusUnprocessableEntity || errorCode(t, w) != ErrCodeInvalidExpiry {
+		t.Errorf("UnprocessableEntity: status = %d, want 422 %s", w.Code, ErrCodeInvalidExpiry)
+	}
+}
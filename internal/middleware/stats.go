@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"encoding/json"
+	"math"
+	"math/rand"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultReservoirSize is the number of latency samples kept for percentile estimates
+const DefaultReservoirSize = 1024
+
+// LatencyStats estimates request latency percentiles in process, without a metrics backend.
+// It keeps a fixed-size uniform sample of all latencies (reservoir sampling), so memory
+// stays bounded however many requests are served.
+type LatencyStats struct {
+	mu      sync.Mutex
+	samples []time.Duration
+	size    int
+	count   uint64
+	rng     *rand.Rand
+}
+
+// StatsSnapshot holds latency percentiles computed on demand
+type StatsSnapshot struct {
+	Requests uint64  `json:"requests"`
+	Sampled  int     `json:"sampled"`
+	P50      float64 `json:"p50_ms"`
+	P95      float64 `json:"p95_ms"`
+	P99      float64 `json:"p99_ms"`
+}
+
+// NewLatencyStats creates latency stats keeping at most size samples
+func NewLatencyStats(size int) *LatencyStats {
+	if size <= 0 {
+		size = DefaultReservoirSize
+	}
+	return &LatencyStats{
+		samples: make([]time.Duration, 0, size),
+		size:    size,
+		rng:     rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// StatsMiddleware creates a middleware function that records each request's latency
+func (ls *LatencyStats) StatsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		next.ServeHTTP(w, r)
+		ls.Record(time.Since(start))
+	})
+}
+
+// Record adds a latency. Once the reservoir is full, the n-th latency replaces a random
+// sample with probability size/n, keeping the sample uniform over every request.
+func (ls *LatencyStats) Record(latency time.Duration) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+
+	ls.count++
+	if len(ls.samples) < ls.size {
+		ls.samples = append(ls.samples, latency)
+		return
+	}
+	if i := ls.rng.Int63n(int64(ls.count)); i < int64(ls.size) {
+		ls.samples[i] = latency
+	}
+}
+
+// Snapshot computes the current percentiles
+func (ls *LatencyStats) Snapshot() StatsSnapshot {
+	ls.mu.Lock()
+	sorted := append([]time.Duration(nil), ls.samples...)
+	count := ls.count
+	ls.mu.Unlock()
+
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	return StatsSnapshot{
+		Requests: count,
+		Sampled:  len(sorted),
+		P50:      percentile(sorted, 50),
+		P95:      percentile(sorted, 95),
+		P99:      percentile(sorted, 99),
+	}
+}
+
+// StatsHandler serves the current snapshot as JSON
+func (ls *LatencyStats) StatsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(ls.Snapshot())
+}
+
+// percentile returns the nearest-rank percentile of sorted latencies in milliseconds
+func percentile(sorted []time.Duration, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return float64(sorted[rank-1]) / float64(time.Millisecond)
+}
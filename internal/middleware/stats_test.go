@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLatencyStatsExactPercentiles(t *testing.T) {
+	// 1ms to 100ms fit in the reservoir, so the nearest-rank percentiles are exact
+	stats := NewLatencyStats(100)
+	for ms := 100; ms >= 1; ms-- {
+		stats.Record(time.Duration(ms) * time.Millisecond)
+	}
+
+	snapshot := stats.Snapshot()
+	want := StatsSnapshot{Requests: 100, Sampled: 100, P50: 50, P95: 95, P99: 99}
+	if snapshot != want {
+		t.Errorf("snapshot = %+v, want %+v", snapshot, want)
+	}
+}
+
+func TestLatencyStatsSampledPercentiles(t *testing.T) {
+	// 100000 uniform latencies from 0 to 100ms through a 1024-sample reservoir
+	stats := NewLatencyStats(DefaultReservoirSize)
+	for i := 0; i < 100000; i++ {
+		stats.Record(time.Duration(i%1000) * 100 * time.Microsecond)
+	}
+
+	snapshot := stats.Snapshot()
+	if snapshot.Requests != 100000 || snapshot.Sampled != DefaultReservoirSize {
+		t.Errorf("requests %d, sampled %d; want 100000, %d", snapshot.Requests, snapshot.Sampled, DefaultReservoirSize)
+	}
+	// The sample is random; 10ms is over six standard errors for the p50 estimate
+	for _, tt := range []struct {
+		name      string
+		got, want float64
+	}{
+		{"p50", snapshot.P50, 50},
+		{"p95", snapshot.P95, 95},
+		{"p99", snapshot.P99, 99},
+	} {
+		if math.Abs(tt.got-tt.want) > 10 {
+			t.Errorf("%s = %.1fms, want about %.0fms", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestLatencyStatsEmpty(t *testing.T) {
+	if snapshot := NewLatencyStats(0).Snapshot(); snapshot != (StatsSnapshot{}) {
+		t.Errorf("empty snapshot = %+v", snapshot)
+	}
+}
+
+func TestStatsMiddlewareAndHandler(t *testing.T) {
+	stats := NewLatencyStats(10)
+	handler := stats.StatsMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(5 * time.Millisecond)
+	}))
+	for i := 0; i < 3; i++ {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/validate", nil))
+	}
+
+	w := httptest.NewRecorder()
+	stats.StatsHandler(w, httptest.NewRequest(http.MethodGet, "/stats", nil))
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+
+	var snapshot StatsSnapshot
+	if err := json.NewDecoder(w.Body).Decode(&snapshot); err != nil {
+		t.Fatal(err)
+	}
+	if snapshot.Requests != 3 || snapshot.Sampled != 3 || snapshot.P50 < 5 {
+		t.Errorf("snapshot = %+v, want 3 requests of at least 5ms", snapshot)
+	}
+
+	w = httptest.NewRecorder()
+	stats.StatsHandler(w, httptest.NewRequest(http.MethodPost, "/stats", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST: status = %d, want 405", w.Code)
+	}
+}
+
+func BenchmarkLatencyStatsRecord(b *testing.B) {
+	stats := NewLatencyStats(DefaultReservoirSize)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		stats.Record(time.Duration(i))
+	}
+}
@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// TimingBreakdown enables per-stage request timing in the completion log. It is meant for
+// performance debugging and must be set before the server starts handling requests.
+var TimingBreakdown = false
+
+// stageTiming is the inclusive time spent in one stage of the chain
+type stageTiming struct {
+	name     string
+	duration time.Duration
+}
+
+// Timings collects stage durations for a single request
+type Timings struct {
+	mu     sync.Mutex
+	stages []stageTiming // in the order the stages were entered
+}
+
+// withTimings attaches an empty Timings to the context
+func withTimings(ctx context.Context) (context.Context, *Timings) {
+	timings := &Timings{}
+	return context.WithValue(ctx, timingsKey, timings), timings
+}
+
+// Timed records the time spent in next under the given stage name when timing is enabled
+// for the request. Otherwise it adds nothing beyond a context lookup.
+func Timed(name string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		timings, ok := r.Context().Value(timingsKey).(*Timings)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// Reserve the slot on entry so stages stay in chain order
+		timings.mu.Lock()
+		index := len(timings.stages)
+		timings.stages = append(timings.stages, stageTiming{name: name})
+		timings.mu.Unlock()
+
+		start := time.Now()
+		next.ServeHTTP(w, r)
+		elapsed := time.Since(start)
+
+		timings.mu.Lock()
+		timings.stages[index].duration = elapsed
+		timings.mu.Unlock()
+	})
+}
+
+// dict returns each stage's own time in milliseconds. Stages wrap one another in a
+// single chain, so a stage's own time is its inclusive time minus the next stage's.
+func (t *Timings) dict() *zerolog.Event {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	event := zerolog.Dict()
+	for i, stage := range t.stages {
+		own := stage.duration
+		if i+1 < len(t.stages) {
+			own -= t.stages[i+1].duration
+		}
+		event.Float64(stage.name, float64(own)/float64(time.Millisecond))
+	}
+	return event
+}
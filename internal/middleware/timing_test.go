@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// setTimingBreakdown sets the timing flag for one test
+func setTimingBreakdown(t *testing.T, enabled bool) {
+	t.Helper()
+	previous := TimingBreakdown
+	TimingBreakdown = enabled
+	t.Cleanup(func() { TimingBreakdown = previous })
+}
+
+// timedChain is logging → sanitizer → rate limiter → a handler that sleeps, with each stage timed
+func timedChain(t *testing.T, handlerDelay time.Duration) http.Handler {
+	t.Helper()
+	limiter := newTestRateLimiter(t, RateLimiterConfig{Rate: 100, BucketSize: 100})
+	handler := Timed("handler", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(handlerDelay)
+		w.WriteHeader(http.StatusOK)
+	}))
+	handler = Timed("ratelimit", limiter.RateLimitMiddleware(handler))
+	handler = Timed("sanitize", NewInputSanitizer(DefaultSanitizationConfig()).SanitizeMiddleware(handler))
+	return LoggingMiddleware(handler)
+}
+
+// completionLog returns the fields of the "Request completed" log line
+func completionLog(t *testing.T, logs *bytes.Buffer) map[string]interface{} {
+	t.Helper()
+	scanner := bufio.NewScanner(logs)
+	for scanner.Scan() {
+		var fields map[string]interface{}
+		if json.Unmarshal(scanner.Bytes(), &fields) == nil && fields["message"] == "Request completed" {
+			return fields
+		}
+	}
+	t.Fatalf("no completion log in %s", logs)
+	return nil
+}
+
+func TestTimingBreakdownLogged(t *testing.T) {
+	setTimingBreakdown(t, true)
+	logs := captureLogs(t)
+
+	r := httptest.NewRequest(http.MethodPost, "/validate", strings.NewReader(`{"card_number":"4111111111111111"}`))
+	r.Header.Set("Content-Type", "application/json")
+	timedChain(t, 20*time.Millisecond).ServeHTTP(httptest.NewRecorder(), r)
+
+	timings, ok := completionLog(t, logs)["timings_ms"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("completion log has no timings_ms object: %s", logs)
+	}
+	for _, stage := range []string{"sanitize", "ratelimit", "handler"} {
+		ms, ok := timings[stage].(float64)
+		if !ok || ms < 0 {
+			t.Errorf("timings_ms.%s = %v, want a non-negative duration", stage, timings[stage])
+		}
+	}
+
+	// Each stage reports its own time, so the handler's sleep is not counted in the stages around it
+	if handler := timings["handler"].(float64); handler < 20 {
+		t.Errorf("handler = %vms, want at least the 20ms it slept", handler)
+	}
+	if sanitize := timings["sanitize"].(float64); sanitize >= 20 {
+		t.Errorf("sanitize = %vms, want its own time without the handler", sanitize)
+	}
+}
+
+func TestTimingBreakdownOff(t *testing.T) {
+	setTimingBreakdown(t, false)
+	logs := captureLogs(t)
+
+	r := httptest.NewRequest(http.MethodPost, "/validate", strings.NewReader(`{"card_number":"4111111111111111"}`))
+	r.Header.Set("Content-Type", "application/json")
+	timedChain(t, 0).ServeHTTP(httptest.NewRecorder(), r)
+
+	if fields := completionLog(t, logs); fields["timings_ms"] != nil {
+		t.Errorf("timings_ms logged with the breakdown off: %v", fields["timings_ms"])
+	}
+}
+
+func BenchmarkTimedDisabled(b *testing.B) {
+	handler := Timed("handler", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	w := httptest.NewRecorder()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		handler.ServeHTTP(w, r)
+	}
+}
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/jamesmeyerr/credit-card-validator/internal/tracing"
+)
+
+// TracingMiddleware starts a server span for each request, continuing the caller's trace
+// when a traceparent header is present. Only the method, path, and status are recorded;
+// the query string and body, which can carry card data, are never added to the span.
+func TracingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracing.Tracer().Start(ctx, r.Method+" "+r.URL.Path,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", r.Method),
+				attribute.String("url.path", r.URL.Path),
+			),
+		)
+		defer span.End()
+
+		rr := &responseRecorder{ResponseWriter: w, Status: http.StatusOK}
+		next.ServeHTTP(rr, r.WithContext(ctx))
+
+		span.SetAttributes(attribute.Int("http.response.status_code", rr.Status))
+		if rr.Status >= 500 {
+			span.SetStatus(codes.Error, http.StatusText(rr.Status))
+		}
+	})
+}
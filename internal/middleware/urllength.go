@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// DefaultMaxURLLength is the longest request URI, including the query string, accepted by default
+const DefaultMaxURLLength = 2048
+
+// MaxURLLengthMiddleware rejects requests whose URI, including the query string, is longer
+// than maxLength with 414 URI Too Long. This bounds the cost of query-parameter validation.
+func MaxURLLengthMiddleware(maxLength int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			uri := r.RequestURI
+			if uri == "" {
+				uri = r.URL.RequestURI()
+			}
+
+			if len(uri) > maxLength {
+				logger := ApplicationLogger(r.Context())
+				logger.Warn().
+					Int("uri_length", len(uri)).
+					Int("max_length", maxLength).
+					Msg("Rejected over-length request URI")
+				WriteError(w, r, http.StatusRequestURITooLong, ErrCodeURITooLong,
+					fmt.Sprintf("request URI exceeds max length %d, received %d", maxLength, len(uri)))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
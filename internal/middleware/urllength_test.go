@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// urlLengthRequest sends target through MaxURLLengthMiddleware with the given limit
+func urlLengthRequest(maxLength int, target string) (*httptest.ResponseRecorder, bool) {
+	forwarded := false
+	handler := MaxURLLengthMiddleware(maxLength)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forwarded = true
+		w.WriteHeader(http.StatusOK)
+	}))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+	return w, forwarded
+}
+
+func TestMaxURLLengthRejectsLongQuery(t *testing.T) {
+	target := "/validate?card_number=" + strings.Repeat("4", DefaultMaxURLLength)
+
+	w, forwarded := urlLengthRequest(DefaultMaxURLLength, target)
+	if forwarded {
+		t.Error("request was forwarded")
+	}
+	if w.Code != http.StatusRequestURITooLong {
+		t.Fatalf("status = %d, want 414", w.Code)
+	}
+	var resp ErrorResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Error.Code != ErrCodeURITooLong {
+		t.Errorf("error code = %q, want %q", resp.Error.Code, ErrCodeURITooLong)
+	}
+}
+
+func TestMaxURLLengthBoundary(t *testing.T) {
+	const limit = 64
+	prefix := "/validate?card_number="
+
+	atLimit := prefix + strings.Repeat("4", limit-len(prefix))
+	if w, forwarded := urlLengthRequest(limit, atLimit); !forwarded || w.Code != http.StatusOK {
+		t.Errorf("%d-character URI: status %d, forwarded %v; want it accepted", len(atLimit), w.Code, forwarded)
+	}
+
+	overLimit := atLimit + "4"
+	if w, _ := urlLengthRequest(limit, overLimit); w.Code != http.StatusRequestURITooLong {
+		t.Errorf("%d-character URI: status %d, want 414", len(overLimit), w.Code)
+	}
+}
@@ -0,0 +1,127 @@
+// Package recent counts how often the same card number was validated within a sliding
+// window, for fraud review. Card numbers are keyed by a salted HMAC-SHA256 and never stored.
+package recent
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"sync"
+	"time"
+)
+
+// Config defines the sliding window
+type Config struct {
+	Window          time.Duration // how far back validations are counted
+	Salt            []byte        // HMAC key; a random key is generated when empty
+	CleanupInterval time.Duration // how often idle entries are removed
+}
+
+// DefaultConfig returns a default configuration
+func DefaultConfig() Config {
+	return Config{
+		Window:          15 * time.Minute,
+		CleanupInterval: time.Minute,
+	}
+}
+
+// Tracker counts validations per card fingerprint within the window
+type Tracker struct {
+	window  time.Duration
+	salt    []byte
+	mu      sync.Mutex
+	seen    map[[sha256.Size]byte][]time.Time // timestamps within the window, oldest first
+	cleanup *time.Ticker
+	done    chan struct{}
+	stopped sync.Once
+}
+
+// NewTracker creates a tracker and starts its cleanup routine
+func NewTracker(config Config) (*Tracker, error) {
+	salt := config.Salt
+	if len(salt) == 0 {
+		// A per-process key still groups repeats while making the fingerprints useless elsewhere
+		salt = make([]byte, 32)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, err
+		}
+	}
+
+	tracker := &Tracker{
+		window:  config.Window,
+		salt:    salt,
+		seen:    make(map[[sha256.Size]byte][]time.Time),
+		cleanup: time.NewTicker(config.CleanupInterval),
+		done:    make(chan struct{}),
+	}
+
+	go func() {
+		for {
+			select {
+			case <-tracker.cleanup.C:
+				tracker.cleanupStale()
+			case <-tracker.done:
+				return
+			}
+		}
+	}()
+
+	return tracker, nil
+}
+
+// Record notes a validation of the digits-only card number and returns how many times it
+// has been validated within the window, including this one
+func (t *Tracker) Record(cardNumber string) int {
+	key := t.fingerprint(cardNumber)
+	now := time.Now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	times := append(prune(t.seen[key], now.Add(-t.window)), now)
+	t.seen[key] = times
+	return len(times)
+}
+
+// Shutdown stops the cleanup ticker and its goroutine. It is safe to call more than once.
+func (t *Tracker) Shutdown() {
+	t.stopped.Do(func() {
+		t.cleanup.Stop()
+		close(t.done)
+	})
+}
+
+// fingerprint returns the salted hash used in place of the card number
+func (t *Tracker) fingerprint(cardNumber string) [sha256.Size]byte {
+	mac := hmac.New(sha256.New, t.salt)
+	mac.Write([]byte(cardNumber))
+
+	var key [sha256.Size]byte
+	copy(key[:], mac.Sum(nil))
+	return key
+}
+
+// cleanupStale removes fingerprints with no validations inside the window
+func (t *Tracker) cleanupStale() {
+	cutoff := time.Now().Add(-t.window)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for key, times := range t.seen {
+		if times = prune(times, cutoff); len(times) == 0 {
+			delete(t.seen, key)
+		} else {
+			t.seen[key] = times
+		}
+	}
+}
+
+// prune drops timestamps at or before the cutoff
+func prune(times []time.Time, cutoff time.Time) []time.Time {
+	i := 0
+	for i < len(times) && !times[i].After(cutoff) {
+		i++
+	}
+	return times[i:]
+}
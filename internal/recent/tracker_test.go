@@ -0,0 +1,98 @@
+package recent
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+func newTestTracker(t *testing.T, config Config) *Tracker {
+	t.Helper()
+	tracker, err := NewTracker(config)
+	if err != nil {
+		t.Fatalf("NewTracker: %v", err)
+	}
+	t.Cleanup(tracker.Shutdown)
+	return tracker
+}
+
+func TestRecordCountsRepeats(t *testing.T) {
+	tracker := newTestTracker(t, DefaultConfig())
+
+	for want := 1; want <= 3; want++ {
+		if got := tracker.Record("4111111111111111"); got != want {
+			t.Errorf("Record #%d = %d, want %d", want, got, want)
+		}
+	}
+	if got := tracker.Record("5500000000000004"); got != 1 {
+		t.Errorf("Record of a different card = %d, want 1", got)
+	}
+}
+
+func TestRecordForgetsOutsideWindow(t *testing.T) {
+	config := DefaultConfig()
+	config.Window = 20 * time.Millisecond
+	tracker := newTestTracker(t, config)
+
+	tracker.Record("4111111111111111")
+	time.Sleep(40 * time.Millisecond)
+	if got := tracker.Record("4111111111111111"); got != 1 {
+		t.Errorf("Record after the window = %d, want 1", got)
+	}
+}
+
+func TestCleanupRemovesStaleEntries(t *testing.T) {
+	config := DefaultConfig()
+	config.Window = 10 * time.Millisecond
+	config.CleanupInterval = 5 * time.Millisecond
+	tracker := newTestTracker(t, config)
+
+	tracker.Record("4111111111111111")
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		tracker.mu.Lock()
+		remaining := len(tracker.seen)
+		tracker.mu.Unlock()
+		if remaining == 0 {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Error("stale entry was never cleaned up")
+}
+
+func TestFingerprintsDependOnSalt(t *testing.T) {
+	a := newTestTracker(t, Config{Window: time.Minute, Salt: []byte("a"), CleanupInterval: time.Minute})
+	b := newTestTracker(t, Config{Window: time.Minute, Salt: []byte("b"), CleanupInterval: time.Minute})
+
+	if a.fingerprint("4111111111111111") == b.fingerprint("4111111111111111") {
+		t.Error("different salts produced the same fingerprint")
+	}
+}
+
+func TestShutdownStopsCleanupGoroutine(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	config := DefaultConfig()
+	config.CleanupInterval = time.Millisecond
+	trackers := make([]*Tracker, 10)
+	for i := range trackers {
+		tracker, err := NewTracker(config)
+		if err != nil {
+			t.Fatalf("NewTracker: %v", err)
+		}
+		trackers[i] = tracker
+	}
+	for _, tracker := range trackers {
+		tracker.Shutdown()
+		tracker.Shutdown() // a second call must not panic
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d goroutines still running after Shutdown, want %d", runtime.NumGoroutine(), before)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,90 @@
+// Package tracing configures OpenTelemetry distributed tracing.
+//
+// Spans are exported over OTLP/HTTP when OTEL_EXPORTER_OTLP_ENDPOINT or
+// OTEL_EXPORTER_OTLP_TRACES_ENDPOINT is set; the exporter reads the other standard
+// OTEL_* variables (headers, timeout, protocol options) itself. Without an endpoint
+// the global no-op provider stays in place and tracing costs nothing.
+//
+// Span attributes never include card numbers, expiry dates, or security codes.
+package tracing
+
+import (
+	"context"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ServiceName identifies this service in traces unless OTEL_SERVICE_NAME overrides it
+const ServiceName = "credit-card-validator"
+
+// instrumentationName names the tracer that creates this service's spans
+const instrumentationName = "github.com/jamesmeyerr/credit-card-validator"
+
+// Setup installs the W3C trace context propagator and, when an OTLP endpoint is configured,
+// a batching tracer provider. The returned function flushes and stops the provider.
+func Setup(ctx context.Context) (shutdown func(context.Context) error, err error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	if !Enabled() {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// resource.Default reads OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES
+	res, err := resource.Merge(
+		resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(ServiceName)),
+		resource.Default(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// Enabled reports whether an OTLP endpoint is configured and the SDK is not disabled
+func Enabled() bool {
+	if os.Getenv("OTEL_SDK_DISABLED") == "true" {
+		return false
+	}
+	return os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" || os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != ""
+}
+
+// Tracer returns the tracer for this service's spans
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// StartValidationSpan starts a child span around a single card validation
+func StartValidationSpan(ctx context.Context) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, "luhn.ValidateCard")
+}
+
+// RecordValidation adds the non-sensitive validation results to a span
+func RecordValidation(span trace.Span, network, outcome string) {
+	span.SetAttributes(
+		attribute.String("card.network", network),
+		attribute.String("card.outcome", outcome),
+	)
+}
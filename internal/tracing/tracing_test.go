@@ -0,0 +1,50 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+)
+
+func TestEnabled(t *testing.T) {
+	tests := []struct {
+		endpoint       string
+		tracesEndpoint string
+		disabled       string
+		want           bool
+	}{
+		{"", "", "", false},
+		{"http://collector:4318", "", "", true},
+		{"", "http://collector:4318/v1/traces", "", true},
+		{"http://collector:4318", "", "true", false},
+	}
+
+	for _, tt := range tests {
+		t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", tt.endpoint)
+		t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", tt.tracesEndpoint)
+		t.Setenv("OTEL_SDK_DISABLED", tt.disabled)
+		if got := Enabled(); got != tt.want {
+			t.Errorf("endpoint %q, traces endpoint %q, disabled %q: Enabled() = %v, want %v",
+				tt.endpoint, tt.tracesEndpoint, tt.disabled, got, tt.want)
+		}
+	}
+}
+
+func TestSetupWithoutEndpoint(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+
+	shutdown, err := Setup(context.Background())
+	if err != nil {
+		t.Fatalf("Setup: %v", err)
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Errorf("shutdown: %v", err)
+	}
+
+	// The no-op provider still hands out spans, which record nothing
+	_, span := StartValidationSpan(context.Background())
+	if span.IsRecording() {
+		t.Error("span is recording without an exporter")
+	}
+	span.End()
+}
@@ -173,12 +173,16 @@ document.addEventListener('DOMContentLoaded', function() {
             body: JSON.stringify(payload)
         })
         .then(response => {
-            if (!response.ok) {
+            // Rejected cards arrive as 422 with their full result when the server enables UNPROCESSABLE_ENTITY
+            if (!response.ok && response.status !== 422) {
                 throw new Error('Error: ' + response.status);
             }
             return response.json();
         })
         .then(data => {
+            if (data.error) {
+                throw new Error(data.error.message);
+            }
             displayResult(data);
         })
         .catch(error => {
@@ -0,0 +1,9 @@
+// Package web holds the frontend assets, embedded so the binary is self-contained.
+package web
+
+import "embed"
+
+// Assets contains the templates and static directories
+//
+//go:embed templates static
+var Assets embed.FS